@@ -2,9 +2,15 @@ package golib
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"github.com/golibs-starter/golib/actuator"
 	"github.com/golibs-starter/golib/event"
 	"github.com/golibs-starter/golib/log"
 	"github.com/golibs-starter/golib/pubsub"
+	"github.com/golibs-starter/golib/pubsub/outbox"
+	"github.com/golibs-starter/golib/utils"
+	webActuator "github.com/golibs-starter/golib/web/actuator"
 	"go.uber.org/fx"
 )
 
@@ -18,8 +24,21 @@ func EventOpt() fx.Option {
 		ProvideEventBusOpt(func(props *event.Properties) pubsub.EventBusOpt {
 			return pubsub.WithEventChannelSize(props.ChannelSize)
 		}),
+		ProvideEventBusOpt(NewEventBusOverflowOpt),
+		ProvideEventBusOpt(NewEventBusRetryOpt),
+		ProvideEventBusOpt(NewEventBusTimeoutOpt),
+		ProvideEventBusOpt(NewEventBusOutboxOpt),
+		ProvideEventBusOpt(NewEventBusIdempotencyOpt),
+		ProvideEventBusOpt(NewEventBusDisabledOpt),
+		fx.Provide(NewInMemoryDeadLetterStore),
+		ProvideEventBusOpt(func(store pubsub.DeadLetterStore) pubsub.EventBusOpt {
+			return pubsub.WithDeadLetterSink(store)
+		}),
 		fx.Provide(NewDefaultEventBus),
 		ProvideInformer(pubsub.NewDefaultBusInformer),
+		ProvideInformer(pubsub.NewDefaultBusSubscriberInformer),
+		ProvideHealthChecker(NewEventBusHealthChecker),
+		fx.Provide(NewDeadLetterEndpoint),
 
 		SupplyEventPublisherOpt(pubsub.WithPublisherDebugLog(func(ctx context.Context, msgFormat string, args ...interface{}) {
 			log.WithCtx(ctx).Debugf(msgFormat, args...)
@@ -27,10 +46,17 @@ func EventOpt() fx.Option {
 		ProvideEventPublisherOpt(func(props *event.Properties) pubsub.PublisherOpt {
 			return pubsub.WithPublisherNotLogPayload(props.Log.NotLogPayloadForEvents)
 		}),
+		ProvideEventPublisherOpt(NewEventPublisherSchemaOpt),
+		fx.Provide(NewEventScheduler),
+		ProvideEventPublisherOpt(func(scheduler *pubsub.EventScheduler) pubsub.PublisherOpt {
+			return pubsub.WithScheduler(scheduler)
+		}),
+		ProvideInformer(pubsub.NewEventSchedulerInformer),
 		fx.Provide(NewDefaultEventPublisher),
 
 		fx.Invoke(RegisterEventPublisher),
 		fx.Invoke(RunEventBus),
+		fx.Invoke(RunEventScheduler),
 	)
 }
 
@@ -38,8 +64,7 @@ func OnStopEventOpt() fx.Option {
 	return fx.Invoke(func(lc fx.Lifecycle, bus pubsub.EventBus) {
 		lc.Append(fx.Hook{
 			OnStop: func(ctx context.Context) error {
-				bus.Stop()
-				return nil
+				return pubsub.ShutdownBus(ctx, bus)
 			},
 		})
 	})
@@ -57,13 +82,164 @@ func ProvideEventBusOpt(optConstructor interface{}) fx.Option {
 	return fx.Provide(fx.Annotated{Group: "event_bus_opt", Target: optConstructor})
 }
 
+// SupplyEventHandleInterceptor registers an interceptor around handling of events,
+// interceptors are called in the order that they are provided.
+func SupplyEventHandleInterceptor(interceptor pubsub.HandleInterceptor) fx.Option {
+	return fx.Supply(fx.Annotated{Group: "event_handle_interceptor", Target: interceptor})
+}
+
+func ProvideEventHandleInterceptor(interceptorConstructor interface{}) fx.Option {
+	return fx.Provide(fx.Annotated{Group: "event_handle_interceptor", Target: interceptorConstructor})
+}
+
 type EventBusIn struct {
 	fx.In
-	Options []pubsub.EventBusOpt `group:"event_bus_opt"`
+	Options            []pubsub.EventBusOpt       `group:"event_bus_opt"`
+	HandleInterceptors []pubsub.HandleInterceptor `group:"event_handle_interceptor"`
 }
 
 func NewDefaultEventBus(in EventBusIn) pubsub.EventBus {
-	return pubsub.NewDefaultEventBus(in.Options...)
+	return pubsub.NewDefaultEventBus(append(in.Options, pubsub.WithHandleInterceptors(in.HandleInterceptors...))...)
+}
+
+// NewEventBusOverflowOpt creates an EventBusOpt to apply the overflow policy from the event properties
+func NewEventBusOverflowOpt(props *event.Properties) (pubsub.EventBusOpt, error) {
+	policy := pubsub.OverflowPolicy(props.OverflowPolicy)
+	if !policy.IsValid() {
+		return nil, fmt.Errorf("event overflow policy [%s] is not supported", props.OverflowPolicy)
+	}
+	return pubsub.WithOverflowPolicy(policy, props.BlockTimeout), nil
+}
+
+// NewEventBusRetryOpt creates an EventBusOpt to apply retry policies from the event properties
+func NewEventBusRetryOpt(props *event.Properties) pubsub.EventBusOpt {
+	return func(bus *pubsub.DefaultEventBus) {
+		defaultPolicy := pubsub.RetryPolicy{
+			MaxAttempts:     props.Retry.MaxAttempts,
+			InitialInterval: props.Retry.InitialInterval,
+			MaxInterval:     props.Retry.MaxInterval,
+			Multiplier:      props.Retry.Multiplier,
+			Jitter:          props.Retry.Jitter,
+		}
+		pubsub.WithRetryPolicy(defaultPolicy)(bus)
+		for _, subscriberProps := range props.Retry.Subscribers {
+			policy := defaultPolicy
+			if subscriberProps.MaxAttempts != nil {
+				policy.MaxAttempts = *subscriberProps.MaxAttempts
+			}
+			if subscriberProps.InitialInterval != nil {
+				policy.InitialInterval = *subscriberProps.InitialInterval
+			}
+			if subscriberProps.MaxInterval != nil {
+				policy.MaxInterval = *subscriberProps.MaxInterval
+			}
+			if subscriberProps.Multiplier != nil {
+				policy.Multiplier = *subscriberProps.Multiplier
+			}
+			if subscriberProps.Jitter != nil {
+				policy.Jitter = *subscriberProps.Jitter
+			}
+			pubsub.WithSubscriberRetryPolicy(subscriberProps.Subscriber, policy)(bus)
+		}
+	}
+}
+
+// NewEventBusTimeoutOpt creates an EventBusOpt to apply handler timeouts from the event properties
+func NewEventBusTimeoutOpt(props *event.Properties) pubsub.EventBusOpt {
+	return func(bus *pubsub.DefaultEventBus) {
+		pubsub.WithHandlerTimeout(props.Timeout.Handler)(bus)
+		for _, subscriberProps := range props.Timeout.Subscribers {
+			if subscriberProps.Handler != nil {
+				pubsub.WithSubscriberHandlerTimeout(subscriberProps.Subscriber, *subscriberProps.Handler)(bus)
+			}
+		}
+	}
+}
+
+// NewEventBusOutboxOpt creates an EventBusOpt to persist events to a FileOutbox when the outbox is enabled,
+// the outbox is closed after the bus is shutdown (see OnStopEventOpt).
+func NewEventBusOutboxOpt(lc fx.Lifecycle, props *event.Properties) (pubsub.EventBusOpt, error) {
+	if !props.Outbox.Enabled {
+		return func(bus *pubsub.DefaultEventBus) {}, nil
+	}
+	fileOutbox, err := outbox.NewFileOutbox(props.Outbox.Dir,
+		outbox.WithSegmentSize(props.Outbox.SegmentSize),
+		outbox.WithFsyncPolicy(outbox.FsyncPolicy(props.Outbox.FsyncPolicy), props.Outbox.FsyncInterval),
+	)
+	if err != nil {
+		return nil, err
+	}
+	lc.Append(fx.Hook{
+		OnStop: func(ctx context.Context) error {
+			return fileOutbox.Close()
+		},
+	})
+	return pubsub.WithOutbox(fileOutbox, props.Outbox.Events...), nil
+}
+
+// NewEventBusIdempotencyOpt creates an EventBusOpt to skip duplicated events when idempotency is enabled,
+// the file store is closed after the bus is shutdown (see OnStopEventOpt).
+func NewEventBusIdempotencyOpt(lc fx.Lifecycle, props *event.Properties) (pubsub.EventBusOpt, error) {
+	if !props.Idempotency.Enabled {
+		return func(bus *pubsub.DefaultEventBus) {}, nil
+	}
+	var store pubsub.IdempotencyStore
+	switch props.Idempotency.Store {
+	case "memory":
+		store = pubsub.NewInMemoryIdempotencyStore(props.Idempotency.TTL)
+	case "file":
+		fileStore, err := outbox.NewFileIdempotencyStore(props.Idempotency.File, props.Idempotency.TTL)
+		if err != nil {
+			return nil, err
+		}
+		lc.Append(fx.Hook{
+			OnStop: func(ctx context.Context) error {
+				return fileStore.Close()
+			},
+		})
+		store = fileStore
+	default:
+		return nil, fmt.Errorf("event idempotency store [%s] is not supported", props.Idempotency.Store)
+	}
+	return pubsub.WithIdempotency(store, props.Idempotency.Subscribers...), nil
+}
+
+// NewEventBusHealthChecker creates the health checker of the bus with the saturation threshold from the event properties
+func NewEventBusHealthChecker(bus pubsub.EventBus, props *event.Properties) (actuator.HealthChecker, error) {
+	return pubsub.NewDefaultBusHealthChecker(bus,
+		pubsub.WithSaturationThreshold(props.Health.SaturationRatio, props.Health.SaturationDuration))
+}
+
+// NewEventPublisherSchemaOpt creates a PublisherOpt to validate payloads by schemas of the global registry,
+// the validation mode is applied from the event properties
+func NewEventPublisherSchemaOpt(props *event.Properties) (pubsub.PublisherOpt, error) {
+	mode := pubsub.ValidationMode(props.Schema.Validation)
+	if !mode.IsValid() {
+		return nil, fmt.Errorf("event schema validation [%s] is not supported", props.Schema.Validation)
+	}
+	return pubsub.WithSchemaValidation(pubsub.GetEventTypeRegistry(), mode), nil
+}
+
+// NewEventBusDisabledOpt creates an EventBusOpt to switch off listeners and events from the event properties
+func NewEventBusDisabledOpt(props *event.Properties) pubsub.EventBusOpt {
+	return func(bus *pubsub.DefaultEventBus) {
+		pubsub.WithDisabledSubscribers(props.DisabledListeners...)(bus)
+		pubsub.WithDisabledEvents(props.DisabledEvents...)(bus)
+	}
+}
+
+func NewInMemoryDeadLetterStore(props *event.Properties) pubsub.DeadLetterStore {
+	return pubsub.NewInMemoryDeadLetterStore(props.DeadLetter.Capacity)
+}
+
+// NewDeadLetterEndpoint Initiate an endpoint to list and re-drive dead letters.
+// It's not registered to any router, the web framework should register its handlers.
+func NewDeadLetterEndpoint(store pubsub.DeadLetterStore, bus pubsub.EventBus) (*webActuator.DeadLetterEndpoint, error) {
+	redriver, ok := bus.(pubsub.DeadLetterRedriver)
+	if !ok {
+		return nil, errors.New("EventBus is not support to redrive dead letters")
+	}
+	return webActuator.NewDeadLetterEndpoint(store, redriver), nil
 }
 
 func SupplyEventPublisherOpt(opt pubsub.PublisherOpt) fx.Option {
@@ -74,28 +250,92 @@ func ProvideEventPublisherOpt(optConstructor interface{}) fx.Option {
 	return fx.Provide(fx.Annotated{Group: "event_publisher_opt", Target: optConstructor})
 }
 
+// SupplyEventPublishInterceptor registers an interceptor around publishing of events,
+// interceptors are called in the order that they are provided.
+func SupplyEventPublishInterceptor(interceptor pubsub.PublishInterceptor) fx.Option {
+	return fx.Supply(fx.Annotated{Group: "event_publish_interceptor", Target: interceptor})
+}
+
+func ProvideEventPublishInterceptor(interceptorConstructor interface{}) fx.Option {
+	return fx.Provide(fx.Annotated{Group: "event_publish_interceptor", Target: interceptorConstructor})
+}
+
 type EventPublisherIn struct {
 	fx.In
-	Bus     pubsub.EventBus
-	Options []pubsub.PublisherOpt `group:"event_publisher_opt"`
+	Bus          pubsub.EventBus
+	Options      []pubsub.PublisherOpt       `group:"event_publisher_opt"`
+	Interceptors []pubsub.PublishInterceptor `group:"event_publish_interceptor"`
 }
 
 func NewDefaultEventPublisher(in EventPublisherIn) pubsub.Publisher {
-	return pubsub.NewDefaultPublisher(in.Bus, in.Options...)
+	return pubsub.NewDefaultPublisher(in.Bus, append(in.Options, pubsub.WithPublishInterceptors(in.Interceptors...))...)
 }
 
 type RegisterEventPublisherIn struct {
 	fx.In
+	Props       *event.Properties
 	Bus         pubsub.EventBus
 	Publisher   pubsub.Publisher
 	Subscribers []pubsub.Subscriber `group:"event_listener"`
 }
 
+// RegisterEventPublisher registers listeners to the bus and replaces the global bus and publisher.
+// Disabled listeners are registered to a bus that supports switching off subscribers (such as DefaultEventBus),
+// so that they are shown in the bus informer, otherwise they are not registered.
 func RegisterEventPublisher(in RegisterEventPublisherIn) {
 	pubsub.ReplaceGlobal(in.Bus, in.Publisher)
-	in.Bus.Register(in.Subscribers...)
+	_, supportsDisabling := in.Bus.(interface{ SetDisabledSubscribers(patterns ...string) })
+	subscribers := make([]pubsub.Subscriber, 0, len(in.Subscribers))
+	for _, subscriber := range in.Subscribers {
+		name := utils.GetStructFullname(subscriber)
+		if len(in.Props.DisabledListeners) == 0 || !utils.MatchAnyWildcard(in.Props.DisabledListeners, name) {
+			subscribers = append(subscribers, subscriber)
+			continue
+		}
+		log.Infof("Event listener [%s] is disabled by config", name)
+		if supportsDisabling {
+			subscribers = append(subscribers, subscriber)
+		}
+	}
+	in.Bus.Register(subscribers...)
 }
 
 func RunEventBus(bus pubsub.EventBus) {
 	bus.Run()
 }
+
+// NewEventScheduler creates the scheduler of PublishAt and PublishAfter,
+// scheduled events are kept in a FileScheduleStore when the schedule is persistent.
+// Events that are dropped after failed attempts are put to the dead letter store.
+func NewEventScheduler(props *event.Properties, deadLetterStore pubsub.DeadLetterStore) (*pubsub.EventScheduler, error) {
+	opts := []pubsub.EventSchedulerOpt{
+		pubsub.WithSchedulerRetryInterval(props.Schedule.RetryInterval),
+		pubsub.WithSchedulerMaxAttempts(props.Schedule.MaxAttempts),
+		pubsub.WithSchedulerDeadLetterSink(deadLetterStore),
+		pubsub.WithSchedulerDebugLog(func(ctx context.Context, msgFormat string, args ...interface{}) {
+			log.WithCtx(ctx).Debugf(msgFormat, args...)
+		}),
+	}
+	if props.Schedule.Persistent {
+		store, err := outbox.NewFileScheduleStore(props.Schedule.File)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, pubsub.WithScheduleStore(store))
+	}
+	return pubsub.NewEventScheduler(opts...), nil
+}
+
+// RunEventScheduler starts publishing scheduled events when they are due,
+// the scheduler is stopped when the application stops.
+func RunEventScheduler(lc fx.Lifecycle, scheduler *pubsub.EventScheduler, publisher pubsub.Publisher) error {
+	lc.Append(fx.Hook{
+		OnStop: func(ctx context.Context) error {
+			scheduler.Stop()
+			return nil
+		},
+	})
+	return scheduler.Start(func(ctx context.Context, event pubsub.Event) error {
+		return pubsub.PublishCtxOn(ctx, publisher, event)
+	})
+}
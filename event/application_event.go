@@ -25,6 +25,17 @@ func NewApplicationEvent(ctx context.Context, name string, options ...AppEventOp
 	if e.Source == "" {
 		e.Source = DefaultEventSource
 	}
+	// An event that created inside the handling of another event is caused by that event,
+	// and is in the same correlation chain, the first event of a chain correlates to itself.
+	if cause := GetCause(ctx); cause != nil && e.CausationId == "" && cause.EventId != e.Id {
+		e.CausationId = cause.EventId
+		if e.CorrelationId == "" {
+			e.CorrelationId = cause.CorrelationId
+		}
+	}
+	if e.CorrelationId == "" {
+		e.CorrelationId = e.Id
+	}
 	e.Event = name
 	e.Timestamp = utils.Time2Ms(time.Now())
 	return &e
@@ -36,6 +47,9 @@ type ApplicationEvent struct {
 	Event          string                 `json:"event"`
 	Source         string                 `json:"source"`
 	ServiceCode    string                 `json:"service_code"`
+	CorrelationId  string                 `json:"correlation_id,omitempty"`
+	CausationId    string                 `json:"causation_id,omitempty"`
+	SchemaVersion  int                    `json:"schema_version,omitempty"`
 	AdditionalData map[string]interface{} `json:"additional_data,omitempty"`
 	PayloadData    interface{}            `json:"payload"`
 	Timestamp      int64                  `json:"timestamp"`
@@ -57,6 +71,23 @@ func (a *ApplicationEvent) Payload() interface{} {
 	return a.PayloadData
 }
 
+// AsCause returns the Cause of events that are created inside the handling of this event
+func (a *ApplicationEvent) AsCause() Cause {
+	if a == nil {
+		return Cause{}
+	}
+	return Cause{EventId: a.Id, CorrelationId: a.CorrelationId, CausationId: a.CausationId}
+}
+
+// GetSchemaVersion returns the schema version of the payload, 0 means no version
+func (a *ApplicationEvent) GetSchemaVersion() int {
+	return a.SchemaVersion
+}
+
+func (a *ApplicationEvent) SetSchemaVersion(version int) {
+	a.SchemaVersion = version
+}
+
 func (a *ApplicationEvent) AddAdditionData(key string, value interface{}) {
 	if a.AdditionalData == nil {
 		a.AdditionalData = make(map[string]interface{})
@@ -119,3 +119,19 @@ func TestApplicationEvent_WhenDeleteAdditionalData_ShouldDeleteCorrectly(t *test
 		"key2": "val2",
 	}, e.AdditionalData)
 }
+
+func TestNewApplicationEvent_WhenContextHasCause_ShouldSetCorrelationAndCausation(t *testing.T) {
+	ctx := WithCause(context.Background(), Cause{EventId: "cause-id", CorrelationId: "chain-id"})
+	e := NewApplicationEvent(ctx, "TestEvent")
+	assert.Equal(t, "chain-id", e.CorrelationId)
+	assert.Equal(t, "cause-id", e.CausationId)
+	assert.Equal(t, Cause{EventId: e.Id, CorrelationId: "chain-id", CausationId: "cause-id"}, e.AsCause())
+
+	e = NewApplicationEvent(ctx, "TestEvent", WithCorrelationId("another-chain"), WithCausationId("another-cause"))
+	assert.Equal(t, "another-chain", e.CorrelationId)
+	assert.Equal(t, "another-cause", e.CausationId)
+
+	e = NewApplicationEvent(context.Background(), "TestEvent")
+	assert.Equal(t, e.Id, e.CorrelationId)
+	assert.Empty(t, e.CausationId)
+}
@@ -0,0 +1,27 @@
+package event
+
+import "context"
+
+type causeContextKey struct{}
+
+// Cause describes the event that causes follow-up events,
+// events that are created with a context carrying a Cause are its follow-up events.
+type Cause struct {
+	EventId       string
+	CorrelationId string
+	CausationId   string
+}
+
+// WithCause returns a copy of ctx that carries the cause of follow-up events
+func WithCause(ctx context.Context, cause Cause) context.Context {
+	return context.WithValue(ctx, causeContextKey{}, &cause)
+}
+
+// GetCause returns the cause that carried by ctx, or nil when there is no cause
+func GetCause(ctx context.Context) *Cause {
+	if ctx == nil {
+		return nil
+	}
+	cause, _ := ctx.Value(causeContextKey{}).(*Cause)
+	return cause
+}
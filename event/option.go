@@ -20,6 +20,20 @@ func WithServiceCode(serviceCode string) AppEventOpt {
 	}
 }
 
+// WithCorrelationId sets the correlation id instead of inheriting it from the cause
+func WithCorrelationId(correlationId string) AppEventOpt {
+	return func(event *ApplicationEvent) {
+		event.CorrelationId = correlationId
+	}
+}
+
+// WithCausationId sets the id of the event that causes this event
+func WithCausationId(causationId string) AppEventOpt {
+	return func(event *ApplicationEvent) {
+		event.CausationId = causationId
+	}
+}
+
 func WithAdditionalData(additionalData map[string]interface{}) AppEventOpt {
 	return func(event *ApplicationEvent) {
 		event.AdditionalData = additionalData
@@ -1,6 +1,9 @@
 package event
 
-import "github.com/golibs-starter/golib/config"
+import (
+	"github.com/golibs-starter/golib/config"
+	"time"
+)
 
 func NewProperties(loader config.Loader) (*Properties, error) {
 	props := Properties{}
@@ -10,13 +13,245 @@ func NewProperties(loader config.Loader) (*Properties, error) {
 
 type Properties struct {
 	ChannelSize int `default:"10"`
+
+	// OverflowPolicy defines the behavior when the event channel is full,
+	// accepted values: block, block_timeout, drop_newest, drop_oldest, reject
+	OverflowPolicy string `default:"block"`
+
+	// BlockTimeout is the maximum time to wait for free space
+	// in the event channel, used by block_timeout policy
+	BlockTimeout time.Duration `default:"1s"`
+
+	// DisabledListeners are full names (or patterns with `*`) of listeners that are switched off,
+	// events are not dispatched to them.
+	DisabledListeners []string
+
+	// DisabledEvents are names (or patterns with `*`) of events that are suppressed,
+	// they are neither queued nor dispatched to listeners.
+	DisabledEvents []string
+
+	// Buses are named buses that are isolated from the default bus, such as a bus of telemetry events,
+	// a named bus is wired by golib.NamedEventBusOpt(name).
+	Buses map[string]BusProperties
+
 	Log         LogProperties
+	Retry       RetryProperties
+	Timeout     TimeoutProperties
+	DeadLetter  DeadLetterProperties
+	Outbox      OutboxProperties
+	Schedule    ScheduleProperties
+	Idempotency IdempotencyProperties
+	Health      HealthProperties
+	Schema      SchemaProperties
 }
 
 func (p Properties) Prefix() string {
 	return "app.event"
 }
 
+type BusProperties struct {
+	// ChannelSize of the bus, the channel size of the default bus is used when it's zero
+	ChannelSize int
+
+	// OverflowPolicy of the bus, the policy of the default bus is used when it's empty
+	OverflowPolicy string
+	BlockTimeout   time.Duration
+
+	// Executor runs handlings of the bus, accepted values: async, partitioned.
+	// Default is async
+	Executor string
+
+	// Partitions is the number of workers of the partitioned executor
+	Partitions int
+
+	// PartitionQueueSize is the number of handlings that each worker buffers, the bus waits
+	// for a full worker, so handlers that publish to the bus should not wait forever
+	// for the event channel (see executor.PartitionedExecutor).
+	PartitionQueueSize int
+
+	// Events are names (or patterns with `*`) of events that are published to the bus
+	Events []string
+
+	// EventTypes are full names of event structs (or patterns with `*`) that are published to the bus,
+	// eg: event.RequestCompletedEvent
+	EventTypes []string
+
+	DeadLetter  BusDeadLetterProperties
+	Outbox      BusOutboxProperties
+	Idempotency BusIdempotencyProperties
+}
+
+type BusDeadLetterProperties struct {
+	// Capacity of the dead letter store of the bus,
+	// the capacity of the default dead letter store is used when it's zero
+	Capacity int
+}
+
+type BusOutboxProperties struct {
+	// Enabled persists events of the bus to its own write-ahead log,
+	// segment size and fsync policy are the ones of the default outbox.
+	Enabled bool
+
+	// Dir is the directory of write-ahead log segments,
+	// default is <name> under the directory of the default outbox
+	Dir string
+
+	// Events are names (or patterns with `*`) of persisted events,
+	// all events are persisted when it's empty.
+	Events []string
+}
+
+type BusIdempotencyProperties struct {
+	// Enabled skips events that subscribers of the bus processed already,
+	// store and TTL are the ones of the default bus.
+	Enabled bool
+
+	// Subscribers are ids (or patterns with `*`) of idempotent subscribers,
+	// all subscribers are idempotent when it's empty.
+	Subscribers []string
+
+	// File is the path of the file store,
+	// default is the file of the default store suffixed by .<name>
+	File string
+}
+
 type LogProperties struct {
 	NotLogPayloadForEvents []string
 }
+
+type RetryProperties struct {
+	// MaxAttempts is the maximum number of handling attempts
+	// for all subscribers, includes the first one.
+	// Default is 1, means no retry.
+	MaxAttempts int `default:"1"`
+
+	// InitialInterval is the backoff before the first retry
+	InitialInterval time.Duration `default:"100ms"`
+
+	// MaxInterval caps the backoff between two attempts
+	MaxInterval time.Duration `default:"10s"`
+
+	// Multiplier is the factor that the backoff is multiplied by after each retry
+	Multiplier float64 `default:"2"`
+
+	// Jitter randomizes the backoff, in range [0, 1]
+	Jitter float64 `default:"0.2"`
+
+	// Subscribers overrides the retry policy for specific subscribers,
+	// unset fields fallback to the above default values.
+	Subscribers []SubscriberRetryProperties
+}
+
+type SubscriberRetryProperties struct {
+	// Subscriber is the id or the full name (or a pattern with `*`) of subscribers,
+	// eg: listener.RequestCompletedLogListener. The first matched override is used.
+	Subscriber string
+
+	// Unset fields are nil, so that they can be overridden by zero, such as jitter: 0
+	MaxAttempts     *int
+	InitialInterval *time.Duration
+	MaxInterval     *time.Duration
+	Multiplier      *float64
+	Jitter          *float64
+}
+
+type TimeoutProperties struct {
+	// Handler is the maximum duration of a handling attempt for all subscribers,
+	// zero means no timeout
+	Handler time.Duration
+
+	// Subscribers overrides the handler timeout for specific subscribers
+	Subscribers []SubscriberTimeoutProperties
+}
+
+type SubscriberTimeoutProperties struct {
+	// Subscriber is the id or the full name (or a pattern with `*`) of subscribers,
+	// eg: listener.RequestCompletedLogListener. The first matched override is used.
+	Subscriber string
+
+	// Handler is the handler timeout of the subscribers, zero means no timeout.
+	// The override is skipped when it's unset.
+	Handler *time.Duration
+}
+
+type DeadLetterProperties struct {
+	// Capacity is the maximum number of dead letters
+	// kept in memory, the oldest one is evicted when it's full.
+	Capacity int `default:"1000"`
+}
+
+type OutboxProperties struct {
+	// Enabled persists events to a write-ahead log before they are accepted,
+	// pending events are replayed when the application starts again.
+	Enabled bool
+
+	// Dir is the directory of write-ahead log segments
+	Dir string `default:"./data/event-outbox"`
+
+	// Events are names (or patterns with `*`) of persisted events,
+	// all events are persisted when it's empty.
+	Events []string
+
+	// SegmentSize in bytes, the active segment is compacted when it exceeds
+	SegmentSize int64 `default:"67108864"`
+
+	// FsyncPolicy defines when records are flushed to the disk,
+	// accepted values: always, interval, never
+	FsyncPolicy string `default:"always"`
+
+	// FsyncInterval is used by interval fsync policy
+	FsyncInterval time.Duration `default:"1s"`
+}
+
+type ScheduleProperties struct {
+	// RetryInterval is the time to wait before publishing
+	// a due event again when it failed to publish
+	RetryInterval time.Duration `default:"1s"`
+
+	// MaxAttempts is the maximum number of publishing attempts of a due event,
+	// the event is dropped to the dead letter store when all attempts failed.
+	// A negative value means unlimited.
+	MaxAttempts int `default:"10"`
+
+	// Persistent keeps scheduled events in a file,
+	// they are scheduled again when the application starts.
+	Persistent bool
+
+	// File is the path of the schedule file
+	File string `default:"./data/event-schedule.json"`
+}
+
+type IdempotencyProperties struct {
+	// Enabled skips events that subscribers processed already,
+	// such as events that are redelivered by retries or replays.
+	Enabled bool
+
+	// Subscribers are ids (or patterns with `*`) of idempotent subscribers,
+	// all subscribers are idempotent when it's empty.
+	Subscribers []string
+
+	// Store keeps processed events, accepted values: memory, file
+	Store string `default:"memory"`
+
+	// TTL is the time that a processed event is remembered
+	TTL time.Duration `default:"24h"`
+
+	// File is the path of the file store
+	File string `default:"./data/event-idempotency.log"`
+}
+
+type HealthProperties struct {
+	// SaturationRatio is the ratio of the channel capacity
+	// that the event channel is considered saturated
+	SaturationRatio float64 `default:"0.9"`
+
+	// SaturationDuration is the time that the event channel
+	// stays saturated before the event bus is DOWN
+	SaturationDuration time.Duration `default:"30s"`
+}
+
+type SchemaProperties struct {
+	// Validation is the behavior when a payload does not match its schema,
+	// accepted values: fail, warn, off
+	Validation string `default:"warn"`
+}
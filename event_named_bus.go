@@ -0,0 +1,240 @@
+package golib
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/golibs-starter/golib/actuator"
+	"github.com/golibs-starter/golib/event"
+	"github.com/golibs-starter/golib/log"
+	"github.com/golibs-starter/golib/pubsub"
+	"github.com/golibs-starter/golib/pubsub/executor"
+	"go.uber.org/fx"
+)
+
+// NamedEventBusOpt wires an isolated bus with its own channel, executor and dead letter store,
+// it's configured under app.event.buses.<name> and requires EventOpt.
+//
+// The bus is provided with the name tag `name:"event_bus.<name>"` (see NamedEventBusTag),
+// and its dead letter store with `name:"event_dead_letter_store.<name>"` (see NamedDeadLetterStoreTag).
+// Listeners of ProvideNamedEventListener are registered to it, events of app.event.buses.<name>.events
+// and app.event.buses.<name>.eventTypes are published to it by the global publisher.
+// The bus is shutdown when the application stops.
+func NamedEventBusOpt(name string) fx.Option {
+	busTag, deadLetterStoreTag := NamedEventBusTag(name), NamedDeadLetterStoreTag(name)
+	return fx.Options(
+		fx.Provide(fx.Annotate(
+			func(props *event.Properties) pubsub.DeadLetterStore {
+				return NewNamedDeadLetterStore(name, props)
+			},
+			fx.ResultTags(deadLetterStoreTag),
+		)),
+		fx.Provide(fx.Annotate(
+			func(lc fx.Lifecycle, props *event.Properties, deadLetterStore pubsub.DeadLetterStore,
+				interceptors []pubsub.HandleInterceptor) (pubsub.EventBus, error) {
+				return NewNamedEventBus(lc, name, props, deadLetterStore, interceptors)
+			},
+			fx.ParamTags(``, ``, deadLetterStoreTag, `group:"event_handle_interceptor"`),
+			fx.ResultTags(busTag),
+		)),
+		fx.Provide(fx.Annotate(
+			func(bus pubsub.EventBus, props *event.Properties) pubsub.PublisherOpt {
+				return NewNamedEventRouteOpt(name, bus, props)
+			},
+			fx.ParamTags(busTag),
+			fx.ResultTags(`group:"event_publisher_opt"`),
+		)),
+		fx.Provide(fx.Annotate(
+			func(bus pubsub.EventBus) (actuator.Informer, error) {
+				return pubsub.NewNamedBusInformer(name, bus)
+			},
+			fx.ParamTags(busTag),
+			fx.ResultTags(`group:"actuator_informer"`),
+		)),
+		fx.Provide(fx.Annotate(
+			func(bus pubsub.EventBus) (actuator.Informer, error) {
+				return pubsub.NewNamedBusSubscriberInformer(name, bus)
+			},
+			fx.ParamTags(busTag),
+			fx.ResultTags(`group:"actuator_informer"`),
+		)),
+		fx.Provide(fx.Annotate(
+			func(bus pubsub.EventBus, props *event.Properties) (actuator.HealthChecker, error) {
+				return pubsub.NewNamedBusHealthChecker(name, bus,
+					pubsub.WithSaturationThreshold(props.Health.SaturationRatio, props.Health.SaturationDuration))
+			},
+			fx.ParamTags(busTag),
+			fx.ResultTags(`group:"actuator_health_checker"`),
+		)),
+		fx.Invoke(fx.Annotate(
+			func(lc fx.Lifecycle, bus pubsub.EventBus, subscribers []pubsub.Subscriber) {
+				RunNamedEventBus(lc, name, bus, subscribers)
+			},
+			fx.ParamTags(``, busTag, fmt.Sprintf(`group:"event_listener.%s"`, name)),
+		)),
+	)
+}
+
+// NamedEventBusTag returns the fx tag of a named bus, such as
+// `name:"event_bus.telemetry"` for the bus of NamedEventBusOpt("telemetry").
+func NamedEventBusTag(name string) string {
+	return fmt.Sprintf(`name:"event_bus.%s"`, name)
+}
+
+// NamedDeadLetterStoreTag returns the fx tag of the dead letter store of a named bus, such as
+// `name:"event_dead_letter_store.telemetry"` for the bus of NamedEventBusOpt("telemetry").
+func NamedDeadLetterStoreTag(name string) string {
+	return fmt.Sprintf(`name:"event_dead_letter_store.%s"`, name)
+}
+
+// ProvideNamedEventListener registers a listener to the named bus instead of the default bus
+func ProvideNamedEventListener(busName string, listener interface{}) fx.Option {
+	return fx.Provide(fx.Annotated{Group: "event_listener." + busName, Target: listener})
+}
+
+// NewNamedEventBus creates a named bus from app.event.buses.<name>, unset channel size
+// and overflow policy fallback to the ones of the default bus. Retry policies, handler timeouts,
+// disabled listeners and events, and handle interceptors are shared with the default bus.
+// Failed events are kept in the dead letter store of the bus, the outbox and the idempotency store
+// are the bus's own ones when they are enabled under app.event.buses.<name>.
+func NewNamedEventBus(lc fx.Lifecycle, name string, props *event.Properties,
+	deadLetterStore pubsub.DeadLetterStore, interceptors []pubsub.HandleInterceptor) (pubsub.EventBus, error) {
+	busProps, _ := namedBusProperties(props, name)
+	durabilityProps, err := namedBusDurabilityProperties(props, name, busProps)
+	if err != nil {
+		return nil, err
+	}
+	outboxOpt, err := NewEventBusOutboxOpt(lc, durabilityProps)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create outbox of event bus [%s]: %w", name, err)
+	}
+	idempotencyOpt, err := NewEventBusIdempotencyOpt(lc, durabilityProps)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create idempotency store of event bus [%s]: %w", name, err)
+	}
+	channelSize := busProps.ChannelSize
+	if channelSize == 0 {
+		channelSize = props.ChannelSize
+	}
+	overflowPolicy, blockTimeout := pubsub.OverflowPolicy(busProps.OverflowPolicy), busProps.BlockTimeout
+	if overflowPolicy == "" {
+		overflowPolicy = pubsub.OverflowPolicy(props.OverflowPolicy)
+	}
+	if blockTimeout == 0 {
+		blockTimeout = props.BlockTimeout
+	}
+	if !overflowPolicy.IsValid() {
+		return nil, fmt.Errorf("overflow policy [%s] of event bus [%s] is not supported", overflowPolicy, name)
+	}
+	var busExecutor pubsub.Executor
+	switch busProps.Executor {
+	case "", "async":
+		busExecutor = executor.NewAsyncExecutor()
+	case "partitioned":
+		partitionedExecutor := executor.NewPartitionedExecutor(busProps.Partitions, busProps.PartitionQueueSize)
+		lc.Append(fx.Hook{
+			OnStop: func(ctx context.Context) error {
+				partitionedExecutor.Close()
+				return nil
+			},
+		})
+		busExecutor = partitionedExecutor
+	default:
+		return nil, fmt.Errorf("executor [%s] of event bus [%s] is not supported", busProps.Executor, name)
+	}
+	return pubsub.NewDefaultEventBus(
+		pubsub.WithEventBusDebugLog(func(ctx context.Context, msgFormat string, args ...interface{}) {
+			log.WithCtx(ctx).Debugf("[%s] "+msgFormat, append([]interface{}{name}, args...)...)
+		}),
+		pubsub.WithEventChannelSize(channelSize),
+		pubsub.WithOverflowPolicy(overflowPolicy, blockTimeout),
+		pubsub.WithEventExecutor(busExecutor),
+		NewEventBusRetryOpt(props),
+		NewEventBusTimeoutOpt(props),
+		NewEventBusDisabledOpt(props),
+		outboxOpt,
+		idempotencyOpt,
+		pubsub.WithDeadLetterSink(deadLetterStore),
+		pubsub.WithHandleInterceptors(interceptors...),
+	), nil
+}
+
+// NewNamedDeadLetterStore creates the dead letter store of a named bus,
+// its capacity fallbacks to the one of the default store.
+func NewNamedDeadLetterStore(name string, props *event.Properties) pubsub.DeadLetterStore {
+	busProps, _ := namedBusProperties(props, name)
+	capacity := busProps.DeadLetter.Capacity
+	if capacity == 0 {
+		capacity = props.DeadLetter.Capacity
+	}
+	return pubsub.NewInMemoryDeadLetterStore(capacity)
+}
+
+// NewNamedEventRouteOpt creates a PublisherOpt to publish events of app.event.buses.<name>.events
+// and app.event.buses.<name>.eventTypes to the named bus.
+func NewNamedEventRouteOpt(name string, bus pubsub.EventBus, props *event.Properties) pubsub.PublisherOpt {
+	busProps, _ := namedBusProperties(props, name)
+	return func(pub *pubsub.DefaultPublisher) {
+		pubsub.WithEventRoute(bus, busProps.Events...)(pub)
+		pubsub.WithEventTypeNameRoute(bus, busProps.EventTypes...)(pub)
+	}
+}
+
+// RunNamedEventBus registers listeners to the named bus, replaces the global bus of the name and runs it.
+// The bus is shutdown when the application stops.
+func RunNamedEventBus(lc fx.Lifecycle, name string, bus pubsub.EventBus, subscribers []pubsub.Subscriber) {
+	pubsub.ReplaceGlobalNamed(name, bus)
+	bus.Register(subscribers...)
+	bus.Run()
+	lc.Append(fx.Hook{
+		OnStop: func(ctx context.Context) error {
+			return pubsub.ShutdownBus(ctx, bus)
+		},
+	})
+}
+
+// namedBusDurabilityProperties returns the outbox and idempotency properties of a named bus
+// in the form of the default bus, unset paths are derived from the default ones by the name.
+// The default bus and the named bus cannot share an outbox directory or an idempotency file.
+func namedBusDurabilityProperties(props *event.Properties, name string,
+	busProps event.BusProperties) (*event.Properties, error) {
+	durabilityProps := *props
+	durabilityProps.Outbox.Enabled = busProps.Outbox.Enabled
+	durabilityProps.Outbox.Events = busProps.Outbox.Events
+	durabilityProps.Outbox.Dir = busProps.Outbox.Dir
+	if durabilityProps.Outbox.Dir == "" {
+		durabilityProps.Outbox.Dir = filepath.Join(props.Outbox.Dir, name)
+	}
+	if props.Outbox.Enabled && busProps.Outbox.Enabled &&
+		filepath.Clean(durabilityProps.Outbox.Dir) == filepath.Clean(props.Outbox.Dir) {
+		return nil, fmt.Errorf("outbox dir [%s] of event bus [%s] is used by the default bus", busProps.Outbox.Dir, name)
+	}
+	durabilityProps.Idempotency.Enabled = busProps.Idempotency.Enabled
+	durabilityProps.Idempotency.Subscribers = busProps.Idempotency.Subscribers
+	durabilityProps.Idempotency.File = busProps.Idempotency.File
+	if durabilityProps.Idempotency.File == "" {
+		durabilityProps.Idempotency.File = props.Idempotency.File + "." + name
+	}
+	if props.Idempotency.Enabled && busProps.Idempotency.Enabled && props.Idempotency.Store == "file" &&
+		filepath.Clean(durabilityProps.Idempotency.File) == filepath.Clean(props.Idempotency.File) {
+		return nil, fmt.Errorf("idempotency file [%s] of event bus [%s] is used by the default bus",
+			busProps.Idempotency.File, name)
+	}
+	return &durabilityProps, nil
+}
+
+// namedBusProperties returns properties of a named bus, names are case-insensitive
+// since keys of the config are lowercase.
+func namedBusProperties(props *event.Properties, name string) (event.BusProperties, bool) {
+	if busProps, exists := props.Buses[name]; exists {
+		return busProps, true
+	}
+	for busName, busProps := range props.Buses {
+		if strings.EqualFold(busName, name) {
+			return busProps, true
+		}
+	}
+	return event.BusProperties{}, false
+}
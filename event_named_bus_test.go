@@ -0,0 +1,94 @@
+package golib
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/golibs-starter/golib/event"
+	"github.com/golibs-starter/golib/pubsub"
+	assert "github.com/stretchr/testify/require"
+	"go.uber.org/fx/fxtest"
+)
+
+func namedBusInfo(t *testing.T, name string, bus pubsub.EventBus) map[string]interface{} {
+	informer, err := pubsub.NewNamedBusInformer(name, bus)
+	assert.NoError(t, err)
+	assert.Equal(t, "event_bus."+name, informer.Key())
+	return informer.Value().(map[string]interface{})
+}
+
+func TestNewNamedEventBus_ShouldFallbackToDefaultBusProperties(t *testing.T) {
+	props := &event.Properties{
+		ChannelSize:    10,
+		OverflowPolicy: "block",
+		Buses: map[string]event.BusProperties{
+			"telemetry": {ChannelSize: 500, OverflowPolicy: "drop_oldest", Executor: "partitioned", Partitions: 2},
+			"audit":     {},
+		},
+	}
+	lc := fxtest.NewLifecycle(t)
+	// Keys of the config are lowercase
+	bus, err := NewNamedEventBus(lc, "Telemetry", props, nil, nil)
+	assert.NoError(t, err)
+	info := namedBusInfo(t, "Telemetry", bus)
+	assert.Equal(t, 500, info["channel_capacity"])
+	assert.Equal(t, pubsub.OverflowDropOldest, info["overflow_policy"])
+
+	bus, err = NewNamedEventBus(lc, "audit", props, nil, nil)
+	assert.NoError(t, err)
+	info = namedBusInfo(t, "audit", bus)
+	assert.Equal(t, 10, info["channel_capacity"])
+	assert.Equal(t, pubsub.OverflowBlock, info["overflow_policy"])
+	lc.RequireStart().RequireStop()
+}
+
+func TestNewNamedEventBus_WhenExecutorIsNotSupported_ShouldReturnError(t *testing.T) {
+	props := &event.Properties{
+		ChannelSize:    10,
+		OverflowPolicy: "block",
+		Buses:          map[string]event.BusProperties{"telemetry": {Executor: "sync"}},
+	}
+	_, err := NewNamedEventBus(fxtest.NewLifecycle(t), "telemetry", props, nil, nil)
+	assert.Error(t, err)
+}
+
+func TestNewNamedEventBus_WhenOutboxIsEnabled_ShouldUseOwnDirectory(t *testing.T) {
+	dir := t.TempDir()
+	props := &event.Properties{
+		ChannelSize:    10,
+		OverflowPolicy: "block",
+		Outbox:         event.OutboxProperties{Enabled: true, Dir: dir, FsyncPolicy: "never"},
+		Idempotency:    event.IdempotencyProperties{Store: "memory"},
+		Buses:          map[string]event.BusProperties{"telemetry": {Outbox: event.BusOutboxProperties{Enabled: true}}},
+	}
+	lc := fxtest.NewLifecycle(t)
+	_, err := NewNamedEventBus(lc, "telemetry", props, pubsub.NewInMemoryDeadLetterStore(10), nil)
+	assert.NoError(t, err)
+	assert.DirExists(t, filepath.Join(dir, "telemetry"))
+	lc.RequireStart().RequireStop()
+
+	props.Buses["telemetry"] = event.BusProperties{Outbox: event.BusOutboxProperties{Enabled: true, Dir: dir + "/"}}
+	_, err = NewNamedEventBus(fxtest.NewLifecycle(t), "telemetry", props, nil, nil)
+	assert.ErrorContains(t, err, "is used by the default bus")
+}
+
+func TestNewNamedEventBus_WhenIdempotencyFileIsShared_ShouldReturnError(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "idempotency.log")
+	props := &event.Properties{
+		ChannelSize:    10,
+		OverflowPolicy: "block",
+		Idempotency:    event.IdempotencyProperties{Enabled: true, Store: "file", File: file},
+		Buses: map[string]event.BusProperties{
+			"telemetry": {Idempotency: event.BusIdempotencyProperties{Enabled: true}},
+			"audit":     {Idempotency: event.BusIdempotencyProperties{Enabled: true, File: file}},
+		},
+	}
+	lc := fxtest.NewLifecycle(t)
+	_, err := NewNamedEventBus(lc, "telemetry", props, nil, nil)
+	assert.NoError(t, err)
+	assert.FileExists(t, file+".telemetry")
+	lc.RequireStart().RequireStop()
+
+	_, err = NewNamedEventBus(fxtest.NewLifecycle(t), "audit", props, nil, nil)
+	assert.ErrorContains(t, err, "is used by the default bus")
+}
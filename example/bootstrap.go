@@ -5,6 +5,8 @@ package example
 // ==================================================
 
 import (
+	"context"
+
 	"github.com/golibs-starter/golib"
 	"github.com/golibs-starter/golib/pubsub"
 	"github.com/golibs-starter/golib/pubsub/executor"
@@ -24,11 +26,27 @@ func All() fx.Option {
 		golib.EventOpt(),
 		// When you want handle event in simple synchronous way
 		golib.SupplyEventBusOpt(pubsub.WithEventExecutor(executor.NewSyncExecutor())),
+		// Or when you want events with the same ordering key (see pubsub.OrderingKeyProvider)
+		// are handled in order, while events with different keys are handled in parallel.
+		// The executor is closed after the bus is shutdown (see golib.OnStopEventOpt)
+		golib.ProvideEventBusOpt(func(lc fx.Lifecycle) pubsub.EventBusOpt {
+			partitionedExecutor := executor.NewPartitionedExecutor(8, 100)
+			lc.Append(fx.Hook{
+				OnStop: func(ctx context.Context) error {
+					partitionedExecutor.Close()
+					return nil
+				},
+			})
+			return pubsub.WithEventExecutor(partitionedExecutor)
+		}),
 		// Or want a custom executor, such as using worker pool
 		fx.Provide(NewSampleEventExecutor),
 		golib.ProvideEventBusOpt(func(executor *SampleEventExecutor) pubsub.EventBusOpt {
 			return pubsub.WithEventExecutor(executor)
 		}),
+		// When you want to intercept publishing or handling of events.
+		golib.ProvideEventHandleInterceptor(NewSampleHandleInterceptor),
+		golib.SupplyEventPublishInterceptor(SampleTenantPublishInterceptor),
 
 		// When you want to enable http request log
 		golib.HttpRequestLogOpt(),
@@ -53,6 +71,10 @@ func All() fx.Option {
 
 		// When you want to register your event listener.
 		golib.ProvideEventListener(NewSampleListener),
+		// Or register typed handlers directly.
+		fx.Invoke(RegisterSampleHandlers),
+		// Declare the payload schema of an event.
+		fx.Invoke(RegisterSampleEventSchema),
 
 		// Graceful shutdown.
 		// OnStop hooks will run in reverse order.
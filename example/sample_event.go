@@ -6,7 +6,9 @@ package example
 
 import (
 	"context"
+	"encoding/json"
 	baseEvent "github.com/golibs-starter/golib/event"
+	"github.com/golibs-starter/golib/pubsub"
 	"github.com/golibs-starter/golib/web/event"
 )
 
@@ -26,6 +28,33 @@ func (a SampleEvent) String() string {
 }
 
 type SampleEventMessage struct {
-	Field1 string
+	Field1 string `validate:"required"`
 	Field2 string
 }
+
+// RegisterSampleEventSchema declares that payloads of SampleEvent are in version 2,
+// they are validated by `validate` tags before publishing.
+// Payloads of version 1 (that has Field instead of Field1) are upcasted when they are decoded,
+// such as when they are received by a transport or replayed from the outbox.
+// Use fx.Invoke(RegisterSampleEventSchema) to register the schema.
+func RegisterSampleEventSchema() error {
+	registry := pubsub.GetEventTypeRegistry()
+	if err := registry.Register("SampleEvent", &SampleEvent{}); err != nil {
+		return err
+	}
+	if err := registry.RegisterSchema("SampleEvent", pubsub.EventSchema{
+		Version:   2,
+		Validator: pubsub.NewStructValidator(),
+	}); err != nil {
+		return err
+	}
+	return registry.RegisterUpcaster("SampleEvent", 1, func(payload json.RawMessage) (json.RawMessage, error) {
+		var v1 map[string]interface{}
+		if err := json.Unmarshal(payload, &v1); err != nil {
+			return nil, err
+		}
+		v1["Field1"] = v1["Field"]
+		delete(v1, "Field")
+		return json.Marshal(v1)
+	})
+}
@@ -0,0 +1,57 @@
+package example
+
+// ==================================================
+// ===== Example about declare a typed handler ======
+// ==================================================
+
+import (
+	"context"
+	"github.com/golibs-starter/golib/log"
+	"github.com/golibs-starter/golib/pubsub"
+)
+
+// OrderCreated is a message that published directly by
+// pubsub.PublishEvent(ctx, OrderCreated{OrderId: "1"})
+type OrderCreated struct {
+	OrderId string
+}
+
+// ResolvePricing is a query that sent by
+// pubsub.Request[ResolvePricing, Pricing](ctx, ResolvePricing{CartId: "1"})
+type ResolvePricing struct {
+	CartId string
+}
+
+type Pricing struct {
+	Total int64
+}
+
+// RegisterSampleHandlers
+// Use fx.Invoke(RegisterSampleHandlers) to register handlers,
+// no need to hand-write Supports with type assertion.
+func RegisterSampleHandlers(bus pubsub.EventBus, service *SampleService) error {
+	// Handle payload of MessageEvent[OrderCreated]
+	if _, err := pubsub.Subscribe(bus, func(ctx context.Context, msg OrderCreated) error {
+		log.WithCtx(ctx).Infof("Order [%s] is created", msg.OrderId)
+		return service.DoSomething(ctx)
+	}); err != nil {
+		return err
+	}
+
+	// Or handle all events that their name match a topic.
+	// The returned subscription can be used to unsubscribe the handler later.
+	subscription, err := pubsub.RegisterHandlerOn(bus, "Sample*", func(ctx context.Context, e pubsub.Event) {
+		log.WithCtx(ctx).Infof("Event [%s] is received", e.Name())
+	})
+	if err != nil {
+		return err
+	}
+	log.Infof("Handler [%s] is registered", subscription.Id())
+
+	// Reply requests of ResolvePricing, only one responder is allowed per query type
+	_, err = pubsub.Respond(bus, func(ctx context.Context, query ResolvePricing) (Pricing, error) {
+		log.WithCtx(ctx).Infof("Resolve pricing for cart [%s]", query.CartId)
+		return Pricing{Total: 100}, nil
+	})
+	return err
+}
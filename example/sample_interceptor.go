@@ -0,0 +1,36 @@
+package example
+
+// ==================================================
+// ===== Example about declare event interceptors ===
+// ==================================================
+
+import (
+	"context"
+	"errors"
+	"github.com/golibs-starter/golib/log"
+	"github.com/golibs-starter/golib/pubsub"
+	"time"
+)
+
+// NewSampleHandleInterceptor
+// Use golib.ProvideEventHandleInterceptor(NewSampleHandleInterceptor) to register it.
+// The interceptor measures the time that subscribers spend to handle events.
+func NewSampleHandleInterceptor() pubsub.HandleInterceptor {
+	return func(ctx context.Context, subscriberId string, event pubsub.Event, next pubsub.HandleFunc) error {
+		start := time.Now()
+		err := next(ctx, event)
+		log.WithCtx(ctx).Debugf("Subscriber [%s] handled event [%s] in [%s], error [%v]",
+			subscriberId, event.Name(), time.Since(start), err)
+		return err
+	}
+}
+
+// SampleTenantPublishInterceptor
+// Use golib.SupplyEventPublishInterceptor(SampleTenantPublishInterceptor) to register it.
+// The interceptor short-circuits events that published without a tenant.
+func SampleTenantPublishInterceptor(ctx context.Context, event pubsub.Event, next pubsub.PublishFunc) error {
+	if ctx.Value("tenant") == nil {
+		return errors.New("tenant is required")
+	}
+	return next(ctx, event)
+}
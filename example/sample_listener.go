@@ -1,6 +1,10 @@
 package example
 
 import (
+	"context"
+	"errors"
+	"time"
+
 	"github.com/golibs-starter/golib/log"
 	"github.com/golibs-starter/golib/pubsub"
 )
@@ -35,6 +39,77 @@ func (s SampleListener) Handle(e pubsub.Event) {
 	// You can get context in the web abstract event directly
 	log.WithCtx(sampleEvent.Context()).Info("Another log with context")
 
-	// Then pass the context to the next call
+	// Then pass the context to the next call.
+	// Events that are created with this context are follow-up events of this event:
+	// their CausationId is the id of this event, and they have the same CorrelationId,
+	// both are logged with the context, so that the whole cascade can be traced.
 	_ = s.service.DoSomething(sampleEvent.Context())
 }
+
+// NewSampleBatchListener
+// Use golib.ProvideEventListener(NewSampleBatchListener) to declare a listener that handles events in batches,
+// a batch is handled when it has 50 events or 2 seconds elapsed since its first event.
+// Buffered events are handled when the application is stopped.
+func NewSampleBatchListener() pubsub.Subscriber {
+	return pubsub.NewBatchingSubscriber(&SampleBatchListener{},
+		pubsub.WithBatchSize(50),
+		pubsub.WithBatchWindow(2*time.Second),
+		pubsub.WithBatchBufferSize(500),
+	)
+}
+
+type SampleBatchListener struct {
+}
+
+func (s SampleBatchListener) Supports(e pubsub.Event) bool {
+	_, ok := e.(*SampleEvent)
+	return ok
+}
+
+func (s SampleBatchListener) HandleBatch(ctx context.Context, events []pubsub.Event) error {
+	// Write all events at once, such as to an audit storage.
+	// The returned error is reported once for the whole batch.
+	log.Infof("Handle a batch of [%d] sample events", len(events))
+	return nil
+}
+
+// NewSampleEnrichListener
+// Use golib.ProvideEventListener(NewSampleEnrichListener) to declare a listener that runs inline in Publish.
+//
+// Listeners are dispatched in a deterministic order:
+// synchronous listeners run first, then the event is queued for asynchronous listeners.
+// In each phase, listeners with higher priority run first, then by the registration order.
+func NewSampleEnrichListener() pubsub.Subscriber {
+	return &SampleEnrichListener{}
+}
+
+type SampleEnrichListener struct {
+}
+
+// Synchronous runs this listener inline in Publish, before the event is queued
+func (s SampleEnrichListener) Synchronous() bool {
+	return true
+}
+
+// Priority of this listener, the default priority is 0
+func (s SampleEnrichListener) Priority() int {
+	return 100
+}
+
+func (s SampleEnrichListener) Supports(e pubsub.Event) bool {
+	_, ok := e.(*SampleEvent)
+	return ok
+}
+
+func (s SampleEnrichListener) Handle(e pubsub.Event) {
+}
+
+// HandleWithError validates or enriches the event, asynchronous listeners see the changes.
+// The returned error rejects the event, it's returned to the publisher.
+func (s SampleEnrichListener) HandleWithError(ctx context.Context, e pubsub.Event) error {
+	sampleEvent := e.(*SampleEvent)
+	if sampleEvent.Payload() == nil {
+		return errors.New("sample event must have payload")
+	}
+	return nil
+}
@@ -6,6 +6,7 @@ import (
 	"github.com/golibs-starter/golib/log/field"
 	"github.com/golibs-starter/golib/pubsub"
 	"github.com/golibs-starter/golib/web/client"
+	"time"
 )
 
 // ==================================================
@@ -52,3 +53,29 @@ func (s SampleService) DoSomething(ctx context.Context) error {
 	}))
 	return nil
 }
+
+// ScheduleSampleEvent publishes an event in 15 minutes unless it's cancelled,
+// keep the handle (or its id) to cancel the event, eg: when the payment is completed.
+func (s SampleService) ScheduleSampleEvent(ctx context.Context) (*pubsub.ScheduleHandle, error) {
+	return pubsub.PublishAfter(15*time.Minute, NewSampleEvent(ctx, &SampleEventMessage{
+		Field1: "val1",
+	}))
+}
+
+// DoSomethingInScope publishes events only when the whole operation succeeds.
+// Events of pubsub.Collect are buffered by the scope of the context, they are published
+// in order when the scope is committed, or discarded when it's rolled back.
+// In HTTP handlers, add middleware.EventScope() to commit the scope of the request on 2xx responses.
+func (s SampleService) DoSomethingInScope(ctx context.Context) error {
+	ctx = pubsub.WithEventScope(ctx)
+	scope := pubsub.GetEventScope(ctx)
+	if err := pubsub.Collect(ctx, NewSampleEvent(ctx, &SampleEventMessage{Field1: "val1"})); err != nil {
+		scope.Rollback()
+		return err
+	}
+	if err := s.DoSomething(ctx); err != nil {
+		scope.Rollback()
+		return err
+	}
+	return scope.Commit()
+}
@@ -0,0 +1,63 @@
+package example
+
+// ==================================================
+// == Example about forward events out of process ===
+// ==================================================
+
+import (
+	"context"
+	"github.com/golibs-starter/golib/pubsub"
+	"github.com/golibs-starter/golib/pubsub/transport"
+	"github.com/golibs-starter/golib/web/cloudevents"
+	"go.uber.org/fx"
+)
+
+// NewSampleWebhookTransport
+// Use fx.Provide(NewSampleWebhookTransport) to provide the transport.
+func NewSampleWebhookTransport(lc fx.Lifecycle) *transport.WebhookTransport {
+	webhook := transport.NewWebhookTransport("https://example.com/events",
+		transport.WithWebhookHeader("Authorization", "Bearer token"))
+	lc.Append(fx.Hook{
+		OnStop: func(ctx context.Context) error {
+			return webhook.Close()
+		},
+	})
+	return webhook
+}
+
+// NewSampleForwarder
+// Use golib.ProvideEventListener(NewSampleForwarder) to forward
+// OrderCreated and all Sample* events to the webhook.
+func NewSampleForwarder(webhook *transport.WebhookTransport) pubsub.Subscriber {
+	return transport.NewForwarder(webhook,
+		transport.ForwardEvents("Sample*"),
+		transport.ForwardType[OrderCreated](),
+	)
+}
+
+// RunSampleReceiver
+// Use fx.Invoke(RunSampleReceiver) to deliver events that are received
+// from other services on the local bus, it requires to register
+// the webhook (as a http.Handler) to your router.
+func RunSampleReceiver(lc fx.Lifecycle, webhook *transport.WebhookTransport, bus pubsub.EventBus) {
+	receiver := transport.NewReceiver(webhook, bus, transport.DecoderByName(map[string]transport.Decoder{
+		"OrderCreated": transport.DecodeAs[OrderCreated](),
+	}))
+	lc.Append(fx.Hook{
+		OnStart: func(ctx context.Context) error {
+			receiver.Start()
+			return nil
+		},
+		OnStop: func(ctx context.Context) error {
+			receiver.Stop()
+			return nil
+		},
+	})
+}
+
+// NewSampleCloudEventsHandler
+// Use fx.Provide(NewSampleCloudEventsHandler) and register the handler to your router,
+// to publish CloudEvents that are posted by other services on the local bus.
+func NewSampleCloudEventsHandler(publisher pubsub.Publisher) *cloudevents.Handler {
+	return cloudevents.NewHandler(cloudevents.WithPublisher(publisher))
+}
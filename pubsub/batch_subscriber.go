@@ -0,0 +1,277 @@
+package pubsub
+
+import (
+	"context"
+	"errors"
+	"runtime/debug"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/golibs-starter/golib/log"
+	"github.com/golibs-starter/golib/log/field"
+	"github.com/golibs-starter/golib/utils"
+)
+
+var ErrBatchSubscriberStopped = errors.New("batch subscriber is stopped")
+
+// BatchErrorHandler is called when a BatchSubscriber failed to handle a batch,
+// either by returning an error or by panicking.
+// The stack is only available when the subscriber panicked.
+type BatchErrorHandler func(subscriberId string, events []Event, err error, stack []byte)
+
+var defaultBatchErrorHandler BatchErrorHandler = func(subscriberId string, events []Event, err error, stack []byte) {
+	logger := log.WithErrors(err)
+	if len(stack) > 0 {
+		logger = logger.WithField(field.String("stacktrace", string(stack)))
+	}
+	logger.Errorf("Subscriber [%s] failed to handle a batch of [%d] events", subscriberId, len(events))
+}
+
+// BatchingSubscriber is a Subscriber that buffers events of a BatchSubscriber,
+// a batch is handled when it has enough events (batch size)
+// or when the batch window is elapsed since its first event, whichever comes first.
+//
+// The buffer is bounded, handling an event is blocked when the buffer is full,
+// until the event is buffered or the event context is done.
+// Buffered events are handled when the subscriber is stopped (see StoppableSubscriber).
+//
+// By default, handling an event succeeds once it's buffered, so the bus completes the event
+// (such as in the Outbox and the IdempotencyStore) before its batch is handled, and a failed batch
+// is only reported to the BatchErrorHandler without retries or dead letters.
+// Use WithBatchAcknowledgement to wait for the result of the batch instead.
+//
+// The batching loop is started when the bus runs or when the subscriber is registered to a running bus,
+// batches are handled with a context that is cancelled when the bus shutdown is aborted.
+// Events are kept in the buffer until the loop is started, use Start when it's used without a bus.
+type BatchingSubscriber struct {
+	subscriber   BatchSubscriber
+	id           string
+	size         int
+	window       time.Duration
+	bufferSize   int
+	errorHandler BatchErrorHandler
+	clock        Clock
+	acknowledged bool
+
+	ctx          context.Context
+	eventCh      chan batchItem
+	startOnce    sync.Once
+	stopped      bool
+	stateMu      sync.RWMutex
+	stopOnce     sync.Once
+	closingCh    chan struct{}
+	stopCh       chan struct{}
+	doneCh       chan struct{}
+	handledCount int64
+	failedCount  int64
+}
+
+// NewBatchingSubscriber wraps a BatchSubscriber, events are handled in batches of 100 events
+// or 1s window by default. The id of the BatchSubscriber is provided by IdentifiableSubscriber
+// or the full name of its struct.
+func NewBatchingSubscriber(subscriber BatchSubscriber, opts ...BatchingSubscriberOpt) *BatchingSubscriber {
+	s := &BatchingSubscriber{
+		ctx:          context.Background(),
+		subscriber:   subscriber,
+		size:         100,
+		window:       time.Second,
+		errorHandler: defaultBatchErrorHandler,
+		clock:        SystemClock(),
+		closingCh:    make(chan struct{}),
+		stopCh:       make(chan struct{}),
+		doneCh:       make(chan struct{}),
+	}
+	if identifiable, ok := subscriber.(interface{ SubscriberId() string }); ok {
+		s.id = identifiable.SubscriberId()
+	} else {
+		s.id = utils.GetStructFullname(subscriber)
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	if s.bufferSize < s.size {
+		s.bufferSize = s.size
+	}
+	s.eventCh = make(chan batchItem, s.bufferSize)
+	return s
+}
+
+// batchItem is a buffered event, result receives the error of its batch when the batch is acknowledged
+type batchItem struct {
+	event  Event
+	result chan error
+}
+
+func (s *BatchingSubscriber) SubscriberId() string {
+	return s.id
+}
+
+func (s *BatchingSubscriber) Supports(event Event) bool {
+	return s.subscriber.Supports(event)
+}
+
+func (s *BatchingSubscriber) Handle(event Event) {
+	_ = s.HandleWithError(eventContext(event), event)
+}
+
+// HandleWithError buffers the event, it returns ErrBatchSubscriberStopped when the subscriber is stopped,
+// or the context error when the context is done before the event is buffered.
+// When the batch is acknowledged (see WithBatchAcknowledgement), it waits for the result of the batch.
+func (s *BatchingSubscriber) HandleWithError(ctx context.Context, event Event) error {
+	item := batchItem{event: event}
+	if s.acknowledged {
+		item.result = make(chan error, 1)
+	}
+	if err := s.buffer(ctx, item); err != nil || item.result == nil {
+		return err
+	}
+	select {
+	case err := <-item.result:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (s *BatchingSubscriber) buffer(ctx context.Context, item batchItem) error {
+	s.stateMu.RLock()
+	defer s.stateMu.RUnlock()
+	if s.stopped {
+		return ErrBatchSubscriberStopped
+	}
+	select {
+	case s.eventCh <- item:
+		return nil
+	case <-s.closingCh:
+		return ErrBatchSubscriberStopped
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Start the batching loop when the subscriber is used without a bus, batches are handled with ctx.
+// It's no-op when the loop is started already.
+func (s *BatchingSubscriber) Start(ctx context.Context) {
+	s.start(ctx)
+}
+
+// start the batching loop once, batches are handled with ctx
+func (s *BatchingSubscriber) start(ctx context.Context) {
+	s.startOnce.Do(func() {
+		s.ctx = ctx
+		go s.loop()
+	})
+}
+
+// Stop accepting events, buffered events are handled before it returns.
+func (s *BatchingSubscriber) Stop() {
+	s.stopOnce.Do(func() {
+		close(s.closingCh)
+		s.stateMu.Lock()
+		s.stopped = true
+		s.stateMu.Unlock()
+		close(s.stopCh)
+	})
+	// The loop is never started
+	s.startOnce.Do(func() {
+		close(s.doneCh)
+	})
+	<-s.doneCh
+}
+
+// Buffered returns the number of events in the buffer,
+// events of the batch that is being collected are not included.
+func (s *BatchingSubscriber) Buffered() int {
+	return len(s.eventCh)
+}
+
+// HandledCount returns the number of batches that were handled successfully
+func (s *BatchingSubscriber) HandledCount() int64 {
+	return atomic.LoadInt64(&s.handledCount)
+}
+
+// FailedCount returns the number of batches that were failed to handle
+func (s *BatchingSubscriber) FailedCount() int64 {
+	return atomic.LoadInt64(&s.failedCount)
+}
+
+func (s *BatchingSubscriber) loop() {
+	defer close(s.doneCh)
+	batch := make([]batchItem, 0, s.size)
+	var timer Timer
+	var timerCh <-chan time.Time
+	flush := func() {
+		if timer != nil {
+			timer.Stop()
+			timer, timerCh = nil, nil
+		}
+		if len(batch) > 0 {
+			s.handleBatch(batch)
+			batch = make([]batchItem, 0, s.size)
+		}
+	}
+	for {
+		select {
+		case item := <-s.eventCh:
+			batch = append(batch, item)
+			if len(batch) >= s.size {
+				flush()
+			} else if timer == nil {
+				timer = s.clock.NewTimer(s.window)
+				timerCh = timer.C()
+			}
+		case <-timerCh:
+			flush()
+		case <-s.stopCh:
+			// No more event is buffered after stopCh is closed
+			for {
+				select {
+				case item := <-s.eventCh:
+					batch = append(batch, item)
+					if len(batch) >= s.size {
+						flush()
+					}
+				default:
+					flush()
+					return
+				}
+			}
+		}
+	}
+}
+
+// handleBatch calls the BatchSubscriber, a panic is recovered and reported as PanicError.
+// The result is sent to acknowledged events of the batch.
+func (s *BatchingSubscriber) handleBatch(items []batchItem) {
+	events := make([]Event, 0, len(items))
+	for _, item := range items {
+		events = append(events, item.event)
+	}
+	err := s.callBatch(events)
+	for _, item := range items {
+		if item.result != nil {
+			item.result <- err
+		}
+	}
+}
+
+func (s *BatchingSubscriber) callBatch(events []Event) error {
+	var stack []byte
+	err := func() (err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				stack = debug.Stack()
+				err = &PanicError{Value: r, Stack: stack}
+			}
+		}()
+		return s.subscriber.HandleBatch(s.ctx, events)
+	}()
+	if err != nil {
+		atomic.AddInt64(&s.failedCount, 1)
+		s.errorHandler(s.id, events, err, stack)
+		return err
+	}
+	atomic.AddInt64(&s.handledCount, 1)
+	return nil
+}
@@ -0,0 +1,58 @@
+package pubsub
+
+import "time"
+
+type BatchingSubscriberOpt func(s *BatchingSubscriber)
+
+// WithBatchSize sets the max number of events in a batch. Default is 100.
+func WithBatchSize(size int) BatchingSubscriberOpt {
+	return func(s *BatchingSubscriber) {
+		if size > 0 {
+			s.size = size
+		}
+	}
+}
+
+// WithBatchWindow sets the max time to wait since the first event of a batch,
+// before the batch is handled. Default is 1s.
+func WithBatchWindow(window time.Duration) BatchingSubscriberOpt {
+	return func(s *BatchingSubscriber) {
+		if window > 0 {
+			s.window = window
+		}
+	}
+}
+
+// WithBatchBufferSize sets the max number of buffered events,
+// it's not less than the batch size. Default is the batch size.
+func WithBatchBufferSize(size int) BatchingSubscriberOpt {
+	return func(s *BatchingSubscriber) {
+		s.bufferSize = size
+	}
+}
+
+// WithBatchErrorHandler replaces the default handler that logs failed batches
+func WithBatchErrorHandler(handler BatchErrorHandler) BatchingSubscriberOpt {
+	return func(s *BatchingSubscriber) {
+		if handler != nil {
+			s.errorHandler = handler
+		}
+	}
+}
+
+// WithBatchAcknowledgement makes handling an event wait until its batch is handled,
+// so that a failed batch is returned to the bus for each of its events, to be retried or dead-lettered,
+// and the bus completes events only after their batch succeeded.
+// Batches are only filled by concurrent handlings, such as with the async executor.
+func WithBatchAcknowledgement() BatchingSubscriberOpt {
+	return func(s *BatchingSubscriber) {
+		s.acknowledged = true
+	}
+}
+
+// WithBatchClock replaces the system clock, such as by a fake clock in tests
+func WithBatchClock(clock Clock) BatchingSubscriberOpt {
+	return func(s *BatchingSubscriber) {
+		s.clock = clock
+	}
+}
@@ -0,0 +1,231 @@
+package pubsub
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golibs-starter/golib/pubsub/executor"
+	assert "github.com/stretchr/testify/require"
+)
+
+type DummyBatchSubscriber struct {
+	batches [][]string
+	mu      sync.Mutex
+	handle  func(events []Event) error
+}
+
+func (d *DummyBatchSubscriber) Supports(event Event) bool {
+	return true
+}
+
+func (d *DummyBatchSubscriber) HandleBatch(ctx context.Context, events []Event) error {
+	names := make([]string, 0, len(events))
+	for _, e := range events {
+		names = append(names, e.Name())
+	}
+	d.mu.Lock()
+	d.batches = append(d.batches, names)
+	d.mu.Unlock()
+	if d.handle != nil {
+		return d.handle(events)
+	}
+	return nil
+}
+
+func (d *DummyBatchSubscriber) handledBatches() [][]string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return append([][]string{}, d.batches...)
+}
+
+type BatchContextSubscriber struct {
+	handle func(ctx context.Context)
+}
+
+func (b *BatchContextSubscriber) Supports(event Event) bool {
+	return true
+}
+
+func (b *BatchContextSubscriber) HandleBatch(ctx context.Context, events []Event) error {
+	b.handle(ctx)
+	return nil
+}
+
+func TestBatchingSubscriber_WhenBatchIsFull_ShouldHandleBatchAndFlushOnStop(t *testing.T) {
+	s := &DummyBatchSubscriber{}
+	batching := NewBatchingSubscriber(s, WithBatchSize(3), WithBatchWindow(time.Hour))
+	batching.Start(context.Background())
+	assert.Equal(t, "pubsub.DummyBatchSubscriber", batching.SubscriberId())
+	for _, name := range []string{"e1", "e2", "e3", "e4", "e5", "e6", "e7"} {
+		assert.NoError(t, batching.HandleWithError(context.Background(), &DummyEvent{name: name}))
+	}
+	assert.Eventually(t, func() bool {
+		return len(s.handledBatches()) == 2
+	}, time.Second, 5*time.Millisecond)
+
+	batching.Stop()
+	assert.Equal(t, [][]string{{"e1", "e2", "e3"}, {"e4", "e5", "e6"}, {"e7"}}, s.handledBatches())
+	assert.EqualValues(t, 3, batching.HandledCount())
+	assert.ErrorIs(t, batching.HandleWithError(context.Background(), &DummyEvent{name: "e8"}), ErrBatchSubscriberStopped)
+}
+
+func TestBatchingSubscriber_WhenWindowElapsed_ShouldHandlePartialBatch(t *testing.T) {
+	s := &DummyBatchSubscriber{}
+	batching := NewBatchingSubscriber(s, WithBatchSize(100), WithBatchWindow(20*time.Millisecond))
+	batching.Start(context.Background())
+	defer batching.Stop()
+	batching.Handle(&DummyEvent{name: "e1"})
+	batching.Handle(&DummyEvent{name: "e2"})
+	assert.Eventually(t, func() bool {
+		return len(s.handledBatches()) == 1
+	}, time.Second, 5*time.Millisecond)
+	assert.Equal(t, []string{"e1", "e2"}, s.handledBatches()[0])
+
+	batching.Handle(&DummyEvent{name: "e3"})
+	assert.Eventually(t, func() bool {
+		return len(s.handledBatches()) == 2
+	}, time.Second, 5*time.Millisecond)
+}
+
+func TestBatchingSubscriber_WhenBatchFailed_ShouldReportBatchError(t *testing.T) {
+	s := &DummyBatchSubscriber{handle: func(events []Event) error {
+		if events[0].Name() == "e1" {
+			return errors.New("audit is unavailable")
+		}
+		panic("audit is broken")
+	}}
+	type batchReport struct {
+		size     int
+		err      error
+		hasStack bool
+	}
+	reports := make(chan batchReport, 2)
+	batching := NewBatchingSubscriber(s, WithBatchSize(2), WithBatchErrorHandler(
+		func(subscriberId string, events []Event, err error, stack []byte) {
+			assert.Equal(t, "pubsub.DummyBatchSubscriber", subscriberId)
+			reports <- batchReport{size: len(events), err: err, hasStack: len(stack) > 0}
+		}))
+	batching.Start(context.Background())
+	for _, name := range []string{"e1", "e2", "e3", "e4"} {
+		batching.Handle(&DummyEvent{name: name})
+	}
+	batching.Stop()
+
+	report := <-reports
+	assert.Equal(t, 2, report.size)
+	assert.EqualError(t, report.err, "audit is unavailable")
+	assert.False(t, report.hasStack)
+	report = <-reports
+	var panicErr *PanicError
+	assert.ErrorAs(t, report.err, &panicErr)
+	assert.True(t, report.hasStack)
+	assert.EqualValues(t, 2, batching.FailedCount())
+	assert.EqualValues(t, 0, batching.HandledCount())
+}
+
+func TestBatchingSubscriber_WhenBufferIsFull_ShouldBlockUntilContextIsDone(t *testing.T) {
+	release := make(chan struct{})
+	s := &DummyBatchSubscriber{handle: func(events []Event) error {
+		<-release
+		return nil
+	}}
+	batching := NewBatchingSubscriber(s, WithBatchSize(1), WithBatchBufferSize(1))
+	batching.Start(context.Background())
+	batching.Handle(&DummyEvent{name: "e1"})
+	assert.Eventually(t, func() bool {
+		return len(s.handledBatches()) == 1
+	}, time.Second, 5*time.Millisecond)
+	batching.Handle(&DummyEvent{name: "e2"})
+	assert.Equal(t, 1, batching.Buffered())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, batching.HandleWithError(ctx, &DummyEvent{name: "e3"}), context.DeadlineExceeded)
+
+	close(release)
+	batching.Stop()
+	assert.Equal(t, [][]string{{"e1"}, {"e2"}}, s.handledBatches())
+}
+
+func TestDefaultEventBus_WhenShutdown_ShouldStopBatchingSubscribers(t *testing.T) {
+	s := &DummyBatchSubscriber{}
+	bus := NewDefaultEventBus(WithEventExecutor(executor.NewSyncExecutor()))
+	bus.Register(NewBatchingSubscriber(s, WithBatchWindow(time.Hour)))
+	bus.Run()
+	bus.Deliver(&DummyEvent{name: "e1"})
+	bus.Deliver(&DummyEvent{name: "e2"})
+	assert.NoError(t, bus.Shutdown(context.Background()))
+	assert.Equal(t, [][]string{{"e1", "e2"}}, s.handledBatches())
+}
+
+func TestBatchingSubscriber_WhenNeverStarted_ShouldStopWithoutLoop(t *testing.T) {
+	s := &DummyBatchSubscriber{}
+	batching := NewBatchingSubscriber(s)
+	batching.Stop()
+	assert.ErrorIs(t, batching.HandleWithError(context.Background(), &DummyEvent{name: "e1"}), ErrBatchSubscriberStopped)
+	assert.Empty(t, s.handledBatches())
+}
+
+func TestBatchingSubscriber_GivenAcknowledgement_WhenBatchFailed_ShouldRetryByBus(t *testing.T) {
+	for _, acknowledged := range []bool{false, true} {
+		s := &DummyBatchSubscriber{}
+		failed := false
+		s.handle = func(events []Event) error {
+			if !failed {
+				failed = true
+				return errors.New("audit is unavailable")
+			}
+			return nil
+		}
+		opts := []BatchingSubscriberOpt{WithBatchSize(2), WithBatchWindow(time.Hour),
+			WithBatchErrorHandler(func(subscriberId string, events []Event, err error, stack []byte) {})}
+		if acknowledged {
+			opts = append(opts, WithBatchAcknowledgement())
+		}
+		batching := NewBatchingSubscriber(s, opts...)
+		bus, errCh := newTimeoutBus(t, WithRetryPolicy(RetryPolicy{MaxAttempts: 2}))
+		bus.Register(batching)
+		assert.NoError(t, bus.TryDeliver(context.Background(), &DummyEvent{name: "e1"}))
+		assert.NoError(t, bus.TryDeliver(context.Background(), &DummyEvent{name: "e1"}))
+
+		if !acknowledged {
+			// The events are completed once they are buffered, the failed batch is not retried
+			assert.Eventually(t, func() bool {
+				return batching.FailedCount() == 1
+			}, time.Second, 5*time.Millisecond)
+			assert.Equal(t, [][]string{{"e1", "e1"}}, s.handledBatches())
+			continue
+		}
+		assert.Eventually(t, func() bool {
+			return batching.HandledCount() == 1
+		}, time.Second, 5*time.Millisecond)
+		assert.Equal(t, [][]string{{"e1", "e1"}, {"e1", "e1"}}, s.handledBatches())
+		assert.EqualValues(t, 1, batching.FailedCount())
+		assert.Empty(t, errCh)
+	}
+}
+
+func TestDefaultEventBus_WhenShutdownIsAborted_ShouldCancelBatchContext(t *testing.T) {
+	cancelled := make(chan error, 1)
+	s := &BatchContextSubscriber{handle: func(ctx context.Context) {
+		<-ctx.Done()
+		cancelled <- ctx.Err()
+	}}
+	bus := NewDefaultEventBus(WithEventExecutor(executor.NewSyncExecutor()))
+	bus.Run()
+	bus.Register(NewBatchingSubscriber(s, WithBatchSize(1)))
+	bus.Deliver(&DummyEvent{name: "e1"})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	assert.Error(t, bus.Shutdown(ctx))
+	select {
+	case err := <-cancelled:
+		assert.ErrorIs(t, err, context.Canceled)
+	case <-time.After(time.Second):
+		t.Fatal("batch context is not cancelled")
+	}
+}
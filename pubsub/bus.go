@@ -1,5 +1,10 @@
 package pubsub
 
+import (
+	"context"
+	"fmt"
+)
+
 type EventBus interface {
 
 	// Register subscriber(s) with the bus
@@ -17,3 +22,70 @@ type EventBus interface {
 	// IsRunning returns whether the bus is running
 	IsRunning() bool
 }
+
+// SubscribingBus is an optional interface for an EventBus,
+// that registers subscribers at anytime and unregisters them later.
+type SubscribingBus interface {
+	EventBus
+
+	// Subscribe registers a subscriber and returns a Subscription to unsubscribe it later.
+	// It's safe to call at anytime, includes when the bus is running.
+	Subscribe(subscriber Subscriber) (Subscription, error)
+
+	// RegisterHandler registers a handler function for events that their name match the topic.
+	// `*` in the topic matches any sequence of characters, an empty topic matches all events.
+	// Returns error when the handler is not compatible.
+	RegisterHandler(topicName string, handler any) (Subscription, error)
+}
+
+// ErrorAwareBus is an optional interface for an EventBus,
+// that returns an error when an event is not accepted.
+type ErrorAwareBus interface {
+	EventBus
+
+	// TryDeliver delivers an event and returns an error when the event is not accepted,
+	// such as the queue is full (ErrQueueFull) or the bus is stopped (ErrBusStopped).
+	TryDeliver(ctx context.Context, event Event) error
+}
+
+// GracefulBus is an optional interface for an EventBus, that is stopped gracefully
+type GracefulBus interface {
+	EventBus
+
+	// Shutdown gracefully stops the bus, it stops accepting new events,
+	// then waits for queued and in-flight events until the context is done.
+	Shutdown(ctx context.Context) error
+}
+
+// TryDeliverOn delivers an event to the bus and returns an error when the event is not accepted.
+// When the bus is not an ErrorAwareBus, the event is delivered by EventBus.Deliver,
+// only ErrBusNotRunning is returned then.
+func TryDeliverOn(ctx context.Context, bus EventBus, event Event) error {
+	if errorAwareBus, ok := bus.(ErrorAwareBus); ok {
+		return errorAwareBus.TryDeliver(ctx, event)
+	}
+	if !bus.IsRunning() {
+		return ErrBusNotRunning
+	}
+	bus.Deliver(event)
+	return nil
+}
+
+// RegisterHandlerOn registers a handler function to the bus, see SubscribingBus.RegisterHandler.
+// It returns an error when the bus is not a SubscribingBus.
+func RegisterHandlerOn(bus EventBus, topicName string, handler any) (Subscription, error) {
+	subscribingBus, ok := bus.(SubscribingBus)
+	if !ok {
+		return nil, fmt.Errorf("EventBus [%T] does not support subscriptions", bus)
+	}
+	return subscribingBus.RegisterHandler(topicName, handler)
+}
+
+// ShutdownBus gracefully stops the bus when it's a GracefulBus, otherwise the bus is stopped by EventBus.Stop.
+func ShutdownBus(ctx context.Context, bus EventBus) error {
+	if gracefulBus, ok := bus.(GracefulBus); ok {
+		return gracefulBus.Shutdown(ctx)
+	}
+	bus.Stop()
+	return nil
+}
@@ -0,0 +1,22 @@
+package pubsub
+
+import (
+	"context"
+
+	baseEvent "github.com/golibs-starter/golib/event"
+)
+
+// handlingContext returns the context that a subscriber handles an event with,
+// it carries the event as the cause of events that are created inside the handling.
+func handlingContext(event Event) context.Context {
+	ctx := eventContext(event)
+	if cause := baseEvent.GetCause(ctx); cause != nil && cause.EventId == event.Identifier() {
+		return ctx
+	}
+	if causeProvider, ok := event.(interface{ AsCause() baseEvent.Cause }); ok {
+		if cause := causeProvider.AsCause(); cause.EventId != "" {
+			return baseEvent.WithCause(ctx, cause)
+		}
+	}
+	return baseEvent.WithCause(ctx, baseEvent.Cause{EventId: event.Identifier(), CorrelationId: event.Identifier()})
+}
@@ -0,0 +1,42 @@
+package pubsub
+
+import "time"
+
+// Clock provides the current time and timers,
+// it can be replaced in tests to control the time.
+type Clock interface {
+	Now() time.Time
+
+	// NewTimer creates a Timer that sends the current time on its channel after d
+	NewTimer(d time.Duration) Timer
+}
+
+type Timer interface {
+	C() <-chan time.Time
+
+	// Stop prevents the Timer from firing, see time.Timer.Stop
+	Stop() bool
+}
+
+type systemClock struct{}
+
+// SystemClock returns the Clock of the system time
+func SystemClock() Clock {
+	return systemClock{}
+}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+func (systemClock) NewTimer(d time.Duration) Timer {
+	return systemTimer{time.NewTimer(d)}
+}
+
+type systemTimer struct {
+	*time.Timer
+}
+
+func (t systemTimer) C() <-chan time.Time {
+	return t.Timer.C
+}
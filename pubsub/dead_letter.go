@@ -0,0 +1,131 @@
+package pubsub
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrDeadLetterNotFound      = errors.New("dead letter not found")
+	ErrDeadLetterStoreNotFound = errors.New("dead letter store is not configured")
+)
+
+// DeadLetter holds an event that a subscriber
+// failed to handle after all retry attempts.
+type DeadLetter struct {
+	Id           string    `json:"id"`
+	SubscriberId string    `json:"subscriber_id"`
+	EventName    string    `json:"event_name"`
+	EventId      string    `json:"event_id"`
+	Event        Event     `json:"event"`
+	Attempts     int       `json:"attempts"`
+	Errors       []string  `json:"errors"`
+	FailedAt     time.Time `json:"failed_at"`
+}
+
+func NewDeadLetter(subscriberId string, event Event, errs []error) *DeadLetter {
+	// No error reached, ignored
+	id, _ := uuid.NewUUID()
+	deadLetter := DeadLetter{
+		Id:           id.String(),
+		SubscriberId: subscriberId,
+		EventName:    event.Name(),
+		EventId:      event.Identifier(),
+		Event:        event,
+		Attempts:     len(errs),
+		Errors:       make([]string, 0, len(errs)),
+		FailedAt:     time.Now(),
+	}
+	for _, err := range errs {
+		deadLetter.Errors = append(deadLetter.Errors, err.Error())
+	}
+	return &deadLetter
+}
+
+// DeadLetterSink receives events that are failed after all retry attempts.
+type DeadLetterSink interface {
+
+	// Put a dead letter to the sink
+	Put(deadLetter *DeadLetter)
+}
+
+// DeadLetterStore is a DeadLetterSink that can be queried,
+// it's required to re-drive dead letters.
+type DeadLetterStore interface {
+	DeadLetterSink
+
+	// List returns all dead letters, the oldest first
+	List() []*DeadLetter
+
+	// Get returns a dead letter by its id
+	Get(id string) (*DeadLetter, bool)
+
+	// Remove a dead letter by its id
+	Remove(id string)
+}
+
+// DeadLetterRedriver re-delivers a dead letter to the subscriber that failed to handle it.
+type DeadLetterRedriver interface {
+
+	// Redrive a dead letter by its id
+	Redrive(id string) error
+}
+
+// InMemoryDeadLetterStore keeps dead letters in memory,
+// when the capacity is reached, the oldest one is evicted.
+type InMemoryDeadLetterStore struct {
+	capacity    int
+	deadLetters []*DeadLetter
+	mu          sync.RWMutex
+}
+
+// NewInMemoryDeadLetterStore creates an InMemoryDeadLetterStore,
+// zero or negative capacity means unlimited.
+func NewInMemoryDeadLetterStore(capacity int) *InMemoryDeadLetterStore {
+	return &InMemoryDeadLetterStore{
+		capacity:    capacity,
+		deadLetters: make([]*DeadLetter, 0),
+	}
+}
+
+func (s *InMemoryDeadLetterStore) Put(deadLetter *DeadLetter) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.capacity > 0 && len(s.deadLetters) >= s.capacity {
+		s.deadLetters = s.deadLetters[len(s.deadLetters)-s.capacity+1:]
+	}
+	s.deadLetters = append(s.deadLetters, deadLetter)
+}
+
+func (s *InMemoryDeadLetterStore) List() []*DeadLetter {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	deadLetters := make([]*DeadLetter, len(s.deadLetters))
+	copy(deadLetters, s.deadLetters)
+	return deadLetters
+}
+
+func (s *InMemoryDeadLetterStore) Get(id string) (*DeadLetter, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, deadLetter := range s.deadLetters {
+		if deadLetter.Id == id {
+			return deadLetter, true
+		}
+	}
+	return nil, false
+}
+
+func (s *InMemoryDeadLetterStore) Remove(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, deadLetter := range s.deadLetters {
+		if deadLetter.Id == id {
+			s.deadLetters = append(s.deadLetters[:i], s.deadLetters[i+1:]...)
+			return
+		}
+	}
+}
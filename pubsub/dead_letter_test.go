@@ -0,0 +1,39 @@
+package pubsub
+
+import (
+	"errors"
+	assert "github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestNewDeadLetter_ShouldKeepErrorHistory(t *testing.T) {
+	deadLetter := NewDeadLetter("sub-1", &DummyEvent{name: "event-1"},
+		[]error{errors.New("error-1"), errors.New("error-2")})
+	assert.NotEmpty(t, deadLetter.Id)
+	assert.Equal(t, "sub-1", deadLetter.SubscriberId)
+	assert.Equal(t, "event-1", deadLetter.EventName)
+	assert.Equal(t, "dummy-event-id", deadLetter.EventId)
+	assert.Equal(t, 2, deadLetter.Attempts)
+	assert.Equal(t, []string{"error-1", "error-2"}, deadLetter.Errors)
+	assert.False(t, deadLetter.FailedAt.IsZero())
+}
+
+func TestInMemoryDeadLetterStore_WhenCapacityReached_ShouldEvictTheOldest(t *testing.T) {
+	store := NewInMemoryDeadLetterStore(2)
+	d1 := NewDeadLetter("sub-1", &DummyEvent{name: "event-1"}, nil)
+	d2 := NewDeadLetter("sub-1", &DummyEvent{name: "event-2"}, nil)
+	d3 := NewDeadLetter("sub-1", &DummyEvent{name: "event-3"}, nil)
+	store.Put(d1)
+	store.Put(d2)
+	store.Put(d3)
+	assert.Equal(t, []*DeadLetter{d2, d3}, store.List())
+
+	_, exists := store.Get(d1.Id)
+	assert.False(t, exists)
+	found, exists := store.Get(d2.Id)
+	assert.True(t, exists)
+	assert.Equal(t, d2, found)
+
+	store.Remove(d2.Id)
+	assert.Equal(t, []*DeadLetter{d3}, store.List())
+}
@@ -2,26 +2,83 @@ package pubsub
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"sort"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/golibs-starter/golib/pubsub/executor"
 	"github.com/golibs-starter/golib/utils"
 )
 
 type DefaultEventBus struct {
-	isRunning   bool
-	debugLog    DebugLog
-	subscribers map[string]Subscriber
-	eventChSize int
-	eventCh     chan Event
-	stopCh      chan bool
-	executor    Executor
-	wg          sync.WaitGroup
+	isRunning    bool
+	isClosing    int32
+	isStopped    bool
+	stateMu      sync.RWMutex
+	debugLog     DebugLog
+	subscribers  map[string]*subscription
+	subsSeq      int64
+	subsMu       sync.RWMutex
+	eventChSize  int
+	eventCh      chan Event
+	executor     Executor
+	errorHandler ErrorHandler
+
+	// closingCh is closed when shutdown starts, to release blocked deliveries.
+	// stopCh is closed when no more event is accepted, the queue is drained then.
+	// abortCh is closed when shutdown deadline is exceeded.
+	// doneCh is closed when the dispatching goroutine exited.
+	closingCh     chan struct{}
+	stopCh        chan struct{}
+	abortCh       chan struct{}
+	doneCh        chan struct{}
+	ctx           context.Context
+	cancel        context.CancelFunc
+	inFlight      sync.WaitGroup
+	inFlightCount int64
+	attempts      attemptGroup
+
+	overflowPolicy OverflowPolicy
+	blockTimeout   time.Duration
+	droppedCount   int64
+	rejectedCount  int64
+
+	// queueLowWater is the lowest queue size that the dispatcher saw since it's reset by the health checker
+	queueLowWater int64
+
+	retryPolicy             RetryPolicy
+	subscriberRetryPolicies []subscriberOverride[RetryPolicy]
+	deadLetterSink          DeadLetterSink
+
+	handleInterceptors []HandleInterceptor
+
+	handlerTimeout            time.Duration
+	subscriberHandlerTimeouts []subscriberOverride[time.Duration]
+	timeoutCount              int64
+
+	outbox       Outbox
+	outboxEvents []string
+
+	idempotencyStore      IdempotencyStore
+	idempotentSubscribers []string
+	duplicateCount        int64
+
+	disabledSubscribers []string
+	disabledEvents      []string
+	disabledMu          sync.RWMutex
+	suppressedCount     int64
+
+	metrics *busMetrics
 }
 
 func NewDefaultEventBus(opts ...EventBusOpt) *DefaultEventBus {
 	bus := &DefaultEventBus{
-		subscribers: make(map[string]Subscriber, 0),
+		subscribers: make(map[string]*subscription),
+		metrics:     newBusMetrics(),
 	}
 	for _, opt := range opts {
 		opt(bus)
@@ -38,72 +95,654 @@ func NewDefaultEventBus(opts ...EventBusOpt) *DefaultEventBus {
 	if bus.executor == nil {
 		bus.executor = executor.NewAsyncExecutor()
 	}
-	bus.stopCh = make(chan bool)
+	if !bus.overflowPolicy.IsValid() {
+		bus.overflowPolicy = OverflowBlock
+	}
+	if bus.errorHandler == nil {
+		bus.errorHandler = defaultErrorHandler
+	}
+	bus.closingCh = make(chan struct{})
+	bus.stopCh = make(chan struct{})
+	bus.abortCh = make(chan struct{})
+	bus.doneCh = make(chan struct{})
+	bus.ctx, bus.cancel = context.WithCancel(context.Background())
 	return bus
 }
 
 func (b *DefaultEventBus) Register(subscribers ...Subscriber) {
 	for _, subscriber := range subscribers {
-		subscriberId := utils.GetStructFullname(subscriber)
-		if _, exists := b.subscribers[subscriberId]; exists {
-			b.debugLog(context.Background(), "Subscriber [%s] already registered", subscriberId)
+		sub, err := b.Subscribe(subscriber)
+		if err != nil {
+			b.debugLog(context.Background(), "Cannot register subscriber: %v", err)
 			continue
 		}
-		b.subscribers[subscriberId] = subscriber
-		b.debugLog(context.Background(), "Register subscriber [%s] successful", subscriberId)
+		b.debugLog(context.Background(), "Register subscriber [%s] successful", sub.Id())
+		b.logDisabledSubscriber(sub.(*subscription))
+	}
+}
+
+// Subscribe registers a subscriber and returns its subscription, it's safe to call while the bus is running.
+// The subscriber id is provided by IdentifiableSubscriber or the full name of subscriber's struct,
+// different instances of the same struct are registered with a numeric suffix, eg: listener.OrderListener#2.
+// Generated ids (such as ids of handler functions) are made unique by the suffix as well.
+func (b *DefaultEventBus) Subscribe(subscriber Subscriber) (Subscription, error) {
+	if subscriber == nil {
+		return nil, errors.New("subscriber must not be nil")
+	}
+	sub, err := b.subscribe(subscriber)
+	if err != nil {
+		return nil, err
+	}
+	if b.IsRunning() {
+		b.startSubscriber(subscriber)
+	}
+	return sub, nil
+}
+
+func (b *DefaultEventBus) subscribe(subscriber Subscriber) (*subscription, error) {
+	b.subsMu.Lock()
+	defer b.subsMu.Unlock()
+	for _, existing := range b.subscribers {
+		if sameInstance(existing.subscriber, subscriber) {
+			return nil, fmt.Errorf("subscriber [%s] already registered", existing.id)
+		}
+	}
+	if existing := b.conflictingResponder(subscriber); existing != nil {
+		return nil, fmt.Errorf("%w: responder [%s] is registered already", ErrMultipleResponders, existing.id)
+	}
+	subscriberId := subscriberIdOf(subscriber)
+	if _, exists := b.subscribers[subscriberId]; exists {
+		_, identifiable := subscriber.(IdentifiableSubscriber)
+		if _, generated := subscriber.(generatedIdSubscriber); identifiable && !generated {
+			return nil, fmt.Errorf("subscriber id [%s] is already used by another subscriber", subscriberId)
+		}
+		for i := 2; ; i++ {
+			if _, exists := b.subscribers[fmt.Sprintf("%s#%d", subscriberId, i)]; !exists {
+				subscriberId = fmt.Sprintf("%s#%d", subscriberId, i)
+				break
+			}
+		}
+	}
+	b.subsSeq++
+	sub := newSubscription(subscriberId, b.subsSeq, subscriber, b)
+	b.subscribers[subscriberId] = sub
+	return sub, nil
+}
+
+// startSubscriber starts a subscriber that runs in background (see startableSubscriber)
+func (b *DefaultEventBus) startSubscriber(subscriber Subscriber) {
+	if startable, ok := subscriber.(startableSubscriber); ok {
+		startable.start(b.ctx)
+	}
+}
+
+func (b *DefaultEventBus) RegisterHandler(topicName string, handler any) (Subscription, error) {
+	subscriber, err := newHandlerSubscriber(topicName, handler)
+	if err != nil {
+		return nil, fmt.Errorf("cannot register handler for topic [%s]: %w", topicName, err)
+	}
+	sub, err := b.Subscribe(subscriber)
+	if err != nil {
+		return nil, err
+	}
+	b.debugLog(context.Background(), "Register handler [%s] successful", sub.Id())
+	return sub, nil
+}
+
+// Subscribers returns information of registered subscribers, ordered by id.
+func (b *DefaultEventBus) Subscribers() []SubscriberInfo {
+	subs := b.subscriptions()
+	infos := make([]SubscriberInfo, 0, len(subs))
+	for _, sub := range subs {
+		info := sub.info()
+		info.Disabled = b.isSubscriberDisabled(sub)
+		if latency, exists := b.SubscriberLatency(sub.id); exists {
+			info.Latency = &latency
+		}
+		infos = append(infos, info)
+	}
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].Id < infos[j].Id
+	})
+	return infos
+}
+
+func (b *DefaultEventBus) unsubscribe(sub *subscription) {
+	b.subsMu.Lock()
+	defer b.subsMu.Unlock()
+	if b.subscribers[sub.id] == sub {
+		delete(b.subscribers, sub.id)
+		b.debugLog(context.Background(), "Unsubscribe subscriber [%s] successful", sub.id)
+	}
+}
+
+// subscriptions returns a snapshot of registered subscriptions in the dispatching order,
+// that is by priority (higher first) then by registration order.
+func (b *DefaultEventBus) subscriptions() []*subscription {
+	b.subsMu.RLock()
+	subs := make([]*subscription, 0, len(b.subscribers))
+	for _, sub := range b.subscribers {
+		subs = append(subs, sub)
 	}
+	b.subsMu.RUnlock()
+	sort.Slice(subs, func(i, j int) bool {
+		return subs[i].dispatchedBefore(subs[j])
+	})
+	return subs
+}
+
+// generatedIdSubscriber is implemented by subscribers that their id is generated from a function name,
+// such as handlers of RegisterHandler, different registrations of the same function have the same id.
+type generatedIdSubscriber interface {
+	generatedId()
 }
 
+func subscriberIdOf(subscriber Subscriber) string {
+	if identifiableSubscriber, ok := subscriber.(IdentifiableSubscriber); ok {
+		return identifiableSubscriber.SubscriberId()
+	}
+	return utils.GetStructFullname(subscriber)
+}
+
+// Deliver an event to the queue, when the queue is full
+// the event is handled by the overflow policy (see TryDeliver).
+// Events delivered after the bus is stopped are ignored.
 func (b *DefaultEventBus) Deliver(event Event) {
-	b.eventCh <- event
+	if err := b.TryDeliver(context.Background(), event); err != nil {
+		b.debugLog(eventContext(event), "Event [%s] with id [%s] was not delivered, error [%v]",
+			event.Name(), event.Identifier(), err)
+	}
+}
+
+// TryDeliver delivers an event to the queue and reports whether the event was accepted.
+// When the queue is full, it behaves according to the overflow policy:
+// blocks until the context is done (block), returns ErrQueueFull after the block timeout (block_timeout),
+// drops the event and returns ErrQueueFull (drop_newest), drops the oldest queued event (drop_oldest),
+// or returns ErrQueueFull (reject). ErrBusStopped is returned when the bus is stopped.
+//
+// Synchronous subscribers (see SynchronousSubscriber) handle the event inline before it's queued,
+// a PreDispatchError is returned when one of them rejected the event.
+// Disabled events are suppressed without error (see SetDisabledEvents),
+// the requester of a suppressed request (see RequestOn) receives ErrRequestDisabled.
+// When the bus has an Outbox, durable events are persisted before they are queued,
+// and discarded from the outbox when they are not accepted.
+func (b *DefaultEventBus) TryDeliver(ctx context.Context, event Event) error {
+	if b.isStoppedState() {
+		return ErrBusStopped
+	}
+	if b.suppress(event) {
+		rejectRequest(event, ErrRequestDisabled)
+		return nil
+	}
+	// Synchronous subscribers may deliver other events, so they run without holding the state lock
+	if err := b.preDispatch(event); err != nil {
+		return err
+	}
+	b.stateMu.RLock()
+	defer b.stateMu.RUnlock()
+	if b.isStopped {
+		return ErrBusStopped
+	}
+	if !b.isDurable(event) {
+		return b.enqueue(ctx, event)
+	}
+	if err := b.outbox.Append(event); err != nil {
+		return fmt.Errorf("cannot append event to outbox: %w", err)
+	}
+	err := b.enqueue(ctx, event)
+	if err != nil {
+		b.completeOutbox(event)
+	}
+	return err
+}
+
+// enqueue puts an event to the queue and records whether it's published or dropped
+func (b *DefaultEventBus) enqueue(ctx context.Context, event Event) error {
+	err := b.put(ctx, event)
+	if err == nil {
+		b.metrics.published(event)
+	} else if err == ErrQueueFull {
+		b.metrics.dropped(event)
+	}
+	return err
+}
+
+// put an event to the queue according to the overflow policy
+func (b *DefaultEventBus) put(ctx context.Context, event Event) error {
+	select {
+	case b.eventCh <- event:
+		return nil
+	default:
+	}
+	switch b.overflowPolicy {
+	case OverflowDropNewest:
+		atomic.AddInt64(&b.droppedCount, 1)
+		return ErrQueueFull
+	case OverflowReject:
+		atomic.AddInt64(&b.rejectedCount, 1)
+		return ErrQueueFull
+	case OverflowDropOldest:
+		return b.putDroppingOldest(ctx, event)
+	case OverflowBlockTimeout:
+		return b.putWithTimeout(ctx, event)
+	default:
+		select {
+		case b.eventCh <- event:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-b.closingCh:
+			return ErrBusStopped
+		}
+	}
+}
+
+// dropOldestAttempts is the maximum number of oldest events that are dropped for a delivering event,
+// the queue may be refilled by concurrent deliveries between attempts.
+const dropOldestAttempts = 3
+
+// putDroppingOldest drops the oldest queued event to make space for the event,
+// the event itself is dropped when the queue is still full after dropOldestAttempts.
+// An unbuffered queue has no oldest event, the event waits for the dispatcher as block_timeout.
+func (b *DefaultEventBus) putDroppingOldest(ctx context.Context, event Event) error {
+	if cap(b.eventCh) == 0 {
+		return b.putWithTimeout(ctx, event)
+	}
+	for attempt := 0; attempt < dropOldestAttempts; attempt++ {
+		select {
+		case oldest := <-b.eventCh:
+			atomic.AddInt64(&b.droppedCount, 1)
+			b.metrics.dropped(oldest)
+			if b.isDurable(oldest) {
+				b.completeOutbox(oldest)
+			}
+			b.debugLog(eventContext(oldest), "Event queue is full, the oldest event [%s] with id [%s] was dropped",
+				oldest.Name(), oldest.Identifier())
+		default:
+		}
+		select {
+		case b.eventCh <- event:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-b.closingCh:
+			return ErrBusStopped
+		default:
+		}
+	}
+	atomic.AddInt64(&b.droppedCount, 1)
+	return ErrQueueFull
+}
+
+// putWithTimeout waits for free space of the queue until the block timeout,
+// the event is dropped when the timeout is reached.
+func (b *DefaultEventBus) putWithTimeout(ctx context.Context, event Event) error {
+	timer := time.NewTimer(b.blockTimeout)
+	defer timer.Stop()
+	select {
+	case b.eventCh <- event:
+		return nil
+	case <-timer.C:
+		atomic.AddInt64(&b.droppedCount, 1)
+		return ErrQueueFull
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-b.closingCh:
+		return ErrBusStopped
+	}
 }
 
 func (b *DefaultEventBus) Run() {
+	b.stateMu.Lock()
+	defer b.stateMu.Unlock()
+	if b.isRunning || b.isStopped {
+		return
+	}
 	b.debugLog(context.Background(), "Default event bus is starting")
-	b.wg.Add(1)
-	go func() {
-		defer b.wg.Done()
-		for {
-			select {
-			case event := <-b.eventCh:
-				for _, subscriber := range b.subscribers {
-					if subscriber.Supports(event) {
-						subscriber := subscriber
-						b.executor.Execute(func() {
-							subscriber.Handle(event)
-						})
-					}
-				}
-				break
-			case isStop := <-b.stopCh:
-				if isStop {
+	// Pending events are loaded before any new event is accepted,
+	// so that an event is not both replayed and dispatched from the queue.
+	go b.dispatch(b.pendingOutboxEntries())
+	b.isRunning = true
+	for _, sub := range b.subscriptions() {
+		b.startSubscriber(sub.subscriber)
+	}
+	b.debugLog(context.Background(), "Default event bus is started")
+}
+
+// dispatch events in the queue to subscribers until the bus is stopped and the queue is drained,
+// or the shutdown deadline is exceeded.
+func (b *DefaultEventBus) dispatch(pendingOutboxEntries []*OutboxEntry) {
+	defer close(b.doneCh)
+	b.replay(pendingOutboxEntries)
+	for {
+		select {
+		case event := <-b.eventCh:
+			b.dispatchEvent(event, b.isDurable(event), nil)
+		case <-b.stopCh:
+			for {
+				select {
+				case event := <-b.eventCh:
+					b.dispatchEvent(event, b.isDurable(event), nil)
+				case <-b.abortCh:
+					return
+				default:
 					return
 				}
-				break
 			}
+		case <-b.abortCh:
+			return
+		}
+	}
+}
+
+// preDispatch runs synchronous subscribers that support the event in the dispatching order,
+// it stops at the first subscriber that rejected the event.
+func (b *DefaultEventBus) preDispatch(event Event) error {
+	ctx := handlingContext(event)
+	for _, sub := range b.subscriptions() {
+		if !sub.synchronous || b.isSubscriberDisabled(sub) || !sub.supports(event) {
+			continue
+		}
+		started := time.Now()
+		err := b.handleAttempt(ctx, sub.id, sub.subscriber, event)
+		b.metrics.handled(sub.id, started)
+		b.metrics.completed(event, err == nil)
+		if err != nil {
+			b.debugLog(eventContext(event), "Synchronous subscriber [%s] rejected event [%s] with id [%s], error [%v]",
+				sub.id, event.Name(), event.Identifier(), err)
+			return &PreDispatchError{SubscriberId: sub.id, Err: err}
+		}
+	}
+	return nil
+}
+
+// dispatchEvent to asynchronous subscribers that support it in the dispatching order,
+// except the done and the disabled ones. An event that is disabled after it was queued is suppressed.
+// A durable event is completed in the outbox when all subscribers handled it successfully.
+func (b *DefaultEventBus) dispatchEvent(event Event, durable bool, doneSubscribers map[string]bool) {
+	b.trackQueueLowWater()
+	if b.suppress(event) {
+		rejectRequest(event, ErrRequestDisabled)
+		if durable {
+			b.completeOutbox(event)
+		}
+		return
+	}
+	b.metrics.dispatched(time.Now())
+	subs := make([]*subscription, 0)
+	for _, sub := range b.subscriptions() {
+		if !sub.synchronous && !doneSubscribers[sub.id] && !b.isSubscriberDisabled(sub) && sub.supports(event) {
+			subs = append(subs, sub)
+		}
+	}
+	if !hasResponder(subs) {
+		rejectRequest(event, ErrNoResponder)
+	}
+	if !durable {
+		for _, sub := range subs {
+			sub := sub
+			b.execute(event, func() {
+				b.handle(sub.id, sub.subscriber, event)
+			})
+		}
+		return
+	}
+	if len(subs) == 0 {
+		b.completeOutbox(event)
+		return
+	}
+	tracker := &outboxTracker{remaining: int32(len(subs))}
+	for _, sub := range subs {
+		sub := sub
+		b.execute(event, func() {
+			b.markDone(event, sub.id, b.handle(sub.id, sub.subscriber, event), tracker)
+		})
+	}
+}
+
+// handle an event by a subscriber, errors and panics are isolated.
+// Failed handling is retried according to the retry policy of the subscriber,
+// when all attempts are failed, the last error is reported to the error handler
+// and the event is sent to the dead letter sink if any.
+// Returns whether the event is handled successfully.
+//
+// Each attempt is limited by the handler timeout of the subscriber (if any),
+// see handleAttempt.
+//
+// When idempotency is enabled for the subscriber, an event that it processed already
+// is skipped as a successful handling.
+func (b *DefaultEventBus) handle(subscriberId string, subscriber Subscriber, event Event) bool {
+	if b.isDuplicate(subscriberId, event) {
+		return true
+	}
+	ctx := handlingContext(event)
+	policy := b.retryPolicyOf(subscriberId, subscriber)
+	errs := make([]error, 0)
+	for attempt := 1; ; attempt++ {
+		started := time.Now()
+		err := b.handleAttempt(ctx, subscriberId, subscriber, event)
+		b.metrics.handled(subscriberId, started)
+		if err == nil {
+			b.markProcessed(subscriberId, event)
+			b.metrics.completed(event, true)
+			return true
+		}
+		errs = append(errs, err)
+		if !policy.ShouldRetry(attempt, err) || isAbandoned(err) {
+			break
+		}
+		backoff := policy.Backoff(attempt)
+		b.debugLog(ctx, "Subscriber [%s] failed to handle event [%s] with id [%s] at attempt [%d], retry after [%s], error [%v]",
+			subscriberId, event.Name(), event.Identifier(), attempt, backoff, err)
+		if !b.sleep(backoff) {
+			break
+		}
+	}
+	lastErr := errs[len(errs)-1]
+	var stack []byte
+	if panicErr, ok := lastErr.(*PanicError); ok {
+		stack = panicErr.Stack
+	}
+	b.metrics.completed(event, false)
+	b.errorHandler(event, subscriberId, lastErr, stack)
+	if b.deadLetterSink != nil {
+		b.deadLetterSink.Put(NewDeadLetter(subscriberId, event, errs))
+	}
+	return false
+}
+
+// sleep for a duration, returns false when the bus is aborted before the duration elapsed.
+func (b *DefaultEventBus) sleep(duration time.Duration) bool {
+	timer := time.NewTimer(duration)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-b.ctx.Done():
+		return false
+	}
+}
+
+func (b *DefaultEventBus) retryPolicyOf(subscriberId string, subscriber Subscriber) RetryPolicy {
+	if retryableSubscriber, ok := subscriber.(RetryableSubscriber); ok {
+		if policy := retryableSubscriber.RetryPolicy(); policy != nil {
+			return *policy
+		}
+	}
+	if policy, exists := overrideOf(b.subscriberRetryPolicies, subscriberId, subscriber); exists {
+		return policy
+	}
+	return b.retryPolicy
+}
+
+// intercept calls the subscriber through the handle interceptors,
+// a panic of interceptors is recovered and returned as PanicError.
+func (b *DefaultEventBus) intercept(ctx context.Context, subscriberId string, subscriber Subscriber, event Event) (err error) {
+	if len(b.handleInterceptors) == 0 {
+		return invoke(ctx, subscriber, event)
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			err = &PanicError{Value: r, Stack: debug.Stack()}
 		}
 	}()
-	b.debugLog(context.Background(), "Default event bus is started")
-	b.isRunning = true
+	return chainHandleInterceptors(b.handleInterceptors, subscriberId, func(ctx context.Context, event Event) error {
+		return invoke(ctx, subscriber, event)
+	})(ctx, event)
 }
 
+// invoke calls the subscriber to handle an event, a panic is recovered and returned as PanicError.
+func invoke(ctx context.Context, subscriber Subscriber, event Event) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = &PanicError{Value: r, Stack: debug.Stack()}
+		}
+	}()
+	if errorAwareSubscriber, ok := subscriber.(ErrorAwareSubscriber); ok {
+		return errorAwareSubscriber.HandleWithError(ctx, event)
+	}
+	subscriber.Handle(event)
+	return nil
+}
+
+// execute runs fn by the executor, events that provide an ordering key
+// are executed in order when the executor is a KeyedExecutor.
+// The execution is tracked as in-flight until fn returns.
+func (b *DefaultEventBus) execute(event Event, fn func()) {
+	b.inFlight.Add(1)
+	atomic.AddInt64(&b.inFlightCount, 1)
+	task := func() {
+		defer func() {
+			atomic.AddInt64(&b.inFlightCount, -1)
+			b.inFlight.Done()
+		}()
+		fn()
+	}
+	if keyedExecutor, ok := b.executor.(KeyedExecutor); ok {
+		if provider, ok := event.(OrderingKeyProvider); ok && provider.OrderingKey() != "" {
+			keyedExecutor.ExecuteWithKey(provider.OrderingKey(), task)
+			return
+		}
+	}
+	b.executor.Execute(task)
+}
+
+// Stop the bus and wait until all events are handled, see Shutdown.
 func (b *DefaultEventBus) Stop() {
-	b.debugLog(context.Background(), "Default event bus is stopping")
-	b.wg.Add(1)
+	_ = b.Shutdown(context.Background())
+}
+
+// Shutdown gracefully stops the bus: it stops accepting new events,
+// drains the queue and waits for in-flight handlings until the context is done,
+// then subscribers that implement StoppableSubscriber are stopped.
+// When the context is done first, remaining events are abandoned
+// and a ShutdownError is returned with number of abandoned events.
+func (b *DefaultEventBus) Shutdown(ctx context.Context) error {
+	if !atomic.CompareAndSwapInt32(&b.isClosing, 0, 1) {
+		return nil
+	}
+	b.debugLog(ctx, "Default event bus is stopping")
+	close(b.closingCh)
+	b.stateMu.Lock()
+	wasRunning := b.isRunning
+	b.isRunning = false
+	b.isStopped = true
+	close(b.stopCh)
+	b.stateMu.Unlock()
+
+	done := make(chan struct{})
 	go func() {
-		defer b.wg.Done()
-		for {
-			if len(b.eventCh) == 0 {
-				b.stopCh <- true
-				return
-			}
+		if wasRunning {
+			<-b.doneCh
 		}
+		b.inFlight.Wait()
+		<-b.attempts.idle()
+		b.stopSubscribers()
+		close(done)
 	}()
-	b.wg.Wait()
-	b.debugLog(context.Background(), "Default event bus is stopped")
-	b.isRunning = false
+	select {
+	case <-done:
+		b.cancel()
+		if queued := len(b.eventCh); queued > 0 {
+			b.debugLog(ctx, "Default event bus is stopped without running, [%d] queued events are abandoned", queued)
+			return &ShutdownError{Queued: queued}
+		}
+		b.debugLog(ctx, "Default event bus is stopped")
+		return nil
+	case <-ctx.Done():
+		close(b.abortCh)
+		b.cancel()
+		err := &ShutdownError{
+			Queued:   len(b.eventCh),
+			InFlight: int(atomic.LoadInt64(&b.inFlightCount)),
+			Err:      ctx.Err(),
+		}
+		b.debugLog(ctx, "Default event bus is stopped with error [%v]", err)
+		return err
+	}
+}
+
+// stopSubscribers stops registered subscribers that implement StoppableSubscriber
+func (b *DefaultEventBus) stopSubscribers() {
+	for _, sub := range b.subscriptions() {
+		if stoppable, ok := sub.subscriber.(StoppableSubscriber); ok {
+			stoppable.Stop()
+			b.debugLog(context.Background(), "Subscriber [%s] is stopped", sub.id)
+		}
+	}
+}
+
+// Redrive re-delivers a dead letter to the subscriber that failed to handle it.
+// The dead letter sink must be a DeadLetterStore.
+func (b *DefaultEventBus) Redrive(id string) error {
+	store, ok := b.deadLetterSink.(DeadLetterStore)
+	if !ok {
+		return ErrDeadLetterStoreNotFound
+	}
+	deadLetter, exists := store.Get(id)
+	if !exists {
+		return ErrDeadLetterNotFound
+	}
+	if !b.IsRunning() {
+		return ErrBusNotRunning
+	}
+	b.subsMu.RLock()
+	sub, exists := b.subscribers[deadLetter.SubscriberId]
+	b.subsMu.RUnlock()
+	if !exists {
+		return fmt.Errorf("subscriber [%s] of dead letter [%s] is not registered", deadLetter.SubscriberId, id)
+	}
+	store.Remove(id)
+	b.debugLog(eventContext(deadLetter.Event), "Redrive event [%s] with id [%s] to subscriber [%s]",
+		deadLetter.EventName, deadLetter.EventId, deadLetter.SubscriberId)
+	b.execute(deadLetter.Event, func() {
+		// The event is completed in the outbox when it is replayed
+		// and all subscribers are done.
+		if b.handle(sub.id, sub.subscriber, deadLetter.Event) && b.isDurable(deadLetter.Event) {
+			b.markOutboxDone(deadLetter.Event, sub.id)
+		}
+	})
+	return nil
+}
+
+// DroppedCount returns the number of events that were dropped by the overflow policy
+func (b *DefaultEventBus) DroppedCount() int64 {
+	return atomic.LoadInt64(&b.droppedCount)
+}
+
+// RejectedCount returns the number of events that were rejected by the overflow policy
+func (b *DefaultEventBus) RejectedCount() int64 {
+	return atomic.LoadInt64(&b.rejectedCount)
+}
+
+func (b *DefaultEventBus) isStoppedState() bool {
+	b.stateMu.RLock()
+	defer b.stateMu.RUnlock()
+	return b.isStopped
 }
 
 func (b *DefaultEventBus) IsRunning() bool {
+	b.stateMu.RLock()
+	defer b.stateMu.RUnlock()
 	return b.isRunning
 }
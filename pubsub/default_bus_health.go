@@ -0,0 +1,131 @@
+package pubsub
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/golibs-starter/golib/actuator"
+)
+
+// DefaultBusHealthChecker reports DOWN when the bus is not running,
+// or when its queue stays saturated longer than the saturation duration.
+// The queue is saturated when its size reaches the saturation ratio of its capacity,
+// it stays saturated when the dispatcher never saw the queue below the ratio between checks,
+// so that intermittent bursts don't make the bus DOWN.
+type DefaultBusHealthChecker struct {
+	component          string
+	bus                *DefaultEventBus
+	saturationRatio    float64
+	saturationDuration time.Duration
+	clock              Clock
+
+	saturatedSince time.Time
+	mu             sync.Mutex
+}
+
+type BusHealthCheckerOpt func(c *DefaultBusHealthChecker)
+
+// WithSaturationThreshold sets the ratio of the queue capacity that the queue is saturated,
+// and the duration that the queue stays saturated before the bus is DOWN.
+// Default is 0.9 and 30s.
+func WithSaturationThreshold(ratio float64, duration time.Duration) BusHealthCheckerOpt {
+	return func(c *DefaultBusHealthChecker) {
+		if ratio > 0 {
+			c.saturationRatio = ratio
+		}
+		if duration >= 0 {
+			c.saturationDuration = duration
+		}
+	}
+}
+
+// WithHealthCheckerClock replaces the system clock, such as by a fake clock in tests
+func WithHealthCheckerClock(clock Clock) BusHealthCheckerOpt {
+	return func(c *DefaultBusHealthChecker) {
+		c.clock = clock
+	}
+}
+
+func NewDefaultBusHealthChecker(bus EventBus, opts ...BusHealthCheckerOpt) (actuator.HealthChecker, error) {
+	implBus, ok := bus.(*DefaultEventBus)
+	if !ok {
+		return nil, errors.New("EventBus is not DefaultEventBus")
+	}
+	return newBusHealthChecker("event_bus", implBus, opts...), nil
+}
+
+// NewNamedBusHealthChecker checks a named bus with the component event_bus.<name>
+func NewNamedBusHealthChecker(name string, bus EventBus, opts ...BusHealthCheckerOpt) (actuator.HealthChecker, error) {
+	implBus, ok := bus.(*DefaultEventBus)
+	if !ok {
+		return nil, fmt.Errorf("EventBus [%s] is not DefaultEventBus", name)
+	}
+	return newBusHealthChecker("event_bus."+name, implBus, opts...), nil
+}
+
+func newBusHealthChecker(component string, bus *DefaultEventBus, opts ...BusHealthCheckerOpt) *DefaultBusHealthChecker {
+	checker := &DefaultBusHealthChecker{
+		component:          component,
+		bus:                bus,
+		saturationRatio:    0.9,
+		saturationDuration: 30 * time.Second,
+		clock:              SystemClock(),
+	}
+	for _, opt := range opts {
+		opt(checker)
+	}
+	return checker
+}
+
+// trackQueueLowWater records the queue size when the dispatcher takes an event
+func (b *DefaultEventBus) trackQueueLowWater() {
+	queued := int64(len(b.eventCh))
+	for {
+		lowWater := atomic.LoadInt64(&b.queueLowWater)
+		if queued >= lowWater || atomic.CompareAndSwapInt64(&b.queueLowWater, lowWater, queued) {
+			return
+		}
+	}
+}
+
+// resetQueueLowWater returns the lowest queue size since the previous reset,
+// then restarts tracking from the current size.
+func (b *DefaultEventBus) resetQueueLowWater() int64 {
+	return atomic.SwapInt64(&b.queueLowWater, int64(len(b.eventCh)))
+}
+
+func (c *DefaultBusHealthChecker) Component() string {
+	return c.component
+}
+
+func (c *DefaultBusHealthChecker) Check(ctx context.Context) actuator.StatusDetails {
+	if !c.bus.IsRunning() {
+		return actuator.StatusDetails{Status: actuator.StatusDown, Reason: ErrBusNotRunning.Error()}
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	capacity, size := cap(c.bus.eventCh), len(c.bus.eventCh)
+	lowWater := c.bus.resetQueueLowWater()
+	threshold := c.saturationRatio * float64(capacity)
+	// An unbuffered queue is never saturated
+	if capacity == 0 || float64(size) < threshold {
+		c.saturatedSince = time.Time{}
+		return actuator.StatusDetails{Status: actuator.StatusUp}
+	}
+	now := c.clock.Now()
+	// The queue was drained below the threshold since the previous check
+	if c.saturatedSince.IsZero() || float64(lowWater) < threshold {
+		c.saturatedSince = now
+	}
+	if saturated := now.Sub(c.saturatedSince); saturated >= c.saturationDuration {
+		return actuator.StatusDetails{
+			Status: actuator.StatusDown,
+			Reason: fmt.Sprintf("event queue is saturated [%d/%d] for [%s]", size, capacity, saturated),
+		}
+	}
+	return actuator.StatusDetails{Status: actuator.StatusUp}
+}
@@ -2,10 +2,12 @@ package pubsub
 
 import (
 	"errors"
+	"fmt"
 	"github.com/golibs-starter/golib/actuator"
 )
 
 type DefaultBusInformer struct {
+	key string
 	bus *DefaultEventBus
 }
 
@@ -14,16 +16,109 @@ func NewDefaultBusInformer(bus EventBus) (actuator.Informer, error) {
 	if !ok {
 		return nil, errors.New("EventBus is not DefaultEventBus")
 	}
-	return &DefaultBusInformer{bus: implBus}, nil
+	return &DefaultBusInformer{key: "event_bus", bus: implBus}, nil
+}
+
+// NewNamedBusInformer shows a named bus with the key event_bus.<name>
+func NewNamedBusInformer(name string, bus EventBus) (actuator.Informer, error) {
+	implBus, ok := bus.(*DefaultEventBus)
+	if !ok {
+		return nil, fmt.Errorf("EventBus [%s] is not DefaultEventBus", name)
+	}
+	return &DefaultBusInformer{key: "event_bus." + name, bus: implBus}, nil
 }
 
 func (d DefaultBusInformer) Key() string {
-	return "event_bus"
+	return d.key
 }
 
 func (d DefaultBusInformer) Value() interface{} {
-	return map[string]interface{}{
+	value := map[string]interface{}{
 		"channel_capacity":     cap(d.bus.eventCh),
 		"channel_current_size": len(d.bus.eventCh),
+		"overflow_policy":      d.bus.overflowPolicy,
+		"dropped_events":       d.bus.DroppedCount(),
+		"rejected_events":      d.bus.RejectedCount(),
+		"duplicate_events":     d.bus.DuplicateCount(),
+		"suppressed_events":    d.bus.SuppressedCount(),
+		"timed_out_handlings":  d.bus.TimeoutCount(),
+		"disabled_listeners":   d.bus.DisabledSubscribers(),
+		"disabled_events":      d.bus.DisabledEvents(),
+		"events":               d.bus.EventMetrics(),
+	}
+	if lastDispatchAt := d.bus.LastDispatchAt(); !lastDispatchAt.IsZero() {
+		value["last_dispatch_at"] = lastDispatchAt
+	}
+	// Schemas are global, they are only shown with the default bus
+	if schemas := GetEventTypeRegistry().SchemaStats(); len(schemas) > 0 && d.key == "event_bus" {
+		value["schemas"] = schemas
+	}
+	if outbox, ok := d.bus.outbox.(interface{ Len() int }); ok {
+		value["outbox_pending_events"] = outbox.Len()
+	}
+	return value
+}
+
+// DefaultBusSubscriberInformer lists subscribers of
+// the bus and names of events that they support.
+type DefaultBusSubscriberInformer struct {
+	key string
+	bus *DefaultEventBus
+}
+
+func NewDefaultBusSubscriberInformer(bus EventBus) (actuator.Informer, error) {
+	implBus, ok := bus.(*DefaultEventBus)
+	if !ok {
+		return nil, errors.New("EventBus is not DefaultEventBus")
+	}
+	return &DefaultBusSubscriberInformer{key: "event_subscribers", bus: implBus}, nil
+}
+
+// NewNamedBusSubscriberInformer lists subscribers of a named bus with the key event_subscribers.<name>
+func NewNamedBusSubscriberInformer(name string, bus EventBus) (actuator.Informer, error) {
+	implBus, ok := bus.(*DefaultEventBus)
+	if !ok {
+		return nil, fmt.Errorf("EventBus [%s] is not DefaultEventBus", name)
+	}
+	return &DefaultBusSubscriberInformer{key: "event_subscribers." + name, bus: implBus}, nil
+}
+
+func (d DefaultBusSubscriberInformer) Key() string {
+	return d.key
+}
+
+func (d DefaultBusSubscriberInformer) Value() interface{} {
+	return d.bus.Subscribers()
+}
+
+// EventSchedulerInformer shows counters of the scheduler and its pending events
+type EventSchedulerInformer struct {
+	scheduler *EventScheduler
+}
+
+func NewEventSchedulerInformer(scheduler *EventScheduler) actuator.Informer {
+	return &EventSchedulerInformer{scheduler: scheduler}
+}
+
+func (e EventSchedulerInformer) Key() string {
+	return "event_scheduler"
+}
+
+func (e EventSchedulerInformer) Value() interface{} {
+	pending := e.scheduler.Pending()
+	events := make([]map[string]interface{}, 0, len(pending))
+	for _, scheduled := range pending {
+		events = append(events, map[string]interface{}{
+			"id":     scheduled.Event.Identifier(),
+			"event":  scheduled.Event.Name(),
+			"due_at": scheduled.DueAt,
+		})
+	}
+	return map[string]interface{}{
+		"pending_events":   events,
+		"published_events": e.scheduler.PublishedCount(),
+		"cancelled_events": e.scheduler.CancelledCount(),
+		"failed_attempts":  e.scheduler.FailedCount(),
+		"dropped_events":   e.scheduler.DroppedCount(),
 	}
 }
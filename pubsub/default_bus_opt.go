@@ -1,5 +1,7 @@
 package pubsub
 
+import "time"
+
 type EventBusOpt func(bus *DefaultEventBus)
 
 func WithEventBusDebugLog(debugLog DebugLog) EventBusOpt {
@@ -19,3 +21,99 @@ func WithEventExecutor(executor Executor) EventBusOpt {
 		bus.executor = executor
 	}
 }
+
+func WithEventErrorHandler(errorHandler ErrorHandler) EventBusOpt {
+	return func(bus *DefaultEventBus) {
+		bus.errorHandler = errorHandler
+	}
+}
+
+// WithRetryPolicy sets the default retry policy for all subscribers
+func WithRetryPolicy(policy RetryPolicy) EventBusOpt {
+	return func(bus *DefaultEventBus) {
+		bus.retryPolicy = policy
+	}
+}
+
+// WithSubscriberRetryPolicy sets the retry policy for specific subscribers,
+// they are matched by id or full name of struct (or patterns with `*`), the same way as disabled subscribers.
+// The first matched policy is used.
+func WithSubscriberRetryPolicy(subscriberPattern string, policy RetryPolicy) EventBusOpt {
+	return func(bus *DefaultEventBus) {
+		bus.subscriberRetryPolicies = setOverride(bus.subscriberRetryPolicies, subscriberPattern, policy)
+	}
+}
+
+// WithHandlerTimeout sets the default handler timeout for all subscribers, zero means no timeout
+func WithHandlerTimeout(timeout time.Duration) EventBusOpt {
+	return func(bus *DefaultEventBus) {
+		bus.handlerTimeout = timeout
+	}
+}
+
+// WithSubscriberHandlerTimeout sets the handler timeout for specific subscribers,
+// they are matched the same way as WithSubscriberRetryPolicy.
+func WithSubscriberHandlerTimeout(subscriberPattern string, timeout time.Duration) EventBusOpt {
+	return func(bus *DefaultEventBus) {
+		bus.subscriberHandlerTimeouts = setOverride(bus.subscriberHandlerTimeouts, subscriberPattern, timeout)
+	}
+}
+
+func WithDeadLetterSink(sink DeadLetterSink) EventBusOpt {
+	return func(bus *DefaultEventBus) {
+		bus.deadLetterSink = sink
+	}
+}
+
+// WithOverflowPolicy sets the behavior when the event channel is full,
+// the blockTimeout is only used by OverflowBlockTimeout policy.
+func WithOverflowPolicy(policy OverflowPolicy, blockTimeout time.Duration) EventBusOpt {
+	return func(bus *DefaultEventBus) {
+		bus.overflowPolicy = policy
+		bus.blockTimeout = blockTimeout
+	}
+}
+
+// WithHandleInterceptors appends interceptors around handling of events,
+// interceptors are called in the given order, the first one is the outermost.
+func WithHandleInterceptors(interceptors ...HandleInterceptor) EventBusOpt {
+	return func(bus *DefaultEventBus) {
+		bus.handleInterceptors = append(bus.handleInterceptors, interceptors...)
+	}
+}
+
+// WithOutbox persists events to the outbox before they are accepted,
+// only events that their name match one of the patterns are persisted,
+// all events are persisted when no pattern is given.
+func WithOutbox(outbox Outbox, eventPatterns ...string) EventBusOpt {
+	return func(bus *DefaultEventBus) {
+		bus.outbox = outbox
+		bus.outboxEvents = eventPatterns
+	}
+}
+
+// WithIdempotency skips events that subscribers processed already, by the store.
+// Only subscribers that their id match one of the patterns are idempotent,
+// all subscribers are idempotent when no pattern is given.
+func WithIdempotency(store IdempotencyStore, subscriberPatterns ...string) EventBusOpt {
+	return func(bus *DefaultEventBus) {
+		bus.idempotencyStore = store
+		bus.idempotentSubscribers = subscriberPatterns
+	}
+}
+
+// WithDisabledSubscribers disables subscribers that their id or the full name
+// of their struct match one of the patterns, see DefaultEventBus.SetDisabledSubscribers.
+func WithDisabledSubscribers(patterns ...string) EventBusOpt {
+	return func(bus *DefaultEventBus) {
+		bus.disabledSubscribers = patterns
+	}
+}
+
+// WithDisabledEvents suppresses events that their name match one of the patterns,
+// see DefaultEventBus.SetDisabledEvents.
+func WithDisabledEvents(patterns ...string) EventBusOpt {
+	return func(bus *DefaultEventBus) {
+		bus.disabledEvents = patterns
+	}
+}
@@ -2,10 +2,14 @@ package pubsub
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	baseEvent "github.com/golibs-starter/golib/event"
 	"github.com/golibs-starter/golib/pubsub/executor"
 	assert "github.com/stretchr/testify/require"
 	"reflect"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 )
@@ -22,16 +26,20 @@ func TestNewDefaultEventBus_WhenNoOpts_ShouldUseDefaultValue(t *testing.T) {
 	assert.Equal(t, cap(bus.eventCh), 0)
 	assert.Equal(t, reflect.ValueOf(defaultDebugLog).Pointer(), reflect.ValueOf(bus.debugLog).Pointer())
 	assert.IsType(t, new(executor.AsyncExecutor), bus.executor)
+	assert.Equal(t, reflect.ValueOf(defaultErrorHandler).Pointer(), reflect.ValueOf(bus.errorHandler).Pointer())
 }
 
 func TestNewDefaultEventBus_WhenUseOpts_ShouldSetOptCorrectly(t *testing.T) {
 	var logger DebugLog = func(ctx context.Context, msgFormat string, args ...interface{}) {
 	}
+	var errorHandler ErrorHandler = func(event Event, subscriberId string, err error, stack []byte) {
+	}
 	syncExecutor := executor.NewSyncExecutor()
 	bus := NewDefaultEventBus(
 		WithEventBusDebugLog(logger),
 		WithEventExecutor(syncExecutor),
 		WithEventChannelSize(12),
+		WithEventErrorHandler(errorHandler),
 	)
 	assert.NotNil(t, bus.subscribers)
 	assert.Len(t, bus.subscribers, 0)
@@ -43,6 +51,7 @@ func TestNewDefaultEventBus_WhenUseOpts_ShouldSetOptCorrectly(t *testing.T) {
 	assert.Equal(t, cap(bus.eventCh), 12)
 	assert.Equal(t, reflect.ValueOf(logger).Pointer(), reflect.ValueOf(bus.debugLog).Pointer())
 	assert.Equal(t, syncExecutor, bus.executor)
+	assert.Equal(t, reflect.ValueOf(errorHandler).Pointer(), reflect.ValueOf(bus.errorHandler).Pointer())
 }
 
 type DummySubscriber1 struct {
@@ -100,6 +109,62 @@ func (d DummySubscriber2) Supports(event Event) bool {
 func (d DummySubscriber2) Handle(event Event) {
 }
 
+type DummyPanicSubscriber struct {
+}
+
+func (d DummyPanicSubscriber) Supports(event Event) bool {
+	return true
+}
+
+func (d DummyPanicSubscriber) Handle(event Event) {
+	panic("dummy panic")
+}
+
+type DummyErrorSubscriber struct {
+}
+
+func (d DummyErrorSubscriber) Supports(event Event) bool {
+	return true
+}
+
+func (d DummyErrorSubscriber) Handle(event Event) {
+}
+
+func (d DummyErrorSubscriber) HandleWithError(ctx context.Context, event Event) error {
+	return errors.New("dummy error")
+}
+
+type DummyFlakySubscriber struct {
+	failures int32
+	attempts int32
+	policy   *RetryPolicy
+}
+
+func (d *DummyFlakySubscriber) Supports(event Event) bool {
+	return true
+}
+
+func (d *DummyFlakySubscriber) Handle(event Event) {
+}
+
+func (d *DummyFlakySubscriber) HandleWithError(ctx context.Context, event Event) error {
+	if atomic.AddInt32(&d.attempts, 1) <= atomic.LoadInt32(&d.failures) {
+		return errors.New("transient error")
+	}
+	return nil
+}
+
+func (d *DummyFlakySubscriber) RetryPolicy() *RetryPolicy {
+	return d.policy
+}
+
+type dummyErrorReport struct {
+	subscriberId string
+	eventName    string
+	err          error
+	stack        []byte
+}
+
 type DummyEvent struct {
 	name string
 	ctx  context.Context
@@ -127,6 +192,15 @@ func (d DummyEvent) String() string {
 	return "dummy-event-string"
 }
 
+type DummyOrderedEvent struct {
+	DummyEvent
+	key string
+}
+
+func (d DummyOrderedEvent) OrderingKey() string {
+	return d.key
+}
+
 func TestDefaultEventBus_WhenRegisterSubscribers_ShouldRegisterCorrectly(t *testing.T) {
 	bus := NewDefaultEventBus()
 	s1 := DummySubscriber1{}
@@ -138,8 +212,8 @@ func TestDefaultEventBus_WhenRegisterSubscribers_ShouldRegisterCorrectly(t *test
 	assert.True(t, s1Exists)
 	assert.True(t, s2Exists)
 	assert.Len(t, bus.subscribers, 2)
-	assert.Equal(t, &s1, sub1)
-	assert.Equal(t, &s2, sub2)
+	assert.Equal(t, &s1, sub1.subscriber)
+	assert.Equal(t, &s2, sub2.subscriber)
 }
 
 func TestDefaultEventBus_GivenAsyncExecutor_WhenDeliverEvent_ShouldRunCorrectly(t *testing.T) {
@@ -186,3 +260,499 @@ func TestDefaultEventBus_GivenSyncExecutor_WhenDeliverEvent_ShouldRunCorrectly(t
 	assert.Equal(t, "event-3", s1.orderedEventRun[2])
 	assert.Equal(t, "event-4", s1.orderedEventRun[3])
 }
+
+func TestDefaultEventBus_GivenPartitionedExecutor_WhenDeliverEventsWithSameKey_ShouldRunInOrder(t *testing.T) {
+	partitionedExecutor := executor.NewPartitionedExecutor(4, 10)
+	bus := NewDefaultEventBus(WithEventExecutor(partitionedExecutor))
+	s1 := DummySubscriber1{}
+	bus.Register(&s1)
+	bus.Run()
+	for i := 1; i <= 4; i++ {
+		bus.Deliver(&DummyOrderedEvent{DummyEvent: DummyEvent{name: fmt.Sprintf("event-%d", i)}, key: "order-1"})
+	}
+	bus.Stop()
+	partitionedExecutor.Close()
+	assert.Equal(t, 4, s1.numberOfOrderedEventRun())
+	assert.Equal(t, []string{"event-1", "event-2", "event-3", "event-4"}, s1.orderedEventRun)
+}
+
+func TestDefaultEventBus_WhenSubscriberPanicOrReturnError_ShouldReportToErrorHandler(t *testing.T) {
+	var mu sync.Mutex
+	reports := make(map[string]dummyErrorReport)
+	bus := NewDefaultEventBus(
+		WithEventExecutor(executor.NewSyncExecutor()),
+		WithEventErrorHandler(func(event Event, subscriberId string, err error, stack []byte) {
+			mu.Lock()
+			defer mu.Unlock()
+			reports[subscriberId] = dummyErrorReport{
+				subscriberId: subscriberId,
+				eventName:    event.Name(),
+				err:          err,
+				stack:        stack,
+			}
+		}),
+	)
+	s1 := DummySubscriber1{}
+	bus.Register(&s1, &DummyPanicSubscriber{}, &DummyErrorSubscriber{})
+	bus.Run()
+	bus.Deliver(&DummyEvent{name: "event-1"})
+	bus.Deliver(&DummyEvent{name: "event-2"})
+	bus.Stop()
+
+	// The dispatch loop must survive the panic
+	assert.Equal(t, 2, s1.numberOfEventRan())
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Len(t, reports, 2)
+	panicReport := reports["pubsub.DummyPanicSubscriber"]
+	assert.Equal(t, "event-2", panicReport.eventName)
+	assert.IsType(t, &PanicError{}, panicReport.err)
+	assert.Equal(t, "dummy panic", panicReport.err.(*PanicError).Value)
+	assert.NotEmpty(t, panicReport.stack)
+
+	errorReport := reports["pubsub.DummyErrorSubscriber"]
+	assert.Equal(t, "event-2", errorReport.eventName)
+	assert.EqualError(t, errorReport.err, "dummy error")
+	assert.Empty(t, errorReport.stack)
+}
+
+func TestDefaultEventBus_WhenSubscriberFailedTransiently_ShouldRetryByPolicy(t *testing.T) {
+	reported := int32(0)
+	bus := NewDefaultEventBus(
+		WithEventExecutor(executor.NewSyncExecutor()),
+		WithEventErrorHandler(func(event Event, subscriberId string, err error, stack []byte) {
+			atomic.AddInt32(&reported, 1)
+		}),
+	)
+	s1 := &DummyFlakySubscriber{failures: 2, policy: &RetryPolicy{MaxAttempts: 3, InitialInterval: time.Millisecond}}
+	bus.Register(s1)
+	bus.Run()
+	bus.Deliver(&DummyEvent{name: "event-1"})
+	bus.Stop()
+	assert.Equal(t, int32(3), atomic.LoadInt32(&s1.attempts))
+	assert.Equal(t, int32(0), atomic.LoadInt32(&reported))
+}
+
+func TestDefaultEventBus_WhenRetriesExhausted_ShouldSendToDeadLetterAndRedrive(t *testing.T) {
+	store := NewInMemoryDeadLetterStore(10)
+	bus := NewDefaultEventBus(
+		WithEventExecutor(executor.NewSyncExecutor()),
+		WithEventErrorHandler(func(event Event, subscriberId string, err error, stack []byte) {}),
+		WithRetryPolicy(RetryPolicy{MaxAttempts: 2, InitialInterval: time.Millisecond}),
+		WithDeadLetterSink(store),
+	)
+	s1 := &DummyFlakySubscriber{failures: 2}
+	bus.Register(s1)
+	bus.Run()
+	bus.Deliver(&DummyEvent{name: "event-1"})
+	assert.Eventually(t, func() bool { return len(store.List()) == 1 }, time.Second, time.Millisecond)
+
+	assert.Equal(t, int32(2), atomic.LoadInt32(&s1.attempts))
+	deadLetters := store.List()
+	assert.Len(t, deadLetters, 1)
+	assert.Equal(t, "pubsub.DummyFlakySubscriber", deadLetters[0].SubscriberId)
+	assert.Equal(t, "event-1", deadLetters[0].EventName)
+	assert.Equal(t, []string{"transient error", "transient error"}, deadLetters[0].Errors)
+
+	assert.ErrorIs(t, bus.Redrive("not-found"), ErrDeadLetterNotFound)
+	assert.NoError(t, bus.Redrive(deadLetters[0].Id))
+	bus.Stop()
+	assert.Equal(t, int32(3), atomic.LoadInt32(&s1.attempts))
+	assert.Empty(t, store.List())
+	assert.ErrorIs(t, bus.Redrive(deadLetters[0].Id), ErrDeadLetterNotFound)
+}
+
+type DummyBlockingSubscriber struct {
+	release  chan struct{}
+	started  chan struct{}
+	finished int32
+}
+
+func (d *DummyBlockingSubscriber) Supports(event Event) bool {
+	return true
+}
+
+func (d *DummyBlockingSubscriber) Handle(event Event) {
+	d.started <- struct{}{}
+	<-d.release
+	atomic.AddInt32(&d.finished, 1)
+}
+
+func TestDefaultEventBus_WhenShutdown_ShouldWaitForInFlightHandlers(t *testing.T) {
+	bus := NewDefaultEventBus()
+	s1 := &DummyBlockingSubscriber{release: make(chan struct{}), started: make(chan struct{}, 1)}
+	bus.Register(s1)
+	bus.Run()
+	bus.Deliver(&DummyEvent{name: "event-1"})
+	<-s1.started
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		close(s1.release)
+	}()
+	assert.NoError(t, bus.Shutdown(context.Background()))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&s1.finished))
+	assert.False(t, bus.IsRunning())
+}
+
+func TestDefaultEventBus_WhenShutdownDeadlineExceeded_ShouldReportAbandonedEvents(t *testing.T) {
+	bus := NewDefaultEventBus(WithEventExecutor(executor.NewSyncExecutor()), WithEventChannelSize(10))
+	s1 := &DummyBlockingSubscriber{release: make(chan struct{}), started: make(chan struct{}, 10)}
+	defer close(s1.release)
+	bus.Register(s1)
+	bus.Run()
+	bus.Deliver(&DummyEvent{name: "event-1"})
+	bus.Deliver(&DummyEvent{name: "event-2"})
+	bus.Deliver(&DummyEvent{name: "event-3"})
+	<-s1.started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	err := bus.Shutdown(ctx)
+	var shutdownErr *ShutdownError
+	assert.ErrorAs(t, err, &shutdownErr)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Equal(t, 2, shutdownErr.Queued)
+	assert.Equal(t, 1, shutdownErr.InFlight)
+	assert.Equal(t, 3, shutdownErr.Abandoned())
+}
+
+func TestDefaultEventBus_WhenDeliverAfterShutdown_ShouldNotBlock(t *testing.T) {
+	bus := NewDefaultEventBus()
+	bus.Run()
+	assert.NoError(t, bus.Shutdown(context.Background()))
+	assert.NoError(t, bus.Shutdown(context.Background()))
+
+	delivered := make(chan struct{})
+	go func() {
+		bus.Deliver(&DummyEvent{name: "event-1"})
+		close(delivered)
+	}()
+	select {
+	case <-delivered:
+	case <-time.After(time.Second):
+		t.Fatal("deliver after shutdown is blocked")
+	}
+}
+
+func TestDefaultEventBus_WhenShutdownWithoutRunning_ShouldReleaseBlockedDeliveries(t *testing.T) {
+	bus := NewDefaultEventBus(WithEventChannelSize(1))
+	bus.Deliver(&DummyEvent{name: "event-1"})
+	delivered := make(chan struct{})
+	go func() {
+		bus.Deliver(&DummyEvent{name: "event-2"})
+		close(delivered)
+	}()
+	time.Sleep(10 * time.Millisecond)
+	err := bus.Shutdown(context.Background())
+	var shutdownErr *ShutdownError
+	assert.ErrorAs(t, err, &shutdownErr)
+	assert.Equal(t, 1, shutdownErr.Queued)
+	<-delivered
+}
+
+func TestDefaultEventBus_GivenOverflowPolicy_WhenQueueIsFull_ShouldApplyPolicy(t *testing.T) {
+	t.Run("drop newest", func(t *testing.T) {
+		bus := NewDefaultEventBus(WithEventChannelSize(1), WithOverflowPolicy(OverflowDropNewest, 0))
+		assert.NoError(t, bus.TryDeliver(context.Background(), &DummyEvent{name: "event-1"}))
+		assert.ErrorIs(t, bus.TryDeliver(context.Background(), &DummyEvent{name: "event-2"}), ErrQueueFull)
+		assert.Equal(t, "event-1", (<-bus.eventCh).Name())
+		assert.Equal(t, int64(1), bus.DroppedCount())
+		assert.Equal(t, int64(0), bus.RejectedCount())
+	})
+
+	t.Run("drop oldest", func(t *testing.T) {
+		bus := NewDefaultEventBus(WithEventChannelSize(1), WithOverflowPolicy(OverflowDropOldest, 0))
+		assert.NoError(t, bus.TryDeliver(context.Background(), &DummyEvent{name: "event-1"}))
+		assert.NoError(t, bus.TryDeliver(context.Background(), &DummyEvent{name: "event-2"}))
+		assert.Equal(t, "event-2", (<-bus.eventCh).Name())
+		assert.Equal(t, int64(1), bus.DroppedCount())
+	})
+
+	t.Run("drop oldest of unbuffered queue", func(t *testing.T) {
+		bus := NewDefaultEventBus(WithEventChannelSize(0), WithOverflowPolicy(OverflowDropOldest, 20*time.Millisecond))
+		start := time.Now()
+		assert.ErrorIs(t, bus.TryDeliver(context.Background(), &DummyEvent{name: "event-1"}), ErrQueueFull)
+		assert.GreaterOrEqual(t, time.Since(start), 20*time.Millisecond)
+		assert.Equal(t, int64(1), bus.DroppedCount())
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		assert.NoError(t, bus.Shutdown(ctx))
+	})
+
+	t.Run("reject", func(t *testing.T) {
+		bus := NewDefaultEventBus(WithEventChannelSize(1), WithOverflowPolicy(OverflowReject, 0))
+		assert.NoError(t, bus.TryDeliver(context.Background(), &DummyEvent{name: "event-1"}))
+		assert.ErrorIs(t, bus.TryDeliver(context.Background(), &DummyEvent{name: "event-2"}), ErrQueueFull)
+		assert.Equal(t, int64(0), bus.DroppedCount())
+		assert.Equal(t, int64(1), bus.RejectedCount())
+	})
+
+	t.Run("block timeout", func(t *testing.T) {
+		bus := NewDefaultEventBus(WithEventChannelSize(1), WithOverflowPolicy(OverflowBlockTimeout, 20*time.Millisecond))
+		assert.NoError(t, bus.TryDeliver(context.Background(), &DummyEvent{name: "event-1"}))
+		start := time.Now()
+		assert.ErrorIs(t, bus.TryDeliver(context.Background(), &DummyEvent{name: "event-2"}), ErrQueueFull)
+		assert.GreaterOrEqual(t, time.Since(start), 20*time.Millisecond)
+		assert.Equal(t, int64(1), bus.DroppedCount())
+	})
+
+	t.Run("block until context is done", func(t *testing.T) {
+		bus := NewDefaultEventBus(WithEventChannelSize(1))
+		assert.Equal(t, OverflowBlock, bus.overflowPolicy)
+		assert.NoError(t, bus.TryDeliver(context.Background(), &DummyEvent{name: "event-1"}))
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+		assert.ErrorIs(t, bus.TryDeliver(ctx, &DummyEvent{name: "event-2"}), context.DeadlineExceeded)
+		assert.Equal(t, int64(0), bus.DroppedCount())
+	})
+}
+
+func TestDefaultEventBus_WhenTryDeliverAfterShutdown_ShouldReturnErrBusStopped(t *testing.T) {
+	bus := NewDefaultEventBus()
+	bus.Run()
+	assert.NoError(t, bus.Shutdown(context.Background()))
+	assert.ErrorIs(t, bus.TryDeliver(context.Background(), &DummyEvent{name: "event-1"}), ErrBusStopped)
+}
+
+func TestDefaultEventBus_WhenSubscribeDifferentInstancesOfSameType_ShouldRegisterAll(t *testing.T) {
+	bus := NewDefaultEventBus()
+	s1 := DummySubscriber1{}
+	s2 := DummySubscriber1{}
+	sub1, err := bus.Subscribe(&s1)
+	assert.NoError(t, err)
+	sub2, err := bus.Subscribe(&s2)
+	assert.NoError(t, err)
+	_, err = bus.Subscribe(&s1)
+	assert.Error(t, err)
+	assert.Equal(t, "pubsub.DummySubscriber1", sub1.Id())
+	assert.Equal(t, "pubsub.DummySubscriber1#2", sub2.Id())
+	assert.Len(t, bus.subscribers, 2)
+}
+
+func TestDefaultEventBus_WhenSubscribeAndUnsubscribeWhileRunning_ShouldApplyToNextEvents(t *testing.T) {
+	bus := NewDefaultEventBus(WithEventExecutor(executor.NewSyncExecutor()))
+	bus.Run()
+	s1 := DummySubscriber1{}
+	sub1, err := bus.Subscribe(&s1)
+	assert.NoError(t, err)
+	bus.Deliver(&DummyEvent{name: "event-1"})
+	assert.Eventually(t, func() bool { return s1.eventRan("event-1") }, time.Second, time.Millisecond)
+
+	sub1.Unsubscribe()
+	sub1.Unsubscribe()
+	assert.Len(t, bus.Subscribers(), 0)
+	bus.Deliver(&DummyEvent{name: "event-2"})
+	bus.Stop()
+	assert.Equal(t, 1, s1.numberOfEventRan())
+}
+
+func TestDefaultEventBus_Subscribers_ShouldListSupportedEvents(t *testing.T) {
+	bus := NewDefaultEventBus(WithEventExecutor(executor.NewSyncExecutor()))
+	s1 := DummySubscriber1{}
+	bus.Register(&s1)
+	_, err := bus.RegisterHandler("order.*", func(e Event) {})
+	assert.NoError(t, err)
+	bus.Run()
+	bus.Deliver(&DummyEvent{name: "event-1"})
+	bus.Stop()
+
+	infos := bus.Subscribers()
+	assert.Len(t, infos, 2)
+	assert.Equal(t, "handler[order.*](github.com/golibs-starter/golib/pubsub.TestDefaultEventBus_Subscribers_ShouldListSupportedEvents.func1)", infos[0].Id)
+	assert.Equal(t, "*pubsub.handlerSubscriber", infos[0].Type)
+	assert.Equal(t, []string{"order.*"}, infos[0].SupportedEvents)
+	assert.Nil(t, infos[0].Latency)
+	assert.NotNil(t, infos[1].Latency)
+	assert.EqualValues(t, 1, infos[1].Latency.Count)
+	infos[1].Latency = nil
+	assert.Equal(t, SubscriberInfo{
+		Id:              "pubsub.DummySubscriber1",
+		Type:            "*pubsub.DummySubscriber1",
+		SupportedEvents: []string{"event-1"},
+	}, infos[1])
+}
+
+type DummyOrderedSubscriber struct {
+	id          string
+	priority    int
+	synchronous bool
+	handle      func(id string, event Event) error
+}
+
+func (d *DummyOrderedSubscriber) SubscriberId() string {
+	return d.id
+}
+
+func (d *DummyOrderedSubscriber) Priority() int {
+	return d.priority
+}
+
+func (d *DummyOrderedSubscriber) Synchronous() bool {
+	return d.synchronous
+}
+
+func (d *DummyOrderedSubscriber) Supports(event Event) bool {
+	return true
+}
+
+func (d *DummyOrderedSubscriber) Handle(event Event) {
+}
+
+func (d *DummyOrderedSubscriber) HandleWithError(ctx context.Context, event Event) error {
+	return d.handle(d.id, event)
+}
+
+type DummyEnrichedEvent struct {
+	DummyEvent
+	tags []string
+}
+
+func TestDefaultEventBus_WhenDeliverEvent_ShouldDispatchByPriorityThenRegistrationOrder(t *testing.T) {
+	bus := NewDefaultEventBus(WithEventExecutor(executor.NewSyncExecutor()))
+	var mu sync.Mutex
+	handled := make([]string, 0)
+	record := func(id string, event Event) error {
+		mu.Lock()
+		defer mu.Unlock()
+		handled = append(handled, id)
+		return nil
+	}
+	bus.Register(
+		&DummyOrderedSubscriber{id: "async-default-1", handle: record},
+		&DummyOrderedSubscriber{id: "async-low", priority: -1, handle: record},
+		&DummyOrderedSubscriber{id: "sync-low", priority: -5, synchronous: true, handle: record},
+		&DummyOrderedSubscriber{id: "async-high", priority: 10, handle: record},
+		&DummyOrderedSubscriber{id: "async-default-2", handle: record},
+		&DummyOrderedSubscriber{id: "sync-high", priority: 5, synchronous: true, handle: record},
+	)
+	bus.Run()
+	defer bus.Stop()
+
+	assert.NoError(t, bus.TryDeliver(context.Background(), &DummyEvent{name: "event-1"}))
+	assert.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(handled) == 6
+	}, time.Second, 5*time.Millisecond)
+	assert.Equal(t, []string{"sync-high", "sync-low", "async-high", "async-default-1", "async-default-2", "async-low"}, handled)
+
+	infos := bus.Subscribers()
+	assert.Equal(t, "async-default-1", infos[0].Id)
+	assert.Equal(t, 0, infos[0].Priority)
+	assert.Equal(t, "sync-high", infos[4].Id)
+	assert.Equal(t, 5, infos[4].Priority)
+	assert.True(t, infos[4].Synchronous)
+}
+
+func TestDefaultEventBus_GivenSynchronousSubscriber_ShouldEnrichOrRejectEventBeforeQueued(t *testing.T) {
+	bus := NewDefaultEventBus(WithEventExecutor(executor.NewAsyncExecutor()))
+	received := make(chan []string, 2)
+	bus.Register(
+		&DummyOrderedSubscriber{id: "async", handle: func(id string, event Event) error {
+			received <- event.(*DummyEnrichedEvent).tags
+			return nil
+		}},
+		&DummyOrderedSubscriber{id: "validator", synchronous: true, priority: 1, handle: func(id string, event Event) error {
+			if event.Name() == "invalid" {
+				return errors.New("event is invalid")
+			}
+			return nil
+		}},
+		&DummyOrderedSubscriber{id: "enricher", synchronous: true, handle: func(id string, event Event) error {
+			e := event.(*DummyEnrichedEvent)
+			e.tags = append(e.tags, "enriched")
+			return nil
+		}},
+	)
+	bus.Run()
+	defer bus.Stop()
+
+	err := bus.TryDeliver(context.Background(), &DummyEnrichedEvent{DummyEvent: DummyEvent{name: "invalid"}})
+	var preDispatchErr *PreDispatchError
+	assert.ErrorAs(t, err, &preDispatchErr)
+	assert.Equal(t, "validator", preDispatchErr.SubscriberId)
+	assert.EqualError(t, errors.Unwrap(err), "event is invalid")
+
+	assert.NoError(t, bus.TryDeliver(context.Background(), &DummyEnrichedEvent{DummyEvent: DummyEvent{name: "valid"}}))
+	select {
+	case tags := <-received:
+		assert.Equal(t, []string{"enriched"}, tags)
+	case <-time.After(time.Second):
+		t.Fatal("enriched event is not received")
+	}
+	assert.Len(t, received, 0)
+}
+
+func TestDefaultEventBus_GivenDisabledSubscribersAndEvents_ShouldNotDispatchThem(t *testing.T) {
+	bus := NewDefaultEventBus(
+		WithEventExecutor(executor.NewSyncExecutor()),
+		WithDisabledSubscribers("pubsub.DummySubscriber*"),
+		WithDisabledEvents("order.*"),
+	)
+	s1 := DummySubscriber1{}
+	s2 := DummySubscriber1{}
+	bus.Register(&s1)
+	_, err := bus.Subscribe(&identifiedSubscriber{id: "audit", Subscriber: &s2})
+	assert.NoError(t, err)
+	bus.Run()
+	defer bus.Stop()
+
+	assert.NoError(t, bus.TryDeliver(context.Background(), &DummyEvent{name: "order.created"}))
+	assert.NoError(t, bus.TryDeliver(context.Background(), &DummyEvent{name: "payment.created"}))
+	assert.Eventually(t, func() bool {
+		return s2.numberOfOrderedEventRun() == 1
+	}, time.Second, 5*time.Millisecond)
+	assert.True(t, s2.eventRan("payment.created"))
+	assert.Equal(t, 0, s1.numberOfOrderedEventRun())
+	assert.EqualValues(t, 1, bus.SuppressedCount())
+
+	infos := bus.Subscribers()
+	assert.Equal(t, "audit", infos[0].Id)
+	assert.False(t, infos[0].Disabled)
+	assert.Equal(t, "pubsub.DummySubscriber1", infos[1].Id)
+	assert.True(t, infos[1].Disabled)
+
+	// Switch them on again at runtime
+	bus.SetDisabledSubscribers()
+	bus.SetDisabledEvents("payment.*")
+	assert.NoError(t, bus.TryDeliver(context.Background(), &DummyEvent{name: "order.created"}))
+	assert.NoError(t, bus.TryDeliver(context.Background(), &DummyEvent{name: "payment.created"}))
+	assert.Eventually(t, func() bool {
+		return s1.numberOfOrderedEventRun() == 1
+	}, time.Second, 5*time.Millisecond)
+	assert.True(t, s1.eventRan("order.created"))
+	assert.EqualValues(t, 2, bus.SuppressedCount())
+	assert.Equal(t, []string{"payment.*"}, bus.DisabledEvents())
+	assert.Empty(t, bus.DisabledSubscribers())
+}
+
+type DummyCascadeSubscriber struct {
+	followUps chan *baseEvent.ApplicationEvent
+}
+
+func (d *DummyCascadeSubscriber) Supports(event Event) bool {
+	return event.Name() == "event-1"
+}
+
+func (d *DummyCascadeSubscriber) Handle(event Event) {
+}
+
+func (d *DummyCascadeSubscriber) HandleWithError(ctx context.Context, event Event) error {
+	d.followUps <- baseEvent.NewApplicationEvent(ctx, "event-2")
+	return nil
+}
+
+func TestDefaultEventBus_WhenPublishInsideHandler_ShouldSetCausationOfFollowUpEvent(t *testing.T) {
+	bus := NewDefaultEventBus(WithEventExecutor(executor.NewSyncExecutor()))
+	s := &DummyCascadeSubscriber{followUps: make(chan *baseEvent.ApplicationEvent, 1)}
+	bus.Register(s)
+	bus.Run()
+	defer bus.Stop()
+
+	// The handling context carries the cause even when the event context does not
+	bus.Deliver(&DummyEvent{name: "event-1", ctx: context.Background()})
+	followUp := <-s.followUps
+	assert.Equal(t, "dummy-event-id", followUp.CausationId)
+	assert.Equal(t, "dummy-event-id", followUp.CorrelationId)
+}
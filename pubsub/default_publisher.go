@@ -1,11 +1,21 @@
 package pubsub
 
-import "fmt"
+import (
+	"context"
+	"fmt"
+	"time"
+)
 
 type DefaultPublisher struct {
 	bus                    EventBus
 	debugLog               DebugLog
 	notLogPayloadForEvents map[string]bool
+	interceptors           []PublishInterceptor
+	publishFn              PublishFunc
+	scheduler              *EventScheduler
+	schemaRegistry         *EventTypeRegistry
+	validationMode         ValidationMode
+	routes                 []eventRoute
 }
 
 func NewDefaultPublisher(bus EventBus, opts ...PublisherOpt) *DefaultPublisher {
@@ -16,19 +26,70 @@ func NewDefaultPublisher(bus EventBus, opts ...PublisherOpt) *DefaultPublisher {
 	if pub.debugLog == nil {
 		pub.debugLog = defaultDebugLog
 	}
+	pub.publishFn = chainPublishInterceptors(pub.interceptors, pub.deliver)
 	return pub
 }
 
 func (p *DefaultPublisher) Publish(event Event) {
-	if !p.bus.IsRunning() {
+	if err := p.PublishCtx(context.Background(), event); err == ErrBusNotRunning {
 		fmt.Printf("WARN: Event bus is not running, event [%s] was ignored. Please add golib.EventOpt() to your bootstrap\n", event.Name())
-		return
+	} else if err != nil {
+		p.debugLog(event.Context(), "Event [%s] with id [%s] was not fired, error [%v]",
+			event.Name(), event.Identifier(), err)
+	}
+}
+
+// PublishCtx publishes an event by the interceptors, it returns ErrBusNotRunning when the bus is not started yet,
+// or ErrBusStopped when the bus is shutdown (such as scheduled events that are due after the shutdown).
+func (p *DefaultPublisher) PublishCtx(ctx context.Context, event Event) error {
+	if bus := p.busOf(event); !bus.IsRunning() {
+		if stoppedBus, ok := bus.(stoppableBus); ok && stoppedBus.isStoppedState() {
+			return ErrBusStopped
+		}
+		return ErrBusNotRunning
+	}
+	return p.publishFn(ctx, event)
+}
+
+// PublishAt schedules an event by the scheduler of WithScheduler,
+// the interceptors are called when the event is due.
+func (p *DefaultPublisher) PublishAt(at time.Time, event Event) (*ScheduleHandle, error) {
+	if p.scheduler == nil {
+		return nil, ErrSchedulingNotSupported
+	}
+	return p.scheduler.Schedule(at, event)
+}
+
+func (p *DefaultPublisher) PublishAfter(delay time.Duration, event Event) (*ScheduleHandle, error) {
+	if p.scheduler == nil {
+		return nil, ErrSchedulingNotSupported
+	}
+	return p.scheduler.Schedule(p.scheduler.clock.Now().Add(delay), event)
+}
+
+// stoppableBus is implemented by DefaultEventBus and buses that embed it,
+// a stopped bus never runs again.
+type stoppableBus interface {
+	isStoppedState() bool
+}
+
+// deliver is the innermost PublishFunc of the interceptor chain,
+// the payload is validated before it's delivered when schema validation is enabled.
+// The event is delivered to the bus of its route (see WithEventRoute) or the bus of the publisher.
+func (p *DefaultPublisher) deliver(ctx context.Context, event Event) error {
+	if p.schemaRegistry != nil {
+		if err := p.validateSchema(ctx, event); err != nil {
+			return err
+		}
+	}
+	if err := TryDeliverOn(ctx, p.busOf(event), event); err != nil {
+		return err
 	}
-	p.bus.Deliver(event)
 	if p.notLogPayloadForEvents != nil && p.notLogPayloadForEvents[event.Name()] {
 		p.debugLog(event.Context(), "Event [%s] was fired with id [%s]", event.Name(), event.Identifier())
 	} else {
 		p.debugLog(event.Context(), "Event [%s] was fired with id [%s], payload [%+v]",
 			event.Name(), event.Identifier(), event.Payload())
 	}
+	return nil
 }
@@ -1,5 +1,7 @@
 package pubsub
 
+import "reflect"
+
 type PublisherOpt func(pub *DefaultPublisher)
 
 func WithPublisherDebugLog(debugLog DebugLog) PublisherOpt {
@@ -16,3 +18,57 @@ func WithPublisherNotLogPayload(events []string) PublisherOpt {
 		}
 	}
 }
+
+// WithPublishInterceptors appends interceptors around publishing of events,
+// interceptors are called in the given order, the first one is the outermost.
+func WithPublishInterceptors(interceptors ...PublishInterceptor) PublisherOpt {
+	return func(pub *DefaultPublisher) {
+		pub.interceptors = append(pub.interceptors, interceptors...)
+	}
+}
+
+// WithScheduler enables PublishAt and PublishAfter, the scheduler
+// must be started with PublishCtx of the publisher (see EventScheduler.Start).
+func WithScheduler(scheduler *EventScheduler) PublisherOpt {
+	return func(pub *DefaultPublisher) {
+		pub.scheduler = scheduler
+	}
+}
+
+// WithSchemaValidation validates payloads by schemas of the registry before they are delivered,
+// the current schema version is stamped to events that have no version.
+func WithSchemaValidation(registry *EventTypeRegistry, mode ValidationMode) PublisherOpt {
+	return func(pub *DefaultPublisher) {
+		pub.schemaRegistry = registry
+		pub.validationMode = mode
+	}
+}
+
+// WithEventRoute delivers events that their name match one of the patterns to another bus,
+// such as a named bus of telemetry events. Routes are matched in the order that they are added.
+func WithEventRoute(bus EventBus, eventPatterns ...string) PublisherOpt {
+	return func(pub *DefaultPublisher) {
+		pub.routes = append(pub.routes, eventRoute{bus: bus, patterns: eventPatterns})
+	}
+}
+
+// WithEventTypeRoute delivers events that have the same type as one of the prototypes
+// (such as &event.RequestCompletedEvent{}) to another bus, see WithEventRoute.
+func WithEventTypeRoute(bus EventBus, prototypes ...Event) PublisherOpt {
+	return func(pub *DefaultPublisher) {
+		types := make(map[reflect.Type]bool, len(prototypes))
+		for _, prototype := range prototypes {
+			types[reflect.TypeOf(prototype)] = true
+		}
+		pub.routes = append(pub.routes, eventRoute{bus: bus, types: types})
+	}
+}
+
+// WithEventTypeNameRoute delivers events that the full name of their struct
+// (such as event.RequestCompletedEvent) match one of the patterns to another bus,
+// it's the configurable form of WithEventTypeRoute.
+func WithEventTypeNameRoute(bus EventBus, typePatterns ...string) PublisherOpt {
+	return func(pub *DefaultPublisher) {
+		pub.routes = append(pub.routes, eventRoute{bus: bus, typeNames: typePatterns})
+	}
+}
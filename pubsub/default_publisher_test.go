@@ -2,6 +2,7 @@ package pubsub
 
 import (
 	"context"
+	"github.com/golibs-starter/golib/pubsub/executor"
 	assert "github.com/stretchr/testify/require"
 	"reflect"
 	"testing"
@@ -66,3 +67,25 @@ func TestDefaultPublisher_WhenPublishEvent_ShouldPublishCorrectly(t *testing.T)
 	assert.Contains(t, logMsgs["event-1"], "payload")
 	assert.NotContains(t, logMsgs["event-2"], "payload")
 }
+
+func TestDefaultPublisher_WhenPublishCtx_ShouldReportWhetherEventIsAccepted(t *testing.T) {
+	bus := NewDefaultEventBus(WithEventChannelSize(1), WithOverflowPolicy(OverflowReject, 0))
+	pub := NewDefaultPublisher(bus)
+	assert.ErrorIs(t, pub.PublishCtx(context.Background(), &DummyEvent{name: "event-1"}), ErrBusNotRunning)
+
+	s1 := &DummyBlockingSubscriber{release: make(chan struct{}), started: make(chan struct{}, 10)}
+	defer close(s1.release)
+	bus = NewDefaultEventBus(
+		WithEventExecutor(executor.NewSyncExecutor()),
+		WithEventChannelSize(1),
+		WithOverflowPolicy(OverflowReject, 0),
+	)
+	bus.Register(s1)
+	bus.Run()
+	pub = NewDefaultPublisher(bus)
+	assert.NoError(t, pub.PublishCtx(context.Background(), &DummyEvent{name: "event-1"}))
+	<-s1.started
+	assert.NoError(t, pub.PublishCtx(context.Background(), &DummyEvent{name: "event-2"}))
+	assert.ErrorIs(t, pub.PublishCtx(context.Background(), &DummyEvent{name: "event-3"}), ErrQueueFull)
+	assert.Equal(t, int64(1), bus.RejectedCount())
+}
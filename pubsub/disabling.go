@@ -0,0 +1,111 @@
+package pubsub
+
+import (
+	"context"
+	"sync/atomic"
+
+	"github.com/golibs-starter/golib/utils"
+)
+
+// SetDisabledSubscribers replaces patterns of disabled subscribers, it's safe to call while the bus is running.
+// A subscriber is disabled when its id or the full name of its struct matches one of the patterns,
+// disabled subscribers stay registered but events are not dispatched to them.
+func (b *DefaultEventBus) SetDisabledSubscribers(patterns ...string) {
+	b.disabledMu.Lock()
+	defer b.disabledMu.Unlock()
+	b.disabledSubscribers = append([]string{}, patterns...)
+}
+
+// SetDisabledEvents replaces patterns of disabled events, it's safe to call while the bus is running.
+// An event is suppressed when its name matches one of the patterns,
+// suppressed events are neither queued nor dispatched to subscribers.
+func (b *DefaultEventBus) SetDisabledEvents(patterns ...string) {
+	b.disabledMu.Lock()
+	defer b.disabledMu.Unlock()
+	b.disabledEvents = append([]string{}, patterns...)
+}
+
+// DisabledSubscribers returns patterns of disabled subscribers
+func (b *DefaultEventBus) DisabledSubscribers() []string {
+	b.disabledMu.RLock()
+	defer b.disabledMu.RUnlock()
+	return append([]string{}, b.disabledSubscribers...)
+}
+
+// DisabledEvents returns patterns of disabled events
+func (b *DefaultEventBus) DisabledEvents() []string {
+	b.disabledMu.RLock()
+	defer b.disabledMu.RUnlock()
+	return append([]string{}, b.disabledEvents...)
+}
+
+// SuppressedCount returns the number of events that were suppressed since they are disabled
+func (b *DefaultEventBus) SuppressedCount() int64 {
+	return atomic.LoadInt64(&b.suppressedCount)
+}
+
+func (b *DefaultEventBus) isSubscriberDisabled(sub *subscription) bool {
+	b.disabledMu.RLock()
+	defer b.disabledMu.RUnlock()
+	if len(b.disabledSubscribers) == 0 {
+		return false
+	}
+	return utils.MatchAnyWildcard(b.disabledSubscribers, sub.id) ||
+		utils.MatchAnyWildcard(b.disabledSubscribers, utils.GetStructFullname(sub.subscriber))
+}
+
+// suppress returns whether the event is disabled, a disabled event is counted as suppressed
+func (b *DefaultEventBus) suppress(event Event) bool {
+	b.disabledMu.RLock()
+	disabled := len(b.disabledEvents) > 0 && utils.MatchAnyWildcard(b.disabledEvents, event.Name())
+	b.disabledMu.RUnlock()
+	if disabled {
+		atomic.AddInt64(&b.suppressedCount, 1)
+		b.debugLog(eventContext(event), "Event [%s] with id [%s] is disabled, it's suppressed",
+			event.Name(), event.Identifier())
+	}
+	return disabled
+}
+
+// logDisabledSubscriber logs when a disabled subscriber is registered
+func (b *DefaultEventBus) logDisabledSubscriber(sub *subscription) {
+	if b.isSubscriberDisabled(sub) {
+		b.debugLog(context.Background(), "Subscriber [%s] is disabled, events are not dispatched to it", sub.id)
+	}
+}
+
+// subscriberOverride overrides a setting of subscribers that match the pattern,
+// they are matched by id or full name of struct like disabled subscribers.
+type subscriberOverride[T any] struct {
+	pattern string
+	value   T
+}
+
+// setOverride replaces the value of the pattern, or adds the pattern when it does not exist
+func setOverride[T any](overrides []subscriberOverride[T], pattern string, value T) []subscriberOverride[T] {
+	for i := range overrides {
+		if overrides[i].pattern == pattern {
+			overrides[i].value = value
+			return overrides
+		}
+	}
+	return append(overrides, subscriberOverride[T]{pattern: pattern, value: value})
+}
+
+// overrideOf returns the value of the first override that matches the subscriber
+func overrideOf[T any](overrides []subscriberOverride[T], subscriberId string, subscriber Subscriber) (T, bool) {
+	var fullname string
+	for _, override := range overrides {
+		if utils.MatchWildcard(override.pattern, subscriberId) {
+			return override.value, true
+		}
+		if fullname == "" {
+			fullname = utils.GetStructFullname(subscriber)
+		}
+		if utils.MatchWildcard(override.pattern, fullname) {
+			return override.value, true
+		}
+	}
+	var zero T
+	return zero, false
+}
@@ -0,0 +1,62 @@
+package pubsub
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrBusNotRunning = errors.New("event bus is not running")
+	ErrBusStopped    = errors.New("event bus is stopped")
+	ErrQueueFull     = errors.New("event queue is full")
+
+	ErrHandlerTimeout = errors.New("event handler is timeout")
+
+	ErrSchedulerStopped       = errors.New("event scheduler is stopped")
+	ErrSchedulingNotSupported = errors.New("scheduled publishing is not supported")
+)
+
+// ShutdownError is returned when the bus is shut down
+// before all events are handled, the remaining events are abandoned.
+type ShutdownError struct {
+	// Queued is the number of events that are still in the queue
+	Queued int
+
+	// InFlight is the number of handlings that are still running
+	InFlight int
+
+	// Err is the reason, such as context.DeadlineExceeded
+	Err error
+}
+
+// Abandoned returns the total number of abandoned events and handlings
+func (e *ShutdownError) Abandoned() int {
+	return e.Queued + e.InFlight
+}
+
+func (e *ShutdownError) Error() string {
+	msg := fmt.Sprintf("event bus shutdown abandoned [%d] queued events and [%d] in-flight handlings",
+		e.Queued, e.InFlight)
+	if e.Err != nil {
+		msg += ": " + e.Err.Error()
+	}
+	return msg
+}
+
+func (e *ShutdownError) Unwrap() error {
+	return e.Err
+}
+
+// PreDispatchError is returned when a SynchronousSubscriber rejected an event
+type PreDispatchError struct {
+	SubscriberId string
+	Err          error
+}
+
+func (e *PreDispatchError) Error() string {
+	return fmt.Sprintf("event is rejected by subscriber [%s]: %v", e.SubscriberId, e.Err)
+}
+
+func (e *PreDispatchError) Unwrap() error {
+	return e.Err
+}
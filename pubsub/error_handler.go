@@ -0,0 +1,42 @@
+package pubsub
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/golibs-starter/golib/log"
+	"github.com/golibs-starter/golib/log/field"
+)
+
+// ErrorHandler is called when a subscriber failed to handle an event,
+// either by returning an error (see ErrorAwareSubscriber) or by panicking.
+// The stack is only available when the subscriber panicked.
+type ErrorHandler func(event Event, subscriberId string, err error, stack []byte)
+
+var defaultErrorHandler ErrorHandler = func(event Event, subscriberId string, err error, stack []byte) {
+	logger := log.WithCtx(eventContext(event)).WithErrors(err)
+	if len(stack) > 0 {
+		logger = logger.WithField(field.String("stacktrace", string(stack)))
+	}
+	logger.Errorf("Subscriber [%s] failed to handle event [%s] with id [%s]",
+		subscriberId, event.Name(), event.Identifier())
+}
+
+// PanicError is reported to the ErrorHandler when a subscriber panics
+type PanicError struct {
+	Value interface{}
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("subscriber panic: %v", e.Value)
+}
+
+// eventContext returns the context of event or
+// a background context when the event has no context.
+func eventContext(event Event) context.Context {
+	if ctx := event.Context(); ctx != nil {
+		return ctx
+	}
+	return context.Background()
+}
@@ -23,6 +23,15 @@ type Event interface {
 	String() string
 }
 
+// OrderingKeyProvider is an optional interface for an Event.
+// When the bus is running with a KeyedExecutor, events with the same
+// ordering key are handled in the order they were published.
+type OrderingKeyProvider interface {
+	// OrderingKey returns the key used to order this event,
+	// such as an aggregate id. Empty key means no ordering is required.
+	OrderingKey() string
+}
+
 type MessageEvent[T any] struct {
 	*baseEvent.AbstractEvent
 	PayloadData T `json:"payload"`
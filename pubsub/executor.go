@@ -5,3 +5,13 @@ type Executor interface {
 	// Execute a function
 	Execute(fn func())
 }
+
+// KeyedExecutor is an Executor that can guarantee ordering for functions
+// submitted with the same key (see executor.PartitionedExecutor).
+type KeyedExecutor interface {
+	Executor
+
+	// ExecuteWithKey executes a function, functions with the
+	// same key are executed in the order they were submitted.
+	ExecuteWithKey(key string, fn func())
+}
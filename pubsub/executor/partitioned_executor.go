@@ -0,0 +1,97 @@
+package executor
+
+import (
+	"hash/fnv"
+	"sync"
+	"sync/atomic"
+)
+
+// PartitionedExecutor runs functions on a fixed number of workers (partitions).
+// Functions submitted with the same key are always run by the same worker
+// in submission order, while functions with different keys may run in parallel.
+//
+// Submitting to a full partition blocks the caller, that is the dispatcher of the bus.
+// When a handler publishes to the same bus while the event channel is full under the block
+// overflow policy, the dispatcher waits for the handler's partition and the handler waits
+// for the event channel, both are stuck until the bus is shutdown. Size the queue for bursts
+// of published events, or use an overflow policy that does not wait forever (such as block_timeout).
+// Close the executor after the bus is shutdown, such as in an fx.Hook.
+type PartitionedExecutor struct {
+	partitions []chan func()
+	next       uint32
+	closed     bool
+	mu         sync.RWMutex
+	wg         sync.WaitGroup
+}
+
+// NewPartitionedExecutor creates a PartitionedExecutor with the given number of
+// partitions, each partition buffers up to queueSize functions before blocking the caller.
+func NewPartitionedExecutor(partitions int, queueSize int) *PartitionedExecutor {
+	if partitions <= 0 {
+		partitions = 1
+	}
+	if queueSize < 0 {
+		queueSize = 0
+	}
+	p := &PartitionedExecutor{
+		partitions: make([]chan func(), partitions),
+	}
+	for i := range p.partitions {
+		p.partitions[i] = make(chan func(), queueSize)
+		p.wg.Add(1)
+		go p.work(p.partitions[i])
+	}
+	return p
+}
+
+// Execute runs a function without ordering key,
+// functions are distributed to partitions in round-robin manner.
+func (p *PartitionedExecutor) Execute(fn func()) {
+	idx := atomic.AddUint32(&p.next, 1) % uint32(len(p.partitions))
+	p.submit(int(idx), fn)
+}
+
+// ExecuteWithKey runs a function in the partition that owned by the key.
+func (p *PartitionedExecutor) ExecuteWithKey(key string, fn func()) {
+	p.submit(p.partitionOf(key), fn)
+}
+
+// Close stops accepting new functions and waits for queued functions to finish.
+// Functions submitted after Close are run in the caller goroutine.
+func (p *PartitionedExecutor) Close() {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return
+	}
+	p.closed = true
+	for _, partition := range p.partitions {
+		close(partition)
+	}
+	p.mu.Unlock()
+	p.wg.Wait()
+}
+
+func (p *PartitionedExecutor) submit(idx int, fn func()) {
+	p.mu.RLock()
+	if p.closed {
+		p.mu.RUnlock()
+		fn()
+		return
+	}
+	p.partitions[idx] <- fn
+	p.mu.RUnlock()
+}
+
+func (p *PartitionedExecutor) partitionOf(key string) int {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(key))
+	return int(h.Sum32() % uint32(len(p.partitions)))
+}
+
+func (p *PartitionedExecutor) work(partition chan func()) {
+	defer p.wg.Done()
+	for fn := range partition {
+		fn()
+	}
+}
@@ -0,0 +1,68 @@
+package executor
+
+import (
+	assert "github.com/stretchr/testify/require"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestPartitionedExecutor_WhenExecuteWithSameKey_ShouldRunInOrder(t *testing.T) {
+	e := NewPartitionedExecutor(4, 10)
+	var mu sync.Mutex
+	results := make(map[string][]int)
+	for i := 0; i < 100; i++ {
+		i := i
+		for _, key := range []string{"order-1", "order-2", "order-3"} {
+			key := key
+			e.ExecuteWithKey(key, func() {
+				mu.Lock()
+				results[key] = append(results[key], i)
+				mu.Unlock()
+			})
+		}
+	}
+	e.Close()
+	assert.Len(t, results, 3)
+	for _, values := range results {
+		assert.Len(t, values, 100)
+		for i, v := range values {
+			assert.Equal(t, i, v)
+		}
+	}
+}
+
+func TestPartitionedExecutor_WhenAKeyIsBlocked_ShouldNotBlockOtherPartitions(t *testing.T) {
+	e := NewPartitionedExecutor(2, 10)
+	defer e.Close()
+	blockedKey, freeKey := "", ""
+	for _, key := range []string{"a", "b", "c", "d", "e"} {
+		if e.partitionOf(key) == 0 && blockedKey == "" {
+			blockedKey = key
+		}
+		if e.partitionOf(key) == 1 && freeKey == "" {
+			freeKey = key
+		}
+	}
+	assert.NotEmpty(t, blockedKey)
+	assert.NotEmpty(t, freeKey)
+
+	release := make(chan struct{})
+	e.ExecuteWithKey(blockedKey, func() { <-release })
+	done := make(chan struct{})
+	e.ExecuteWithKey(freeKey, func() { close(done) })
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("function of the free partition was not executed")
+	}
+	close(release)
+}
+
+func TestPartitionedExecutor_WhenClosed_ShouldRunInCallerGoroutine(t *testing.T) {
+	e := NewPartitionedExecutor(1, 0)
+	e.Close()
+	ran := false
+	e.Execute(func() { ran = true })
+	assert.True(t, ran)
+}
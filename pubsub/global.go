@@ -3,12 +3,21 @@ package pubsub
 import (
 	"context"
 	"reflect"
+	"sync"
+	"time"
 
 	"github.com/golibs-starter/golib/web/event"
 )
 
 var _bus EventBus = NewDefaultEventBus()
 var _publisher Publisher = NewDefaultPublisher(_bus)
+var _registry = NewEventTypeRegistry()
+var _namedBuses = make(map[string]EventBus)
+var _namedBusesMu sync.RWMutex
+
+func init() {
+	_ = _registry.Register("RequestCompletedEvent", &event.RequestCompletedEvent{})
+}
 
 func GetEventBus() EventBus {
 	return _bus
@@ -18,10 +27,20 @@ func GetPublisher() Publisher {
 	return _publisher
 }
 
+// GetEventTypeRegistry returns the global EventTypeRegistry,
+// message types of PublishEvent and Subscribe are registered automatically.
+func GetEventTypeRegistry() *EventTypeRegistry {
+	return _registry
+}
+
 func Register(subscribers ...Subscriber) {
 	_bus.Register(subscribers...)
 }
 
+func RegisterHandler(topicName string, handler any) (Subscription, error) {
+	return RegisterHandlerOn(_bus, topicName, handler)
+}
+
 func Run() {
 	_bus.Run()
 }
@@ -30,8 +49,31 @@ func Publish(event Event) {
 	_publisher.Publish(event)
 }
 
+// PublishCtx publishes an event by the global publisher and returns an error when the event is not accepted,
+// see PublishCtxOn.
+func PublishCtx(ctx context.Context, event Event) error {
+	return PublishCtxOn(ctx, _publisher, event)
+}
+
+// PublishAt publishes an event at the given time when the global publisher is a SchedulingPublisher.
+func PublishAt(at time.Time, event Event) (*ScheduleHandle, error) {
+	if publisher, ok := _publisher.(SchedulingPublisher); ok {
+		return publisher.PublishAt(at, event)
+	}
+	return nil, ErrSchedulingNotSupported
+}
+
+// PublishAfter publishes an event after the given delay when the global publisher is a SchedulingPublisher.
+func PublishAfter(delay time.Duration, event Event) (*ScheduleHandle, error) {
+	if publisher, ok := _publisher.(SchedulingPublisher); ok {
+		return publisher.PublishAfter(delay, event)
+	}
+	return nil, ErrSchedulingNotSupported
+}
+
 // PublishEvent is a helper function to publish a message as an event directly.
 func PublishEvent[T any](ctx context.Context, msg T) {
+	_ = RegisterMessage[T](_registry)
 	Publish(MessageEvent[T]{
 		AbstractEvent: event.NewAbstractEvent(ctx, reflect.TypeOf(msg).Name()),
 		PayloadData:   msg,
@@ -50,3 +92,18 @@ func ReplaceGlobal(bus EventBus, publisher Publisher) {
 	_bus = bus
 	_publisher = publisher
 }
+
+// ReplaceGlobalNamed replaces the global bus of a name, such as an isolated bus of telemetry events.
+// Events are published to named buses by routes of the publisher, see WithEventRoute.
+func ReplaceGlobalNamed(name string, bus EventBus) {
+	_namedBusesMu.Lock()
+	defer _namedBusesMu.Unlock()
+	_namedBuses[name] = bus
+}
+
+// GetNamedEventBus returns the global bus of a name, it returns nil when the bus is not registered.
+func GetNamedEventBus(name string) EventBus {
+	_namedBusesMu.RLock()
+	defer _namedBusesMu.RUnlock()
+	return _namedBuses[name]
+}
@@ -1,6 +1,8 @@
 package pubsub
 
 import (
+	"context"
+
 	assert "github.com/stretchr/testify/require"
 	"testing"
 	"time"
@@ -35,8 +37,8 @@ func TestGlobalRegister(t *testing.T) {
 	assert.True(t, sub1e)
 	assert.True(t, sub2e)
 	assert.Len(t, _bus.(*DefaultEventBus).subscribers, 2)
-	assert.Equal(t, &s1, sub1)
-	assert.Equal(t, &s2, sub2)
+	assert.Equal(t, &s1, sub1.subscriber)
+	assert.Equal(t, &s2, sub2.subscriber)
 }
 
 func TestGlobalPublish(t *testing.T) {
@@ -61,3 +63,41 @@ func TestGlobalPublish(t *testing.T) {
 	assert.Contains(t, s1.orderedEventRun, "event-1")
 	assert.Contains(t, s1.orderedEventRun, "event-2")
 }
+
+// basicEventBus only implements EventBus, such as a bus of a third party
+type basicEventBus struct {
+	running   bool
+	delivered []Event
+}
+
+func (b *basicEventBus) Register(subscribers ...Subscriber) {}
+
+func (b *basicEventBus) Deliver(event Event) {
+	b.delivered = append(b.delivered, event)
+}
+
+func (b *basicEventBus) Run() {
+	b.running = true
+}
+
+func (b *basicEventBus) Stop() {
+	b.running = false
+}
+
+func (b *basicEventBus) IsRunning() bool {
+	return b.running
+}
+
+func TestOptionalBusInterfaces_WhenBusOnlyImplementsEventBus_ShouldFallback(t *testing.T) {
+	bus := &basicEventBus{}
+	assert.ErrorIs(t, TryDeliverOn(context.Background(), bus, &DummyEvent{name: "event-1"}), ErrBusNotRunning)
+	bus.Run()
+	assert.NoError(t, TryDeliverOn(context.Background(), bus, &DummyEvent{name: "event-2"}))
+	assert.Len(t, bus.delivered, 1)
+
+	_, err := RegisterHandlerOn(bus, "", func(e Event) {})
+	assert.Error(t, err)
+
+	assert.NoError(t, ShutdownBus(context.Background(), bus))
+	assert.False(t, bus.IsRunning())
+}
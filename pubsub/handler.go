@@ -0,0 +1,134 @@
+package pubsub
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"runtime"
+
+	"github.com/golibs-starter/golib/utils"
+)
+
+var (
+	contextType = reflect.TypeOf((*context.Context)(nil)).Elem()
+	errorType   = reflect.TypeOf((*error)(nil)).Elem()
+	eventType   = reflect.TypeOf((*Event)(nil)).Elem()
+)
+
+// Subscribe registers a typed handler to the bus. The handler is called with:
+//   - the event itself when the event is assignable to T, such as *event.RequestCompletedEvent
+//   - or the payload of event when it's assignable to T, such as OrderCreated
+//     of the MessageEvent[OrderCreated] that published by PublishEvent.
+//
+// In the latter case, MessageEvent[T] is registered to the global EventTypeRegistry.
+func Subscribe[T any](bus EventBus, handler func(ctx context.Context, msg T) error) (Subscription, error) {
+	if handler == nil {
+		return nil, errors.New("handler must not be nil")
+	}
+	if !reflect.TypeOf((*T)(nil)).Elem().Implements(eventType) {
+		_ = RegisterMessage[T](_registry)
+	}
+	return RegisterHandlerOn(bus, "", handler)
+}
+
+// handlerSubscriber adapts a handler function to a Subscriber,
+// it supports events that match the topic and the message type of the handler.
+type handlerSubscriber struct {
+	id          string
+	topic       string
+	fn          reflect.Value
+	msgType     reflect.Type
+	acceptCtx   bool
+	returnError bool
+}
+
+// newHandlerSubscriber creates a Subscriber from a handler function, accepted signatures are:
+//
+//	func(ctx context.Context, msg T) error
+//	func(ctx context.Context, msg T)
+//	func(msg T) error
+//	func(msg T)
+//
+// Topic is matched with the event name, `*` in topic matches any sequence of characters,
+// an empty topic matches all events.
+func newHandlerSubscriber(topic string, handler any) (*handlerSubscriber, error) {
+	if handler == nil {
+		return nil, errors.New("handler must not be nil")
+	}
+	fn := reflect.ValueOf(handler)
+	fnType := fn.Type()
+	if fnType.Kind() != reflect.Func {
+		return nil, fmt.Errorf("handler must be a function, but got [%s]", fnType)
+	}
+	if fn.IsNil() {
+		return nil, errors.New("handler must not be nil")
+	}
+	acceptCtx := fnType.NumIn() == 2 && fnType.In(0) == contextType
+	if fnType.NumIn() != 1 && !acceptCtx {
+		return nil, fmt.Errorf("handler must accept (msg) or (context.Context, msg), but got [%s]", fnType)
+	}
+	if fnType.NumOut() > 1 || (fnType.NumOut() == 1 && fnType.Out(0) != errorType) {
+		return nil, fmt.Errorf("handler must return nothing or an error, but got [%s]", fnType)
+	}
+	return &handlerSubscriber{
+		id:          fmt.Sprintf("handler[%s](%s)", topic, runtime.FuncForPC(fn.Pointer()).Name()),
+		topic:       topic,
+		fn:          fn,
+		msgType:     fnType.In(fnType.NumIn() - 1),
+		acceptCtx:   acceptCtx,
+		returnError: fnType.NumOut() == 1,
+	}, nil
+}
+
+func (h *handlerSubscriber) SubscriberId() string {
+	return h.id
+}
+
+func (h *handlerSubscriber) generatedId() {}
+
+func (h *handlerSubscriber) SupportedEvents() []string {
+	if h.topic != "" {
+		return []string{h.topic}
+	}
+	return []string{h.msgType.String()}
+}
+
+func (h *handlerSubscriber) Supports(event Event) bool {
+	if h.topic != "" && !utils.MatchWildcard(h.topic, event.Name()) {
+		return false
+	}
+	_, ok := h.message(event)
+	return ok
+}
+
+func (h *handlerSubscriber) Handle(event Event) {
+	_ = h.HandleWithError(eventContext(event), event)
+}
+
+func (h *handlerSubscriber) HandleWithError(ctx context.Context, event Event) error {
+	msg, ok := h.message(event)
+	if !ok {
+		return fmt.Errorf("event [%s] is not assignable to [%s]", event.Name(), h.msgType)
+	}
+	args := []reflect.Value{msg}
+	if h.acceptCtx {
+		args = []reflect.Value{reflect.ValueOf(&ctx).Elem(), msg}
+	}
+	results := h.fn.Call(args)
+	if h.returnError && !results[0].IsNil() {
+		return results[0].Interface().(error)
+	}
+	return nil
+}
+
+// message returns the event or its payload, whichever is assignable to the handler's message type.
+func (h *handlerSubscriber) message(event Event) (reflect.Value, bool) {
+	if reflect.TypeOf(event).AssignableTo(h.msgType) {
+		return reflect.ValueOf(event), true
+	}
+	if payload := event.Payload(); payload != nil && reflect.TypeOf(payload).AssignableTo(h.msgType) {
+		return reflect.ValueOf(payload), true
+	}
+	return reflect.Value{}, false
+}
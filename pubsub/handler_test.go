@@ -0,0 +1,135 @@
+package pubsub
+
+import (
+	"context"
+	"errors"
+	"github.com/golibs-starter/golib/pubsub/executor"
+	"github.com/golibs-starter/golib/web/event"
+	assert "github.com/stretchr/testify/require"
+	"testing"
+)
+
+type OrderCreated struct {
+	OrderId string
+}
+
+type OrderCancelled struct {
+	OrderId string
+}
+
+func newOrderEvent[T any](name string, msg T) MessageEvent[T] {
+	return MessageEvent[T]{
+		AbstractEvent: event.NewAbstractEvent(context.Background(), name),
+		PayloadData:   msg,
+	}
+}
+
+func TestNewHandlerSubscriber_WhenHandlerIsIncompatible_ShouldReturnError(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler any
+	}{
+		{name: "nil handler", handler: nil},
+		{name: "nil function", handler: (func(msg OrderCreated))(nil)},
+		{name: "not a function", handler: "handler"},
+		{name: "no argument", handler: func() {}},
+		{name: "first argument is not context", handler: func(a string, msg OrderCreated) {}},
+		{name: "too many arguments", handler: func(ctx context.Context, msg OrderCreated, b string) {}},
+		{name: "return is not error", handler: func(msg OrderCreated) string { return "" }},
+		{name: "too many returns", handler: func(msg OrderCreated) (string, error) { return "", nil }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := newHandlerSubscriber("", tt.handler)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestHandlerSubscriber_WhenMessageTypeMatches_ShouldSupportEvent(t *testing.T) {
+	created := newOrderEvent("OrderCreated", OrderCreated{OrderId: "1"})
+	cancelled := newOrderEvent("OrderCancelled", OrderCancelled{OrderId: "1"})
+
+	byPayload, err := newHandlerSubscriber("", func(ctx context.Context, msg OrderCreated) error { return nil })
+	assert.NoError(t, err)
+	assert.True(t, byPayload.Supports(created))
+	assert.False(t, byPayload.Supports(cancelled))
+
+	byEvent, err := newHandlerSubscriber("", func(e MessageEvent[OrderCancelled]) {})
+	assert.NoError(t, err)
+	assert.False(t, byEvent.Supports(created))
+	assert.True(t, byEvent.Supports(cancelled))
+
+	byTopic, err := newHandlerSubscriber("Order*", func(e Event) {})
+	assert.NoError(t, err)
+	assert.True(t, byTopic.Supports(created))
+	assert.True(t, byTopic.Supports(cancelled))
+	assert.False(t, byTopic.Supports(&DummyEvent{name: "PaymentCreated"}))
+}
+
+func TestHandlerSubscriber_HandleWithError_ShouldPassContextAndReturnError(t *testing.T) {
+	var received OrderCreated
+	ctx := context.WithValue(context.Background(), "key", "value")
+	subscriber, err := newHandlerSubscriber("", func(ctx context.Context, msg OrderCreated) error {
+		received = msg
+		assert.Equal(t, "value", ctx.Value("key"))
+		return errors.New("handle error")
+	})
+	assert.NoError(t, err)
+	err = subscriber.HandleWithError(ctx, newOrderEvent("OrderCreated", OrderCreated{OrderId: "1"}))
+	assert.EqualError(t, err, "handle error")
+	assert.Equal(t, "1", received.OrderId)
+}
+
+func TestDefaultEventBus_WhenRegisterHandlers_ShouldRouteEventsByTopicAndType(t *testing.T) {
+	bus := NewDefaultEventBus(WithEventExecutor(executor.NewSyncExecutor()))
+	createdOrders := make([]string, 0)
+	topicEvents := make([]string, 0)
+	_, err := Subscribe(bus, func(ctx context.Context, msg OrderCreated) error {
+		createdOrders = append(createdOrders, msg.OrderId)
+		return nil
+	})
+	assert.NoError(t, err)
+	_, err = bus.RegisterHandler("order.*", func(e Event) {
+		topicEvents = append(topicEvents, e.Name())
+	})
+	assert.NoError(t, err)
+	_, err = bus.RegisterHandler("order.*", "not a function")
+	assert.Error(t, err)
+	assert.Len(t, bus.subscribers, 2)
+
+	bus.Run()
+	bus.Deliver(newOrderEvent("OrderCreated", OrderCreated{OrderId: "1"}))
+	bus.Deliver(newOrderEvent("order.created", OrderCreated{OrderId: "2"}))
+	bus.Deliver(newOrderEvent("order.cancelled", OrderCancelled{OrderId: "3"}))
+	bus.Stop()
+
+	assert.Equal(t, []string{"1", "2"}, createdOrders)
+	assert.Equal(t, []string{"order.created", "order.cancelled"}, topicEvents)
+}
+
+type tenantOrderHandler struct {
+	tenant string
+	orders []string
+}
+
+func (h *tenantOrderHandler) Handle(msg OrderCreated) {
+	h.orders = append(h.orders, h.tenant+":"+msg.OrderId)
+}
+
+func TestDefaultEventBus_WhenRegisterMethodValuesOfInstances_ShouldSubscribeBoth(t *testing.T) {
+	bus := NewDefaultEventBus(WithEventExecutor(executor.NewSyncExecutor()))
+	tenantA, tenantB := &tenantOrderHandler{tenant: "a"}, &tenantOrderHandler{tenant: "b"}
+	subscriptionA, err := bus.RegisterHandler("order.*", tenantA.Handle)
+	assert.NoError(t, err)
+	subscriptionB, err := bus.RegisterHandler("order.*", tenantB.Handle)
+	assert.NoError(t, err)
+	assert.NotEqual(t, subscriptionA.Id(), subscriptionB.Id())
+
+	bus.Run()
+	bus.Deliver(newOrderEvent("order.created", OrderCreated{OrderId: "1"}))
+	bus.Stop()
+
+	assert.Equal(t, []string{"a:1"}, tenantA.orders)
+	assert.Equal(t, []string{"b:1"}, tenantB.orders)
+}
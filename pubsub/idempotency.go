@@ -0,0 +1,145 @@
+package pubsub
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/golibs-starter/golib/utils"
+)
+
+// IdempotencyStore records (subscriber, event id) pairs that are handled successfully,
+// so that redelivered events (by retries, replays or transports) are skipped.
+type IdempotencyStore interface {
+	// IsProcessed returns whether the subscriber handled the event successfully
+	IsProcessed(subscriberId string, eventId string) (bool, error)
+
+	// MarkProcessed records that the subscriber handled the event successfully
+	MarkProcessed(subscriberId string, eventId string) error
+}
+
+type idempotencyKey struct {
+	subscriberId string
+	eventId      string
+}
+
+type idempotencyRecord struct {
+	key       idempotencyKey
+	expiresAt time.Time
+}
+
+// InMemoryIdempotencyStore keeps processed pairs in memory until their TTL is expired.
+type InMemoryIdempotencyStore struct {
+	ttl     time.Duration
+	clock   Clock
+	expires map[idempotencyKey]time.Time
+
+	// records are ordered by expiry time since the TTL is fixed
+	records []idempotencyRecord
+	mu      sync.Mutex
+}
+
+type InMemoryIdempotencyStoreOpt func(s *InMemoryIdempotencyStore)
+
+// WithIdempotencyClock replaces the system clock, such as by a fake clock in tests
+func WithIdempotencyClock(clock Clock) InMemoryIdempotencyStoreOpt {
+	return func(s *InMemoryIdempotencyStore) {
+		s.clock = clock
+	}
+}
+
+func NewInMemoryIdempotencyStore(ttl time.Duration, opts ...InMemoryIdempotencyStoreOpt) *InMemoryIdempotencyStore {
+	s := &InMemoryIdempotencyStore{
+		ttl:     ttl,
+		clock:   SystemClock(),
+		expires: make(map[idempotencyKey]time.Time),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+func (s *InMemoryIdempotencyStore) IsProcessed(subscriberId string, eventId string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	expiresAt, exists := s.expires[idempotencyKey{subscriberId: subscriberId, eventId: eventId}]
+	return exists && s.clock.Now().Before(expiresAt), nil
+}
+
+func (s *InMemoryIdempotencyStore) MarkProcessed(subscriberId string, eventId string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.clock.Now()
+	s.evict(now)
+	key := idempotencyKey{subscriberId: subscriberId, eventId: eventId}
+	expiresAt := now.Add(s.ttl)
+	s.expires[key] = expiresAt
+	s.records = append(s.records, idempotencyRecord{key: key, expiresAt: expiresAt})
+	return nil
+}
+
+// Len returns the number of processed pairs, includes expired ones that are not evicted yet
+func (s *InMemoryIdempotencyStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.expires)
+}
+
+// evict expired records, the caller must hold the lock
+func (s *InMemoryIdempotencyStore) evict(now time.Time) {
+	i := 0
+	for ; i < len(s.records) && !now.Before(s.records[i].expiresAt); i++ {
+		record := s.records[i]
+		// The pair may be marked again after this record
+		if s.expires[record.key] == record.expiresAt {
+			delete(s.expires, record.key)
+		}
+	}
+	if i > 0 {
+		s.records = append(s.records[:0], s.records[i:]...)
+	}
+}
+
+// isIdempotent returns whether duplicates are skipped for the subscriber
+func (b *DefaultEventBus) isIdempotent(subscriberId string) bool {
+	if b.idempotencyStore == nil {
+		return false
+	}
+	return len(b.idempotentSubscribers) == 0 || utils.MatchAnyWildcard(b.idempotentSubscribers, subscriberId)
+}
+
+// isDuplicate returns whether the subscriber processed the event already,
+// the event is handled again when the store fails.
+func (b *DefaultEventBus) isDuplicate(subscriberId string, event Event) bool {
+	if !b.isIdempotent(subscriberId) {
+		return false
+	}
+	processed, err := b.idempotencyStore.IsProcessed(subscriberId, event.Identifier())
+	if err != nil {
+		b.debugLog(eventContext(event), "Cannot check whether subscriber [%s] processed event [%s] with id [%s], error [%v]",
+			subscriberId, event.Name(), event.Identifier(), err)
+		return false
+	}
+	if processed {
+		atomic.AddInt64(&b.duplicateCount, 1)
+		b.debugLog(eventContext(event), "Subscriber [%s] skipped duplicated event [%s] with id [%s]",
+			subscriberId, event.Name(), event.Identifier())
+	}
+	return processed
+}
+
+func (b *DefaultEventBus) markProcessed(subscriberId string, event Event) {
+	if !b.isIdempotent(subscriberId) {
+		return
+	}
+	if err := b.idempotencyStore.MarkProcessed(subscriberId, event.Identifier()); err != nil {
+		b.debugLog(eventContext(event), "Cannot mark event [%s] with id [%s] as processed by subscriber [%s], error [%v]",
+			event.Name(), event.Identifier(), subscriberId, err)
+	}
+}
+
+// DuplicateCount returns the number of duplicated events that were skipped by subscribers
+func (b *DefaultEventBus) DuplicateCount() int64 {
+	return atomic.LoadInt64(&b.duplicateCount)
+}
@@ -0,0 +1,108 @@
+package pubsub
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golibs-starter/golib/pubsub/executor"
+	assert "github.com/stretchr/testify/require"
+)
+
+// manualClock is a Clock that only moves when it's advanced
+type manualClock struct {
+	now time.Time
+	mu  sync.Mutex
+}
+
+func (c *manualClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *manualClock) NewTimer(d time.Duration) Timer {
+	return SystemClock().NewTimer(d)
+}
+
+func (c *manualClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+func TestInMemoryIdempotencyStore_ShouldForgetProcessedPairsAfterTTL(t *testing.T) {
+	clock := &manualClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	store := NewInMemoryIdempotencyStore(time.Minute, WithIdempotencyClock(clock))
+	assert.NoError(t, store.MarkProcessed("sub-1", "event-1"))
+	clock.Advance(30 * time.Second)
+	assert.NoError(t, store.MarkProcessed("sub-1", "event-2"))
+
+	processed, err := store.IsProcessed("sub-1", "event-1")
+	assert.NoError(t, err)
+	assert.True(t, processed)
+	processed, _ = store.IsProcessed("sub-2", "event-1")
+	assert.False(t, processed)
+
+	clock.Advance(30 * time.Second)
+	processed, _ = store.IsProcessed("sub-1", "event-1")
+	assert.False(t, processed)
+	processed, _ = store.IsProcessed("sub-1", "event-2")
+	assert.True(t, processed)
+
+	// Expired pairs are evicted when a pair is marked
+	assert.Equal(t, 2, store.Len())
+	assert.NoError(t, store.MarkProcessed("sub-1", "event-3"))
+	assert.Equal(t, 2, store.Len())
+}
+
+func TestDefaultEventBus_GivenIdempotency_WhenEventIsRedelivered_ShouldSkipDuplicates(t *testing.T) {
+	store := NewInMemoryIdempotencyStore(time.Hour)
+	bus := NewDefaultEventBus(
+		WithEventExecutor(executor.NewSyncExecutor()),
+		WithIdempotency(store, "pubsub.DummySubscriber1"),
+	)
+	s1 := DummySubscriber1{}
+	s2 := DummySubscriber1{}
+	bus.Register(&s1)
+	_, err := bus.Subscribe(&identifiedSubscriber{id: "not-idempotent", Subscriber: &s2})
+	assert.NoError(t, err)
+	bus.Run()
+	defer bus.Stop()
+
+	bus.Deliver(&DummyEvent{name: "event-1"})
+	bus.Deliver(&DummyEvent{name: "event-1"})
+	assert.Eventually(t, func() bool {
+		return s2.numberOfOrderedEventRun() == 2
+	}, time.Second, 5*time.Millisecond)
+	assert.Equal(t, 1, s1.numberOfOrderedEventRun())
+	assert.EqualValues(t, 1, bus.DuplicateCount())
+	assert.Equal(t, 1, store.Len())
+}
+
+func TestDefaultEventBus_GivenIdempotency_WhenSubscriberFailed_ShouldNotMarkProcessed(t *testing.T) {
+	store := NewInMemoryIdempotencyStore(time.Hour)
+	bus := NewDefaultEventBus(
+		WithEventExecutor(executor.NewSyncExecutor()),
+		WithEventErrorHandler(func(event Event, subscriberId string, err error, stack []byte) {}),
+		WithIdempotency(store),
+	)
+	bus.Register(DummyErrorSubscriber{})
+	bus.Run()
+	defer bus.Stop()
+
+	bus.Deliver(&DummyEvent{name: "event-1"})
+	bus.Deliver(&DummyEvent{name: "event-1"})
+	time.Sleep(50 * time.Millisecond)
+	assert.EqualValues(t, 0, bus.DuplicateCount())
+	assert.Equal(t, 0, store.Len())
+}
+
+type identifiedSubscriber struct {
+	Subscriber
+	id string
+}
+
+func (s *identifiedSubscriber) SubscriberId() string {
+	return s.id
+}
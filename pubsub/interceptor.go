@@ -0,0 +1,43 @@
+package pubsub
+
+import "context"
+
+// PublishFunc publishes an event, it returns an error when the event is not accepted.
+type PublishFunc func(ctx context.Context, event Event) error
+
+// PublishInterceptor intercepts publishing of an event.
+// An interceptor can pass a modified context (or event) to next,
+// short-circuit the event by returning without calling next
+// or observe the result of next.
+type PublishInterceptor func(ctx context.Context, event Event, next PublishFunc) error
+
+// HandleFunc handles an event, the error is reported to the ErrorHandler after all attempts.
+type HandleFunc func(ctx context.Context, event Event) error
+
+// HandleInterceptor intercepts handling of an event by a subscriber, it's called once per attempt.
+// An interceptor can pass a modified context (or event) to next,
+// short-circuit the handling by returning without calling next
+// or observe the result of next. A panic of the subscriber is returned as PanicError.
+type HandleInterceptor func(ctx context.Context, subscriberId string, event Event, next HandleFunc) error
+
+// chainPublishInterceptors wraps fn by interceptors, the first interceptor is the outermost one.
+func chainPublishInterceptors(interceptors []PublishInterceptor, fn PublishFunc) PublishFunc {
+	for i := len(interceptors) - 1; i >= 0; i-- {
+		interceptor, next := interceptors[i], fn
+		fn = func(ctx context.Context, event Event) error {
+			return interceptor(ctx, event, next)
+		}
+	}
+	return fn
+}
+
+// chainHandleInterceptors wraps fn by interceptors, the first interceptor is the outermost one.
+func chainHandleInterceptors(interceptors []HandleInterceptor, subscriberId string, fn HandleFunc) HandleFunc {
+	for i := len(interceptors) - 1; i >= 0; i-- {
+		interceptor, next := interceptors[i], fn
+		fn = func(ctx context.Context, event Event) error {
+			return interceptor(ctx, subscriberId, event, next)
+		}
+	}
+	return fn
+}
@@ -0,0 +1,147 @@
+package pubsub
+
+import (
+	"context"
+	"errors"
+	"github.com/golibs-starter/golib/pubsub/executor"
+	assert "github.com/stretchr/testify/require"
+	"sync"
+	"testing"
+)
+
+type DummyContextSubscriber struct {
+	values []interface{}
+	mu     sync.Mutex
+}
+
+func (d *DummyContextSubscriber) Supports(event Event) bool {
+	return true
+}
+
+func (d *DummyContextSubscriber) Handle(event Event) {
+}
+
+func (d *DummyContextSubscriber) HandleWithError(ctx context.Context, event Event) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.values = append(d.values, ctx.Value("tenant"))
+	return nil
+}
+
+func TestChainPublishInterceptors_ShouldCallInOrder(t *testing.T) {
+	calls := make([]string, 0)
+	interceptor := func(name string) PublishInterceptor {
+		return func(ctx context.Context, event Event, next PublishFunc) error {
+			calls = append(calls, name+"-before")
+			err := next(ctx, event)
+			calls = append(calls, name+"-after")
+			return err
+		}
+	}
+	fn := chainPublishInterceptors([]PublishInterceptor{interceptor("1"), interceptor("2")},
+		func(ctx context.Context, event Event) error {
+			calls = append(calls, "publish")
+			return nil
+		})
+	assert.NoError(t, fn(context.Background(), &DummyEvent{name: "event-1"}))
+	assert.Equal(t, []string{"1-before", "2-before", "publish", "2-after", "1-after"}, calls)
+}
+
+func TestDefaultEventBus_GivenHandleInterceptors_ShouldMutateContextAndShortCircuit(t *testing.T) {
+	s1 := &DummyContextSubscriber{}
+	bus := NewDefaultEventBus(
+		WithEventExecutor(executor.NewSyncExecutor()),
+		WithHandleInterceptors(
+			func(ctx context.Context, subscriberId string, event Event, next HandleFunc) error {
+				if event.Name() == "skipped" {
+					return nil
+				}
+				return next(context.WithValue(ctx, "tenant", "tenant-1"), event)
+			},
+		),
+	)
+	bus.Register(s1)
+	bus.Run()
+	bus.Deliver(&DummyEvent{name: "event-1"})
+	bus.Deliver(&DummyEvent{name: "skipped"})
+	bus.Stop()
+	assert.Equal(t, []interface{}{"tenant-1"}, s1.values)
+}
+
+func TestDefaultEventBus_GivenHandleInterceptors_ShouldObserveErrorsAndPanics(t *testing.T) {
+	var mu sync.Mutex
+	observed := make(map[string]error)
+	reported := make(map[string]error)
+	bus := NewDefaultEventBus(
+		WithEventExecutor(executor.NewSyncExecutor()),
+		WithEventErrorHandler(func(event Event, subscriberId string, err error, stack []byte) {
+			mu.Lock()
+			defer mu.Unlock()
+			reported[subscriberId] = err
+		}),
+		WithHandleInterceptors(func(ctx context.Context, subscriberId string, event Event, next HandleFunc) error {
+			err := next(ctx, event)
+			mu.Lock()
+			defer mu.Unlock()
+			observed[subscriberId] = err
+			return err
+		}),
+	)
+	bus.Register(&DummyPanicSubscriber{}, &DummyErrorSubscriber{})
+	bus.Run()
+	bus.Deliver(&DummyEvent{name: "event-1"})
+	bus.Stop()
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.IsType(t, &PanicError{}, observed["pubsub.DummyPanicSubscriber"])
+	assert.EqualError(t, observed["pubsub.DummyErrorSubscriber"], "dummy error")
+	assert.Equal(t, observed, reported)
+}
+
+func TestDefaultEventBus_WhenHandleInterceptorPanic_ShouldReportToErrorHandler(t *testing.T) {
+	var reportedErr error
+	bus := NewDefaultEventBus(
+		WithEventExecutor(executor.NewSyncExecutor()),
+		WithEventErrorHandler(func(event Event, subscriberId string, err error, stack []byte) {
+			reportedErr = err
+		}),
+		WithHandleInterceptors(func(ctx context.Context, subscriberId string, event Event, next HandleFunc) error {
+			panic("interceptor panic")
+		}),
+	)
+	bus.Register(&DummyContextSubscriber{})
+	bus.Run()
+	bus.Deliver(&DummyEvent{name: "event-1"})
+	bus.Stop()
+	assert.IsType(t, &PanicError{}, reportedErr)
+	assert.Equal(t, "interceptor panic", reportedErr.(*PanicError).Value)
+}
+
+func TestDefaultPublisher_GivenPublishInterceptors_ShouldShortCircuitAndObserveResult(t *testing.T) {
+	s1 := &DummyContextSubscriber{}
+	bus := NewDefaultEventBus(WithEventExecutor(executor.NewSyncExecutor()))
+	bus.Register(s1)
+	bus.Run()
+	defer bus.Stop()
+
+	observed := make([]error, 0)
+	pub := NewDefaultPublisher(bus, WithPublishInterceptors(
+		func(ctx context.Context, event Event, next PublishFunc) error {
+			err := next(ctx, event)
+			observed = append(observed, err)
+			return err
+		},
+		func(ctx context.Context, event Event, next PublishFunc) error {
+			if event.Name() == "rejected" {
+				return errors.New("tenant is required")
+			}
+			return next(ctx, event)
+		},
+	))
+	assert.NoError(t, pub.PublishCtx(context.Background(), &DummyEvent{name: "event-1"}))
+	assert.EqualError(t, pub.PublishCtx(context.Background(), &DummyEvent{name: "rejected"}), "tenant is required")
+	assert.Len(t, observed, 2)
+	assert.NoError(t, observed[0])
+	assert.EqualError(t, observed[1], "tenant is required")
+}
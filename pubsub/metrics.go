@@ -0,0 +1,230 @@
+package pubsub
+
+import (
+	"encoding/json"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// latencyWindowSize is the number of recent handlings that latency percentiles are computed from
+const latencyWindowSize = 1024
+
+// EventMetrics are counters of an event name
+type EventMetrics struct {
+	// Published is the number of events that are accepted by the bus
+	Published int64 `json:"published"`
+
+	// Delivered is the number of successful handlings by subscribers
+	Delivered int64 `json:"delivered"`
+
+	// Failed is the number of failed handlings by subscribers, after all retries
+	Failed int64 `json:"failed"`
+
+	// Dropped is the number of events that are dropped or rejected by the overflow policy
+	Dropped int64 `json:"dropped"`
+
+	// TimedOut is the number of handling attempts that exceeded the handler timeout
+	TimedOut int64 `json:"timed_out"`
+}
+
+// LatencyStats describes handling latency of a subscriber,
+// percentiles are computed from the recent 1024 handlings.
+type LatencyStats struct {
+	Count         int64
+	P50           time.Duration
+	P90           time.Duration
+	P99           time.Duration
+	Max           time.Duration
+	LastHandledAt time.Time
+}
+
+func (s LatencyStats) MarshalJSON() ([]byte, error) {
+	return json.Marshal(map[string]interface{}{
+		"count":           s.Count,
+		"p50":             s.P50.String(),
+		"p90":             s.P90.String(),
+		"p99":             s.P99.String(),
+		"max":             s.Max.String(),
+		"last_handled_at": s.LastHandledAt,
+	})
+}
+
+type eventCounters struct {
+	published int64
+	delivered int64
+	failed    int64
+	dropped   int64
+	timedOut  int64
+}
+
+// latencyWindow is a ring buffer of recent handling durations
+type latencyWindow struct {
+	samples       []time.Duration
+	next          int
+	count         int64
+	max           time.Duration
+	lastHandledAt time.Time
+	mu            sync.Mutex
+}
+
+func (w *latencyWindow) record(duration time.Duration, at time.Time) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if len(w.samples) < latencyWindowSize {
+		w.samples = append(w.samples, duration)
+	} else {
+		w.samples[w.next] = duration
+		w.next = (w.next + 1) % latencyWindowSize
+	}
+	w.count++
+	if duration > w.max {
+		w.max = duration
+	}
+	w.lastHandledAt = at
+}
+
+func (w *latencyWindow) stats() LatencyStats {
+	w.mu.Lock()
+	samples := append([]time.Duration{}, w.samples...)
+	stats := LatencyStats{Count: w.count, Max: w.max, LastHandledAt: w.lastHandledAt}
+	w.mu.Unlock()
+	if len(samples) == 0 {
+		return stats
+	}
+	sort.Slice(samples, func(i, j int) bool {
+		return samples[i] < samples[j]
+	})
+	stats.P50 = percentile(samples, 50)
+	stats.P90 = percentile(samples, 90)
+	stats.P99 = percentile(samples, 99)
+	return stats
+}
+
+// percentile returns the nearest-rank percentile of sorted samples
+func percentile(sorted []time.Duration, p int) time.Duration {
+	rank := (p*len(sorted) + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+// busMetrics keeps counters by event name and latency by subscriber id
+type busMetrics struct {
+	events         map[string]*eventCounters
+	eventsMu       sync.RWMutex
+	latencies      map[string]*latencyWindow
+	latenciesMu    sync.RWMutex
+	lastDispatchAt int64
+}
+
+func newBusMetrics() *busMetrics {
+	return &busMetrics{
+		events:    make(map[string]*eventCounters),
+		latencies: make(map[string]*latencyWindow),
+	}
+}
+
+func (m *busMetrics) countersOf(eventName string) *eventCounters {
+	m.eventsMu.RLock()
+	counters, exists := m.events[eventName]
+	m.eventsMu.RUnlock()
+	if exists {
+		return counters
+	}
+	m.eventsMu.Lock()
+	defer m.eventsMu.Unlock()
+	if counters, exists = m.events[eventName]; !exists {
+		counters = &eventCounters{}
+		m.events[eventName] = counters
+	}
+	return counters
+}
+
+func (m *busMetrics) latencyOf(subscriberId string) *latencyWindow {
+	m.latenciesMu.RLock()
+	window, exists := m.latencies[subscriberId]
+	m.latenciesMu.RUnlock()
+	if exists {
+		return window
+	}
+	m.latenciesMu.Lock()
+	defer m.latenciesMu.Unlock()
+	if window, exists = m.latencies[subscriberId]; !exists {
+		window = &latencyWindow{}
+		m.latencies[subscriberId] = window
+	}
+	return window
+}
+
+func (m *busMetrics) published(event Event) {
+	atomic.AddInt64(&m.countersOf(event.Name()).published, 1)
+}
+
+func (m *busMetrics) dropped(event Event) {
+	atomic.AddInt64(&m.countersOf(event.Name()).dropped, 1)
+}
+
+func (m *busMetrics) timedOut(event Event) {
+	atomic.AddInt64(&m.countersOf(event.Name()).timedOut, 1)
+}
+
+func (m *busMetrics) dispatched(at time.Time) {
+	atomic.StoreInt64(&m.lastDispatchAt, at.UnixNano())
+}
+
+// handled records a handling attempt of a subscriber
+func (m *busMetrics) handled(subscriberId string, started time.Time) {
+	now := time.Now()
+	m.latencyOf(subscriberId).record(now.Sub(started), now)
+}
+
+// completed records the result of a handling, after all retries
+func (m *busMetrics) completed(event Event, success bool) {
+	counters := m.countersOf(event.Name())
+	if success {
+		atomic.AddInt64(&counters.delivered, 1)
+	} else {
+		atomic.AddInt64(&counters.failed, 1)
+	}
+}
+
+// EventMetrics returns counters by event name
+func (b *DefaultEventBus) EventMetrics() map[string]EventMetrics {
+	b.metrics.eventsMu.RLock()
+	defer b.metrics.eventsMu.RUnlock()
+	metrics := make(map[string]EventMetrics, len(b.metrics.events))
+	for name, counters := range b.metrics.events {
+		metrics[name] = EventMetrics{
+			Published: atomic.LoadInt64(&counters.published),
+			Delivered: atomic.LoadInt64(&counters.delivered),
+			Failed:    atomic.LoadInt64(&counters.failed),
+			Dropped:   atomic.LoadInt64(&counters.dropped),
+			TimedOut:  atomic.LoadInt64(&counters.timedOut),
+		}
+	}
+	return metrics
+}
+
+// SubscriberLatency returns handling latency of a subscriber,
+// it returns false when the subscriber did not handle any event.
+func (b *DefaultEventBus) SubscriberLatency(subscriberId string) (LatencyStats, bool) {
+	b.metrics.latenciesMu.RLock()
+	window, exists := b.metrics.latencies[subscriberId]
+	b.metrics.latenciesMu.RUnlock()
+	if !exists {
+		return LatencyStats{}, false
+	}
+	return window.stats(), true
+}
+
+// LastDispatchAt returns the time that the last event was dispatched to subscribers,
+// it's zero when no event was dispatched.
+func (b *DefaultEventBus) LastDispatchAt() time.Time {
+	if nanos := atomic.LoadInt64(&b.metrics.lastDispatchAt); nanos > 0 {
+		return time.Unix(0, nanos)
+	}
+	return time.Time{}
+}
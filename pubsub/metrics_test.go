@@ -0,0 +1,108 @@
+package pubsub
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golibs-starter/golib/actuator"
+	"github.com/golibs-starter/golib/pubsub/executor"
+	assert "github.com/stretchr/testify/require"
+)
+
+func TestPercentile_ShouldReturnNearestRank(t *testing.T) {
+	samples := make([]time.Duration, 0, 100)
+	for i := 1; i <= 100; i++ {
+		samples = append(samples, time.Duration(i)*time.Millisecond)
+	}
+	assert.Equal(t, 50*time.Millisecond, percentile(samples, 50))
+	assert.Equal(t, 99*time.Millisecond, percentile(samples, 99))
+	assert.Equal(t, 3*time.Millisecond, percentile(samples[:3], 90))
+	assert.Equal(t, time.Millisecond, percentile(samples[:1], 50))
+}
+
+func TestDefaultEventBus_ShouldTrackEventMetricsAndLatency(t *testing.T) {
+	bus := NewDefaultEventBus(
+		WithEventExecutor(executor.NewSyncExecutor()),
+		WithEventChannelSize(1),
+		WithOverflowPolicy(OverflowDropNewest, 0),
+		WithEventErrorHandler(func(event Event, subscriberId string, err error, stack []byte) {}),
+	)
+	s1 := DummySubscriber1{}
+	bus.Register(&s1, DummyErrorSubscriber{})
+	assert.True(t, bus.LastDispatchAt().IsZero())
+
+	// The bus is not running, the second event is dropped
+	assert.NoError(t, bus.TryDeliver(context.Background(), &DummyEvent{name: "event-1"}))
+	assert.ErrorIs(t, bus.TryDeliver(context.Background(), &DummyEvent{name: "event-1"}), ErrQueueFull)
+	bus.Run()
+	assert.Eventually(t, func() bool {
+		return s1.numberOfOrderedEventRun() == 1
+	}, time.Second, 5*time.Millisecond)
+	assert.NoError(t, bus.TryDeliver(context.Background(), &DummyEvent{name: "event-2"}))
+	bus.Stop()
+
+	assert.Equal(t, map[string]EventMetrics{
+		"event-1": {Published: 1, Delivered: 1, Failed: 1, Dropped: 1},
+		"event-2": {Published: 1, Delivered: 1, Failed: 1},
+	}, bus.EventMetrics())
+	assert.False(t, bus.LastDispatchAt().IsZero())
+
+	latency, exists := bus.SubscriberLatency("pubsub.DummySubscriber1")
+	assert.True(t, exists)
+	assert.EqualValues(t, 2, latency.Count)
+	assert.True(t, latency.P50 <= latency.P99 && latency.P99 <= latency.Max)
+	assert.False(t, latency.LastHandledAt.IsZero())
+	_, exists = bus.SubscriberLatency("not-registered")
+	assert.False(t, exists)
+}
+
+func TestDefaultBusHealthChecker_WhenQueueStaysSaturated_ShouldReportDown(t *testing.T) {
+	bus := NewDefaultEventBus(WithEventChannelSize(2))
+	clock := &manualClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	checker, err := NewDefaultBusHealthChecker(bus,
+		WithSaturationThreshold(0.5, 10*time.Second), WithHealthCheckerClock(clock))
+	assert.NoError(t, err)
+	assert.Equal(t, "event_bus", checker.Component())
+
+	details := checker.Check(context.Background())
+	assert.Equal(t, actuator.StatusDown, details.Status)
+	assert.Equal(t, "event bus is not running", details.Reason)
+
+	// Mark the bus as running without dispatching, so that events stay in the queue
+	bus.isRunning = true
+	assert.Equal(t, actuator.StatusUp, checker.Check(context.Background()).Status)
+	bus.Deliver(&DummyEvent{name: "event-1"})
+	assert.Equal(t, actuator.StatusUp, checker.Check(context.Background()).Status)
+	clock.Advance(10 * time.Second)
+	details = checker.Check(context.Background())
+	assert.Equal(t, actuator.StatusDown, details.Status)
+	assert.Equal(t, "event queue is saturated [1/2] for [10s]", details.Reason)
+
+	// Saturation is reset when the queue is drained
+	<-bus.eventCh
+	assert.Equal(t, actuator.StatusUp, checker.Check(context.Background()).Status)
+	bus.Deliver(&DummyEvent{name: "event-2"})
+	clock.Advance(5 * time.Second)
+	assert.Equal(t, actuator.StatusUp, checker.Check(context.Background()).Status)
+}
+
+func TestDefaultBusHealthChecker_WhenQueueIsDrainedBetweenChecks_ShouldReportUp(t *testing.T) {
+	bus := NewDefaultEventBus(WithEventChannelSize(2))
+	clock := &manualClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	checker, err := NewDefaultBusHealthChecker(bus,
+		WithSaturationThreshold(0.5, 10*time.Second), WithHealthCheckerClock(clock))
+	assert.NoError(t, err)
+	bus.isRunning = true
+	bus.Deliver(&DummyEvent{name: "event-1"})
+	assert.Equal(t, actuator.StatusUp, checker.Check(context.Background()).Status)
+
+	// The burst is dispatched and another burst comes before the next check
+	bus.dispatchEvent(<-bus.eventCh, false, nil)
+	bus.Deliver(&DummyEvent{name: "event-2"})
+	clock.Advance(10 * time.Second)
+	assert.Equal(t, actuator.StatusUp, checker.Check(context.Background()).Status)
+
+	clock.Advance(10 * time.Second)
+	assert.Equal(t, actuator.StatusDown, checker.Check(context.Background()).Status)
+}
@@ -0,0 +1,97 @@
+package pubsub
+
+import (
+	"sync/atomic"
+
+	"github.com/golibs-starter/golib/log"
+	"github.com/golibs-starter/golib/utils"
+)
+
+// Outbox persists events before they are accepted by the bus.
+// Events that were not handled by all subscribers are replayed when the bus runs again,
+// so they survive crashes and restarts.
+type Outbox interface {
+
+	// Append persists an event, the event is only accepted by the bus when it returns no error
+	Append(event Event) error
+
+	// MarkDone records that a subscriber handled an event successfully,
+	// the subscriber is skipped when the event is replayed.
+	MarkDone(eventId string, subscriberId string) error
+
+	// Complete records that all subscribers handled an event, or the event is discarded.
+	// A completed event is never replayed.
+	Complete(eventId string) error
+
+	// Pending returns events that were not completed, the oldest first
+	Pending() ([]*OutboxEntry, error)
+}
+
+// OutboxEntry is a pending event in the Outbox
+type OutboxEntry struct {
+	Event           Event
+	DoneSubscribers map[string]bool
+}
+
+// outboxTracker completes a durable event when all subscribers handled it successfully
+type outboxTracker struct {
+	remaining int32
+	failed    int32
+}
+
+// isDurable returns whether the event is persisted to the outbox
+func (b *DefaultEventBus) isDurable(event Event) bool {
+	if b.outbox == nil {
+		return false
+	}
+	return len(b.outboxEvents) == 0 || utils.MatchAnyWildcard(b.outboxEvents, event.Name())
+}
+
+// pendingOutboxEntries returns pending events in the outbox to replay
+func (b *DefaultEventBus) pendingOutboxEntries() []*OutboxEntry {
+	if b.outbox == nil {
+		return nil
+	}
+	entries, err := b.outbox.Pending()
+	if err != nil {
+		log.WithErrors(err).Error("Cannot load pending events from outbox")
+		return nil
+	}
+	return entries
+}
+
+// replay dispatches pending events to subscribers that did not handle them
+func (b *DefaultEventBus) replay(entries []*OutboxEntry) {
+	if len(entries) > 0 {
+		log.Infof("Replay [%d] pending events from outbox", len(entries))
+	}
+	for _, entry := range entries {
+		b.dispatchEvent(entry.Event, true, entry.DoneSubscribers)
+	}
+}
+
+// markDone records the result of handling a durable event by a subscriber
+func (b *DefaultEventBus) markDone(event Event, subscriberId string, success bool, tracker *outboxTracker) {
+	if success {
+		b.markOutboxDone(event, subscriberId)
+	} else {
+		atomic.StoreInt32(&tracker.failed, 1)
+	}
+	if atomic.AddInt32(&tracker.remaining, -1) == 0 && atomic.LoadInt32(&tracker.failed) == 0 {
+		b.completeOutbox(event)
+	}
+}
+
+func (b *DefaultEventBus) markOutboxDone(event Event, subscriberId string) {
+	if err := b.outbox.MarkDone(event.Identifier(), subscriberId); err != nil {
+		log.WithCtx(eventContext(event)).WithErrors(err).Errorf("Cannot mark event [%s] with id [%s] done by subscriber [%s] in outbox",
+			event.Name(), event.Identifier(), subscriberId)
+	}
+}
+
+func (b *DefaultEventBus) completeOutbox(event Event) {
+	if err := b.outbox.Complete(event.Identifier()); err != nil {
+		log.WithCtx(eventContext(event)).WithErrors(err).Errorf("Cannot complete event [%s] with id [%s] in outbox",
+			event.Name(), event.Identifier())
+	}
+}
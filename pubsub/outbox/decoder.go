@@ -0,0 +1,23 @@
+package outbox
+
+import (
+	"context"
+
+	"github.com/golibs-starter/golib/pubsub"
+)
+
+// Decoder restores an event from its name and JSON data
+type Decoder func(name string, data []byte) (pubsub.Event, error)
+
+// DefaultDecoder restores an event by the global pubsub.EventTypeRegistry,
+// an unregistered event is restored as *event.AbstractEvent with a generic JSON payload.
+func DefaultDecoder(name string, data []byte) (pubsub.Event, error) {
+	return pubsub.GetEventTypeRegistry().DecodeNamed(context.Background(), name, data)
+}
+
+// RegistryDecoder returns a Decoder that restores events by the registry
+func RegistryDecoder(registry *pubsub.EventTypeRegistry) Decoder {
+	return func(name string, data []byte) (pubsub.Event, error) {
+		return registry.DecodeNamed(context.Background(), name, data)
+	}
+}
@@ -0,0 +1,220 @@
+package outbox
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/golibs-starter/golib/log"
+	"github.com/golibs-starter/golib/pubsub"
+)
+
+var ErrIdempotencyStoreClosed = errors.New("idempotency store is closed")
+
+// processedRecord is a line in the idempotency log
+type processedRecord struct {
+	Subscriber string    `json:"subscriber"`
+	Event      string    `json:"event"`
+	ExpiresAt  time.Time `json:"expires_at"`
+}
+
+type processedKey struct {
+	subscriber string
+	event      string
+}
+
+// FileIdempotencyStore is a pubsub.IdempotencyStore that appends processed pairs to a log file,
+// records are not flushed to the disk one by one, the latest ones may be lost on crash.
+// Expired pairs are removed when the file is compacted, which happens when it's opened
+// and when the number of records in the file is double of the live pairs of the last compaction.
+type FileIdempotencyStore struct {
+	path  string
+	ttl   time.Duration
+	clock pubsub.Clock
+
+	file         *os.File
+	expires      map[processedKey]time.Time
+	fileRecords  int
+	compactAt    int
+	minCompactAt int
+	closed       bool
+	mu           sync.Mutex
+}
+
+type FileIdempotencyStoreOpt func(s *FileIdempotencyStore)
+
+// WithIdempotencyStoreClock replaces the system clock, such as by a fake clock in tests
+func WithIdempotencyStoreClock(clock pubsub.Clock) FileIdempotencyStoreOpt {
+	return func(s *FileIdempotencyStore) {
+		s.clock = clock
+	}
+}
+
+// NewFileIdempotencyStore opens the log file, pairs are kept until the ttl is expired.
+func NewFileIdempotencyStore(path string, ttl time.Duration, opts ...FileIdempotencyStoreOpt) (*FileIdempotencyStore, error) {
+	s := &FileIdempotencyStore{
+		path:         path,
+		ttl:          ttl,
+		clock:        pubsub.SystemClock(),
+		expires:      make(map[processedKey]time.Time),
+		minCompactAt: 1000,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("cannot create idempotency directory: %w", err)
+	}
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+	if err := s.compact(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *FileIdempotencyStore) IsProcessed(subscriberId string, eventId string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return false, ErrIdempotencyStoreClosed
+	}
+	expiresAt, exists := s.expires[processedKey{subscriber: subscriberId, event: eventId}]
+	return exists && s.clock.Now().Before(expiresAt), nil
+}
+
+func (s *FileIdempotencyStore) MarkProcessed(subscriberId string, eventId string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return ErrIdempotencyStoreClosed
+	}
+	r := processedRecord{Subscriber: subscriberId, Event: eventId, ExpiresAt: s.clock.Now().Add(s.ttl)}
+	line, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	if _, err := s.file.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("cannot write idempotency record: %w", err)
+	}
+	s.expires[processedKey{subscriber: r.Subscriber, event: r.Event}] = r.ExpiresAt
+	s.fileRecords++
+	if s.fileRecords >= s.compactAt {
+		return s.compact()
+	}
+	return nil
+}
+
+// Len returns the number of processed pairs, includes expired ones that are not compacted yet
+func (s *FileIdempotencyStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.expires)
+}
+
+func (s *FileIdempotencyStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return nil
+	}
+	s.closed = true
+	return s.file.Close()
+}
+
+// load records of the log file, a broken record (such as a torn write) is skipped
+func (s *FileIdempotencyStore) load() error {
+	file, err := os.Open(s.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("cannot open idempotency file: %w", err)
+	}
+	defer file.Close()
+	reader := bufio.NewReader(file)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if err != nil && err != io.EOF {
+			return fmt.Errorf("cannot read idempotency file: %w", err)
+		}
+		if len(line) > 0 {
+			var r processedRecord
+			if jsonErr := json.Unmarshal(line, &r); jsonErr != nil {
+				log.Warnf("Idempotency file [%s] has a broken record, it's skipped", s.path)
+			} else {
+				s.expires[processedKey{subscriber: r.Subscriber, event: r.Event}] = r.ExpiresAt
+			}
+		}
+		if err == io.EOF {
+			return nil
+		}
+	}
+}
+
+// compact rewrites live pairs to the log file, the caller must hold the lock
+func (s *FileIdempotencyStore) compact() error {
+	now := s.clock.Now()
+	records := make([]processedRecord, 0, len(s.expires))
+	for key, expiresAt := range s.expires {
+		if !now.Before(expiresAt) {
+			delete(s.expires, key)
+			continue
+		}
+		records = append(records, processedRecord{Subscriber: key.subscriber, Event: key.event, ExpiresAt: expiresAt})
+	}
+	sort.Slice(records, func(i, j int) bool {
+		return records[i].ExpiresAt.Before(records[j].ExpiresAt)
+	})
+	tmpPath := s.path + ".tmp"
+	file, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("cannot create idempotency file: %w", err)
+	}
+	writer := bufio.NewWriter(file)
+	for _, r := range records {
+		line, err := json.Marshal(r)
+		if err != nil {
+			_ = file.Close()
+			return err
+		}
+		_, _ = writer.Write(append(line, '\n'))
+	}
+	if err := writer.Flush(); err != nil {
+		_ = file.Close()
+		return fmt.Errorf("cannot write idempotency file: %w", err)
+	}
+	if err := file.Sync(); err != nil {
+		_ = file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmpPath, s.path); err != nil {
+		return fmt.Errorf("cannot rename idempotency file: %w", err)
+	}
+	syncDir(filepath.Dir(s.path))
+
+	active, err := os.OpenFile(s.path, os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("cannot open idempotency file: %w", err)
+	}
+	if s.file != nil {
+		_ = s.file.Close()
+	}
+	s.file, s.fileRecords = active, len(records)
+	s.compactAt = 2 * len(records)
+	if s.compactAt < s.minCompactAt {
+		s.compactAt = s.minCompactAt
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package outbox
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golibs-starter/golib/pubsub/pubsubtest"
+	assert "github.com/stretchr/testify/require"
+)
+
+func TestFileIdempotencyStore_ShouldKeepProcessedPairsAcrossRestarts(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "idempotency", "processed.log")
+	clock := pubsubtest.NewFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+
+	store, err := NewFileIdempotencyStore(path, time.Hour, WithIdempotencyStoreClock(clock))
+	assert.NoError(t, err)
+	assert.NoError(t, store.MarkProcessed("sub-1", "event-1"))
+	clock.Advance(30 * time.Minute)
+	assert.NoError(t, store.MarkProcessed("sub-1", "event-2"))
+	assert.NoError(t, store.Close())
+	assert.ErrorIs(t, store.MarkProcessed("sub-1", "event-3"), ErrIdempotencyStoreClosed)
+
+	// Restart after the first pair is expired, it's removed by compaction
+	clock.Advance(30 * time.Minute)
+	store, err = NewFileIdempotencyStore(path, time.Hour, WithIdempotencyStoreClock(clock))
+	assert.NoError(t, err)
+	defer store.Close()
+	assert.Equal(t, 1, store.Len())
+	processed, err := store.IsProcessed("sub-1", "event-1")
+	assert.NoError(t, err)
+	assert.False(t, processed)
+	processed, err = store.IsProcessed("sub-1", "event-2")
+	assert.NoError(t, err)
+	assert.True(t, processed)
+	processed, _ = store.IsProcessed("sub-2", "event-2")
+	assert.False(t, processed)
+}
+
+func TestFileIdempotencyStore_WhenRecordsAreDoubleOfLivePairs_ShouldCompactFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "processed.log")
+	clock := pubsubtest.NewFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	store, err := NewFileIdempotencyStore(path, time.Minute, WithIdempotencyStoreClock(clock))
+	assert.NoError(t, err)
+	defer store.Close()
+	for i := 0; i < 999; i++ {
+		assert.NoError(t, store.MarkProcessed("sub-1", fmt.Sprintf("old-%d", i)))
+	}
+	clock.Advance(time.Minute)
+	assert.Equal(t, 999, countLines(t, path))
+
+	assert.NoError(t, store.MarkProcessed("sub-1", "new"))
+	assert.Equal(t, 1, store.Len())
+	assert.Equal(t, 1, countLines(t, path))
+
+	// A torn record is skipped when the file is loaded
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0o644)
+	assert.NoError(t, err)
+	_, err = file.WriteString(`{"subscriber":"sub-1","eve`)
+	assert.NoError(t, err)
+	assert.NoError(t, file.Close())
+	reopened, err := NewFileIdempotencyStore(path, time.Minute, WithIdempotencyStoreClock(clock))
+	assert.NoError(t, err)
+	defer reopened.Close()
+	processed, err := reopened.IsProcessed("sub-1", "new")
+	assert.NoError(t, err)
+	assert.True(t, processed)
+}
+
+func countLines(t *testing.T, path string) int {
+	data, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	return strings.Count(string(data), "\n")
+}
@@ -0,0 +1,430 @@
+package outbox
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golibs-starter/golib/log"
+	"github.com/golibs-starter/golib/pubsub"
+)
+
+const segmentExt = ".wal"
+
+var ErrOutboxClosed = errors.New("outbox is closed")
+
+const (
+	recordAppend   = "append"
+	recordDone     = "done"
+	recordComplete = "complete"
+)
+
+// record is a line in the write-ahead log
+type record struct {
+	Type       string          `json:"type"`
+	Id         string          `json:"id"`
+	Name       string          `json:"name,omitempty"`
+	Subscriber string          `json:"subscriber,omitempty"`
+	Event      json.RawMessage `json:"event,omitempty"`
+}
+
+// entry is a pending event
+type entry struct {
+	seq  int64
+	id   string
+	name string
+	data json.RawMessage
+	done map[string]bool
+}
+
+// FileOutbox is a pubsub.Outbox that appends events to a write-ahead log in a directory.
+//
+// The log is split into segment files, each line of a segment is a JSON record of
+// an appended event, a done mark of a subscriber or a completion of an event.
+// When the active segment exceeds the segment size, pending events are compacted
+// into a new segment and older segments are removed.
+type FileOutbox struct {
+	dir           string
+	segmentSize   int64
+	fsyncPolicy   FsyncPolicy
+	fsyncInterval time.Duration
+	decoder       Decoder
+
+	file       *os.File
+	fileSeq    int64
+	fileSize   int64
+	compactAt  int64
+	entries    map[string]*entry
+	entrySeq   int64
+	dirty      bool
+	closed     bool
+	mu         sync.Mutex
+	closeCh    chan struct{}
+	fsyncLoops sync.WaitGroup
+}
+
+// NewFileOutbox opens the write-ahead log in the directory,
+// existing segments are loaded then compacted.
+func NewFileOutbox(dir string, opts ...FileOutboxOpt) (*FileOutbox, error) {
+	o := &FileOutbox{
+		dir:         dir,
+		segmentSize: DefaultSegmentSize,
+		fsyncPolicy: FsyncAlways,
+		entries:     make(map[string]*entry),
+		closeCh:     make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if o.decoder == nil {
+		o.decoder = DefaultDecoder
+	}
+	if !o.fsyncPolicy.IsValid() {
+		return nil, fmt.Errorf("fsync policy [%s] is not supported", o.fsyncPolicy)
+	}
+	if o.fsyncPolicy == FsyncInterval && o.fsyncInterval <= 0 {
+		return nil, fmt.Errorf("fsync interval [%s] must be positive", o.fsyncInterval)
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("cannot create outbox directory: %w", err)
+	}
+	seqs, err := o.segments()
+	if err != nil {
+		return nil, err
+	}
+	for _, seq := range seqs {
+		if err := o.load(seq); err != nil {
+			return nil, err
+		}
+		o.fileSeq = seq
+	}
+	if err := o.compact(); err != nil {
+		return nil, err
+	}
+	if o.fsyncPolicy == FsyncInterval {
+		o.fsyncLoops.Add(1)
+		go o.fsyncLoop()
+	}
+	return o, nil
+}
+
+func (o *FileOutbox) Append(event pubsub.Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("cannot encode event [%s]: %w", event.Name(), err)
+	}
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if err := o.write(record{Type: recordAppend, Id: event.Identifier(), Name: event.Name(), Event: data}); err != nil {
+		return err
+	}
+	o.entrySeq++
+	o.entries[event.Identifier()] = &entry{
+		seq:  o.entrySeq,
+		id:   event.Identifier(),
+		name: event.Name(),
+		data: data,
+		done: make(map[string]bool),
+	}
+	return o.compactIfNeeded()
+}
+
+func (o *FileOutbox) MarkDone(eventId string, subscriberId string) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	e, exists := o.entries[eventId]
+	if !exists {
+		return nil
+	}
+	if err := o.write(record{Type: recordDone, Id: eventId, Subscriber: subscriberId}); err != nil {
+		return err
+	}
+	e.done[subscriberId] = true
+	return o.compactIfNeeded()
+}
+
+func (o *FileOutbox) Complete(eventId string) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if _, exists := o.entries[eventId]; !exists {
+		return nil
+	}
+	if err := o.write(record{Type: recordComplete, Id: eventId}); err != nil {
+		return err
+	}
+	delete(o.entries, eventId)
+	return o.compactIfNeeded()
+}
+
+// Pending returns events that were not completed, the oldest first.
+// Events that cannot be decoded are skipped and kept in the outbox.
+func (o *FileOutbox) Pending() ([]*pubsub.OutboxEntry, error) {
+	o.mu.Lock()
+	entries := o.sortedEntries()
+	doneSubscribers := make([]map[string]bool, 0, len(entries))
+	for _, e := range entries {
+		done := make(map[string]bool, len(e.done))
+		for subscriberId := range e.done {
+			done[subscriberId] = true
+		}
+		doneSubscribers = append(doneSubscribers, done)
+	}
+	o.mu.Unlock()
+
+	pending := make([]*pubsub.OutboxEntry, 0, len(entries))
+	for i, e := range entries {
+		event, err := o.decoder(e.name, e.data)
+		if err != nil {
+			log.WithErrors(err).Warnf("Cannot decode pending event [%s] with id [%s] in outbox, it's skipped", e.name, e.id)
+			continue
+		}
+		pending = append(pending, &pubsub.OutboxEntry{Event: event, DoneSubscribers: doneSubscribers[i]})
+	}
+	return pending, nil
+}
+
+// Len returns the number of pending events
+func (o *FileOutbox) Len() int {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return len(o.entries)
+}
+
+// Close flushes and closes the active segment
+func (o *FileOutbox) Close() error {
+	o.mu.Lock()
+	if o.closed {
+		o.mu.Unlock()
+		return nil
+	}
+	o.closed = true
+	close(o.closeCh)
+	o.mu.Unlock()
+	o.fsyncLoops.Wait()
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if err := o.file.Sync(); err != nil {
+		_ = o.file.Close()
+		return err
+	}
+	return o.file.Close()
+}
+
+// write a record to the active segment, the caller must hold the lock
+func (o *FileOutbox) write(r record) error {
+	if o.closed {
+		return ErrOutboxClosed
+	}
+	line, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	n, err := o.file.Write(append(line, '\n'))
+	o.fileSize += int64(n)
+	if err != nil {
+		return fmt.Errorf("cannot write to outbox segment: %w", err)
+	}
+	switch o.fsyncPolicy {
+	case FsyncAlways:
+		return o.file.Sync()
+	case FsyncInterval:
+		o.dirty = true
+	}
+	return nil
+}
+
+func (o *FileOutbox) compactIfNeeded() error {
+	if o.fileSize < o.compactAt {
+		return nil
+	}
+	return o.compact()
+}
+
+// compact writes pending events to a new segment, then removes older segments.
+// The caller must hold the lock, except when the outbox is being opened.
+func (o *FileOutbox) compact() error {
+	seq := o.fileSeq + 1
+	path := o.segmentPath(seq)
+	tmpPath := path + ".tmp"
+	file, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("cannot create outbox segment: %w", err)
+	}
+	writer := bufio.NewWriter(file)
+	var size int64
+	for _, e := range o.sortedEntries() {
+		records := []record{{Type: recordAppend, Id: e.id, Name: e.name, Event: e.data}}
+		for subscriberId := range e.done {
+			records = append(records, record{Type: recordDone, Id: e.id, Subscriber: subscriberId})
+		}
+		for _, r := range records {
+			line, err := json.Marshal(r)
+			if err != nil {
+				_ = file.Close()
+				return err
+			}
+			n, _ := writer.Write(append(line, '\n'))
+			size += int64(n)
+		}
+	}
+	if err := writer.Flush(); err != nil {
+		_ = file.Close()
+		return fmt.Errorf("cannot write outbox segment: %w", err)
+	}
+	if err := file.Sync(); err != nil {
+		_ = file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		return fmt.Errorf("cannot rename outbox segment: %w", err)
+	}
+	syncDir(o.dir)
+
+	active, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("cannot open outbox segment: %w", err)
+	}
+	if o.file != nil {
+		_ = o.file.Close()
+	}
+	o.file, o.fileSeq, o.fileSize = active, seq, size
+	o.compactAt = size + o.segmentSize
+	o.dirty = false
+
+	seqs, err := o.segments()
+	if err != nil {
+		return err
+	}
+	for _, oldSeq := range seqs {
+		if oldSeq < seq {
+			if err := os.Remove(o.segmentPath(oldSeq)); err != nil && !os.IsNotExist(err) {
+				return fmt.Errorf("cannot remove outbox segment: %w", err)
+			}
+		}
+	}
+	return nil
+}
+
+// load records of a segment, a broken record (such as a torn write) is skipped
+func (o *FileOutbox) load(seq int64) error {
+	file, err := os.Open(o.segmentPath(seq))
+	if err != nil {
+		return fmt.Errorf("cannot open outbox segment: %w", err)
+	}
+	defer file.Close()
+	reader := bufio.NewReader(file)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if err == io.EOF {
+			if len(line) > 0 {
+				log.Warnf("Outbox segment [%d] ends with an incomplete record, it's skipped", seq)
+			}
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("cannot read outbox segment: %w", err)
+		}
+		var r record
+		if err := json.Unmarshal(line, &r); err != nil {
+			log.WithErrors(err).Warnf("Outbox segment [%d] contains a broken record, it's skipped", seq)
+			continue
+		}
+		o.apply(r)
+	}
+}
+
+func (o *FileOutbox) apply(r record) {
+	switch r.Type {
+	case recordAppend:
+		o.entrySeq++
+		o.entries[r.Id] = &entry{seq: o.entrySeq, id: r.Id, name: r.Name, data: r.Event, done: make(map[string]bool)}
+	case recordDone:
+		if e, exists := o.entries[r.Id]; exists {
+			e.done[r.Subscriber] = true
+		}
+	case recordComplete:
+		delete(o.entries, r.Id)
+	}
+}
+
+func (o *FileOutbox) fsyncLoop() {
+	defer o.fsyncLoops.Done()
+	ticker := time.NewTicker(o.fsyncInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			o.mu.Lock()
+			if o.dirty && !o.closed {
+				if err := o.file.Sync(); err != nil {
+					log.WithErrors(err).Error("Cannot fsync outbox segment")
+				} else {
+					o.dirty = false
+				}
+			}
+			o.mu.Unlock()
+		case <-o.closeCh:
+			return
+		}
+	}
+}
+
+func (o *FileOutbox) sortedEntries() []*entry {
+	entries := make([]*entry, 0, len(o.entries))
+	for _, e := range o.entries {
+		entries = append(entries, e)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].seq < entries[j].seq
+	})
+	return entries
+}
+
+// segments returns sequences of existing segments in ascending order
+func (o *FileOutbox) segments() ([]int64, error) {
+	files, err := os.ReadDir(o.dir)
+	if err != nil {
+		return nil, fmt.Errorf("cannot list outbox segments: %w", err)
+	}
+	seqs := make([]int64, 0)
+	for _, file := range files {
+		if file.IsDir() || !strings.HasSuffix(file.Name(), segmentExt) {
+			continue
+		}
+		seq, err := strconv.ParseInt(strings.TrimSuffix(file.Name(), segmentExt), 10, 64)
+		if err != nil {
+			continue
+		}
+		seqs = append(seqs, seq)
+	}
+	sort.Slice(seqs, func(i, j int) bool {
+		return seqs[i] < seqs[j]
+	})
+	return seqs, nil
+}
+
+func (o *FileOutbox) segmentPath(seq int64) string {
+	return filepath.Join(o.dir, fmt.Sprintf("%020d%s", seq, segmentExt))
+}
+
+// syncDir makes renaming and removing files in the directory durable, errors are ignored
+// since some platforms do not support to fsync a directory.
+func syncDir(dir string) {
+	if d, err := os.Open(dir); err == nil {
+		_ = d.Sync()
+		_ = d.Close()
+	}
+}
@@ -0,0 +1,224 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golibs-starter/golib/event"
+	"github.com/golibs-starter/golib/pubsub"
+	"github.com/golibs-starter/golib/pubsub/executor"
+	webEvent "github.com/golibs-starter/golib/web/event"
+	assert "github.com/stretchr/testify/require"
+)
+
+type PaymentCaptured struct {
+	PaymentId string `json:"payment_id"`
+	Amount    int64  `json:"amount"`
+}
+
+func newPaymentEvent(id string) pubsub.Event {
+	return pubsub.MessageEvent[PaymentCaptured]{
+		AbstractEvent: webEvent.NewAbstractEvent(context.Background(), "PaymentCaptured", event.WithId(id)),
+		PayloadData:   PaymentCaptured{PaymentId: "payment-" + id, Amount: 100},
+	}
+}
+
+type DummyPaymentSubscriber struct {
+	id       string
+	fail     bool
+	handled  []string
+	handleMu sync.Mutex
+}
+
+func (d *DummyPaymentSubscriber) SubscriberId() string {
+	return d.id
+}
+
+func (d *DummyPaymentSubscriber) Supports(event pubsub.Event) bool {
+	return event.Name() == "PaymentCaptured"
+}
+
+func (d *DummyPaymentSubscriber) Handle(event pubsub.Event) {
+}
+
+func (d *DummyPaymentSubscriber) HandleWithError(ctx context.Context, event pubsub.Event) error {
+	d.handleMu.Lock()
+	defer d.handleMu.Unlock()
+	d.handled = append(d.handled, event.Identifier())
+	if d.fail {
+		return errors.New("payment gateway is unavailable")
+	}
+	return nil
+}
+
+func segmentFiles(t *testing.T, dir string) []string {
+	files, err := filepath.Glob(filepath.Join(dir, "*"+segmentExt))
+	assert.NoError(t, err)
+	return files
+}
+
+func TestFileOutbox_WhenReopen_ShouldRestorePendingEvents(t *testing.T) {
+	dir := t.TempDir()
+	o, err := NewFileOutbox(dir)
+	assert.NoError(t, err)
+	assert.NoError(t, o.Append(newPaymentEvent("1")))
+	assert.NoError(t, o.Append(newPaymentEvent("2")))
+	assert.NoError(t, o.Append(newPaymentEvent("3")))
+	assert.NoError(t, o.MarkDone("1", "subscriber-1"))
+	assert.NoError(t, o.Complete("2"))
+	assert.NoError(t, o.Close())
+	assert.ErrorIs(t, o.Append(newPaymentEvent("4")), ErrOutboxClosed)
+
+	o, err = NewFileOutbox(dir)
+	assert.NoError(t, err)
+	defer o.Close()
+	assert.Equal(t, 2, o.Len())
+	pending, err := o.Pending()
+	assert.NoError(t, err)
+	assert.Len(t, pending, 2)
+	assert.Equal(t, "1", pending[0].Event.Identifier())
+	assert.Equal(t, "PaymentCaptured", pending[0].Event.Name())
+	assert.Equal(t, map[string]interface{}{"payment_id": "payment-1", "amount": float64(100)}, pending[0].Event.Payload())
+	assert.Equal(t, map[string]bool{"subscriber-1": true}, pending[0].DoneSubscribers)
+	assert.Equal(t, "3", pending[1].Event.Identifier())
+	assert.Empty(t, pending[1].DoneSubscribers)
+}
+
+func TestFileOutbox_GivenRegistryDecoder_ShouldRestoreTypedEvents(t *testing.T) {
+	registry := pubsub.NewEventTypeRegistry()
+	assert.NoError(t, pubsub.RegisterMessage[PaymentCaptured](registry))
+	dir := t.TempDir()
+	o, err := NewFileOutbox(dir)
+	assert.NoError(t, err)
+	assert.NoError(t, o.Append(newPaymentEvent("1")))
+	assert.NoError(t, o.Close())
+
+	o, err = NewFileOutbox(dir, WithDecoder(RegistryDecoder(registry)))
+	assert.NoError(t, err)
+	defer o.Close()
+	pending, err := o.Pending()
+	assert.NoError(t, err)
+	assert.Len(t, pending, 1)
+	assert.IsType(t, pubsub.MessageEvent[PaymentCaptured]{}, pending[0].Event)
+	assert.Equal(t, PaymentCaptured{PaymentId: "payment-1", Amount: 100}, pending[0].Event.Payload())
+}
+
+func TestFileOutbox_WhenSegmentExceedsSize_ShouldCompact(t *testing.T) {
+	dir := t.TempDir()
+	o, err := NewFileOutbox(dir, WithSegmentSize(1024), WithFsyncPolicy(FsyncNever, 0))
+	assert.NoError(t, err)
+	defer o.Close()
+	for i := 0; i < 100; i++ {
+		id := time.Now().Format(time.RFC3339Nano) + "-" + string(rune('a'+i%26))
+		assert.NoError(t, o.Append(newPaymentEvent(id)))
+		assert.NoError(t, o.Complete(id))
+	}
+	assert.NoError(t, o.Append(newPaymentEvent("pending")))
+	assert.Len(t, segmentFiles(t, dir), 1)
+	info, err := os.Stat(segmentFiles(t, dir)[0])
+	assert.NoError(t, err)
+	assert.Less(t, info.Size(), int64(2048))
+
+	pending, err := o.Pending()
+	assert.NoError(t, err)
+	assert.Len(t, pending, 1)
+	assert.Equal(t, "pending", pending[0].Event.Identifier())
+}
+
+func TestFileOutbox_WhenLastRecordIsTorn_ShouldSkipIt(t *testing.T) {
+	dir := t.TempDir()
+	o, err := NewFileOutbox(dir)
+	assert.NoError(t, err)
+	assert.NoError(t, o.Append(newPaymentEvent("1")))
+	assert.NoError(t, o.Close())
+
+	files := segmentFiles(t, dir)
+	assert.Len(t, files, 1)
+	file, err := os.OpenFile(files[0], os.O_WRONLY|os.O_APPEND, 0o644)
+	assert.NoError(t, err)
+	_, err = file.WriteString(`{"type":"append","id":"2","na`)
+	assert.NoError(t, err)
+	assert.NoError(t, file.Close())
+
+	o, err = NewFileOutbox(dir)
+	assert.NoError(t, err)
+	defer o.Close()
+	pending, err := o.Pending()
+	assert.NoError(t, err)
+	assert.Len(t, pending, 1)
+	assert.Equal(t, "1", pending[0].Event.Identifier())
+}
+
+func TestFileOutbox_GivenIntervalFsyncPolicy_ShouldAppendCorrectly(t *testing.T) {
+	dir := t.TempDir()
+	o, err := NewFileOutbox(dir, WithFsyncPolicy(FsyncInterval, 5*time.Millisecond))
+	assert.NoError(t, err)
+	assert.NoError(t, o.Append(newPaymentEvent("1")))
+	time.Sleep(20 * time.Millisecond)
+	assert.NoError(t, o.Close())
+
+	_, err = NewFileOutbox(dir, WithFsyncPolicy("sometimes", 0))
+	assert.Error(t, err)
+	_, err = NewFileOutbox(dir, WithFsyncPolicy(FsyncInterval, 0))
+	assert.Error(t, err)
+	_, err = NewFileOutbox(dir, WithFsyncPolicy(FsyncInterval, -time.Second))
+	assert.Error(t, err)
+}
+
+func TestDefaultEventBus_GivenFileOutbox_ShouldReplayToSubscribersThatAreNotDone(t *testing.T) {
+	dir := t.TempDir()
+	o, err := NewFileOutbox(dir)
+	assert.NoError(t, err)
+	s1 := &DummyPaymentSubscriber{id: "s1"}
+	s2 := &DummyPaymentSubscriber{id: "s2", fail: true}
+	bus := pubsub.NewDefaultEventBus(
+		pubsub.WithEventExecutor(executor.NewSyncExecutor()),
+		pubsub.WithOutbox(o, "Payment*"),
+		pubsub.WithEventErrorHandler(func(event pubsub.Event, subscriberId string, err error, stack []byte) {}),
+	)
+	bus.Register(s1, s2)
+	bus.Run()
+	bus.Deliver(newPaymentEvent("1"))
+	bus.Deliver(&webEvent.AbstractEvent{ApplicationEvent: event.NewApplicationEvent(context.Background(), "OtherEvent")})
+	assert.NoError(t, bus.Shutdown(context.Background()))
+	assert.NoError(t, o.Close())
+	assert.Equal(t, []string{"1"}, s1.handled)
+	assert.Equal(t, []string{"1"}, s2.handled)
+
+	// Restart, only the failed subscriber receives the event again
+	o, err = NewFileOutbox(dir)
+	assert.NoError(t, err)
+	defer o.Close()
+	assert.Equal(t, 1, o.Len())
+	s1 = &DummyPaymentSubscriber{id: "s1"}
+	s2 = &DummyPaymentSubscriber{id: "s2"}
+	bus = pubsub.NewDefaultEventBus(
+		pubsub.WithEventExecutor(executor.NewSyncExecutor()),
+		pubsub.WithOutbox(o, "Payment*"),
+	)
+	bus.Register(s1, s2)
+	bus.Run()
+	assert.NoError(t, bus.Shutdown(context.Background()))
+	assert.Empty(t, s1.handled)
+	assert.Equal(t, []string{"1"}, s2.handled)
+	assert.Equal(t, 0, o.Len())
+}
+
+func TestDefaultEventBus_GivenFileOutbox_WhenEventIsNotAccepted_ShouldDiscardIt(t *testing.T) {
+	o, err := NewFileOutbox(t.TempDir())
+	assert.NoError(t, err)
+	defer o.Close()
+	bus := pubsub.NewDefaultEventBus(
+		pubsub.WithEventChannelSize(1),
+		pubsub.WithOverflowPolicy(pubsub.OverflowReject, 0),
+		pubsub.WithOutbox(o),
+	)
+	assert.NoError(t, bus.TryDeliver(context.Background(), newPaymentEvent("1")))
+	assert.ErrorIs(t, bus.TryDeliver(context.Background(), newPaymentEvent("2")), pubsub.ErrQueueFull)
+	assert.Equal(t, 1, o.Len())
+}
@@ -0,0 +1,174 @@
+package outbox
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/golibs-starter/golib/pubsub"
+)
+
+// scheduledRecord is a scheduled event in the schedule file
+type scheduledRecord struct {
+	Id    string          `json:"id"`
+	Name  string          `json:"name"`
+	DueAt time.Time       `json:"due_at"`
+	Event json.RawMessage `json:"event"`
+}
+
+// FileScheduleStore is a pubsub.ScheduleStore that keeps scheduled events in a JSON file.
+// The whole file is rewritten atomically on every change, so it's suitable for
+// a moderate number of scheduled events, such as timeouts of pending payments.
+type FileScheduleStore struct {
+	path    string
+	decoder Decoder
+	records map[string]*scheduledRecord
+	mu      sync.Mutex
+}
+
+type FileScheduleStoreOpt func(s *FileScheduleStore)
+
+// WithScheduleDecoder sets the decoder that restores scheduled events
+func WithScheduleDecoder(decoder Decoder) FileScheduleStoreOpt {
+	return func(s *FileScheduleStore) {
+		s.decoder = decoder
+	}
+}
+
+// NewFileScheduleStore opens the schedule file, it's created on the first change when it does not exist.
+func NewFileScheduleStore(path string, opts ...FileScheduleStoreOpt) (*FileScheduleStore, error) {
+	s := &FileScheduleStore{
+		path:    path,
+		decoder: DefaultDecoder,
+		records: make(map[string]*scheduledRecord),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot read schedule file: %w", err)
+	}
+	var records []*scheduledRecord
+	if err := json.Unmarshal(data, &records); err != nil {
+		return nil, fmt.Errorf("cannot decode schedule file: %w", err)
+	}
+	for _, r := range records {
+		s.records[r.Id] = r
+	}
+	return s, nil
+}
+
+func (s *FileScheduleStore) Save(scheduled *pubsub.ScheduledEvent) error {
+	data, err := json.Marshal(scheduled.Event)
+	if err != nil {
+		return fmt.Errorf("cannot encode event [%s]: %w", scheduled.Event.Name(), err)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	id := scheduled.Event.Identifier()
+	previous := s.records[id]
+	s.records[id] = &scheduledRecord{Id: id, Name: scheduled.Event.Name(), DueAt: scheduled.DueAt, Event: data}
+	if err := s.flush(); err != nil {
+		if previous != nil {
+			s.records[id] = previous
+		} else {
+			delete(s.records, id)
+		}
+		return err
+	}
+	return nil
+}
+
+func (s *FileScheduleStore) Delete(eventId string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	previous, exists := s.records[eventId]
+	if !exists {
+		return nil
+	}
+	delete(s.records, eventId)
+	if err := s.flush(); err != nil {
+		s.records[eventId] = previous
+		return err
+	}
+	return nil
+}
+
+// List returns scheduled events ordered by due time,
+// an event that cannot be decoded is returned as an error.
+func (s *FileScheduleStore) List() ([]*pubsub.ScheduledEvent, error) {
+	s.mu.Lock()
+	records := s.sortedRecords()
+	s.mu.Unlock()
+	scheduled := make([]*pubsub.ScheduledEvent, 0, len(records))
+	for _, r := range records {
+		event, err := s.decoder(r.Name, r.Event)
+		if err != nil {
+			return nil, fmt.Errorf("cannot decode scheduled event [%s]: %w", r.Id, err)
+		}
+		scheduled = append(scheduled, &pubsub.ScheduledEvent{Event: event, DueAt: r.DueAt})
+	}
+	return scheduled, nil
+}
+
+// Len returns the number of scheduled events
+func (s *FileScheduleStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.records)
+}
+
+// flush rewrites the schedule file, the caller must hold the lock
+func (s *FileScheduleStore) flush() error {
+	data, err := json.Marshal(s.sortedRecords())
+	if err != nil {
+		return err
+	}
+	dir := filepath.Dir(s.path)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("cannot create schedule directory: %w", err)
+	}
+	tmpPath := s.path + ".tmp"
+	file, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("cannot create schedule file: %w", err)
+	}
+	if _, err := file.Write(data); err != nil {
+		_ = file.Close()
+		return fmt.Errorf("cannot write schedule file: %w", err)
+	}
+	if err := file.Sync(); err != nil {
+		_ = file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmpPath, s.path); err != nil {
+		return fmt.Errorf("cannot rename schedule file: %w", err)
+	}
+	syncDir(dir)
+	return nil
+}
+
+func (s *FileScheduleStore) sortedRecords() []*scheduledRecord {
+	records := make([]*scheduledRecord, 0, len(s.records))
+	for _, r := range s.records {
+		records = append(records, r)
+	}
+	sort.Slice(records, func(i, j int) bool {
+		if records[i].DueAt.Equal(records[j].DueAt) {
+			return records[i].Id < records[j].Id
+		}
+		return records[i].DueAt.Before(records[j].DueAt)
+	})
+	return records
+}
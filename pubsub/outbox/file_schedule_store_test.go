@@ -0,0 +1,50 @@
+package outbox
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golibs-starter/golib/pubsub"
+	"github.com/golibs-starter/golib/pubsub/pubsubtest"
+	assert "github.com/stretchr/testify/require"
+)
+
+func TestFileScheduleStore_ShouldKeepScheduledEventsAcrossRestarts(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "schedule", "events.json")
+	registry := pubsub.NewEventTypeRegistry()
+	assert.NoError(t, pubsub.RegisterMessage[PaymentCaptured](registry))
+	clock := pubsubtest.NewFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+
+	store, err := NewFileScheduleStore(path)
+	assert.NoError(t, err)
+	scheduler := pubsub.NewEventScheduler(pubsub.WithSchedulerClock(clock), pubsub.WithScheduleStore(store))
+	_, err = scheduler.Schedule(clock.Now().Add(time.Hour), newPaymentEvent("1"))
+	assert.NoError(t, err)
+	cancelled, err := scheduler.Schedule(clock.Now().Add(time.Hour), newPaymentEvent("2"))
+	assert.NoError(t, err)
+	assert.True(t, cancelled.Cancel())
+	assert.Equal(t, 1, store.Len())
+	scheduler.Stop()
+
+	// Restart, the pending event is published when it's due
+	store, err = NewFileScheduleStore(path, WithScheduleDecoder(RegistryDecoder(registry)))
+	assert.NoError(t, err)
+	bus := pubsubtest.NewBus()
+	bus.Run()
+	defer bus.Stop()
+	scheduler = pubsub.NewEventScheduler(pubsub.WithSchedulerClock(clock), pubsub.WithScheduleStore(store))
+	assert.NoError(t, scheduler.Start(bus.TryDeliver))
+	defer scheduler.Stop()
+	pending := scheduler.Pending()
+	assert.Len(t, pending, 1)
+	assert.Equal(t, "1", pending[0].Event.Identifier())
+	assert.True(t, clock.Now().Add(time.Hour).Equal(pending[0].DueAt))
+
+	clock.Advance(time.Hour)
+	e := bus.AwaitEvent(t, pubsubtest.EventNamed("PaymentCaptured"), time.Second)
+	assert.Equal(t, PaymentCaptured{PaymentId: "payment-1", Amount: 100}, e.Payload())
+	assert.Eventually(t, func() bool {
+		return store.Len() == 0
+	}, time.Second, 5*time.Millisecond)
+}
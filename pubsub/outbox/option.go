@@ -0,0 +1,57 @@
+package outbox
+
+import "time"
+
+// DefaultSegmentSize is the size that the active segment is compacted when it exceeds
+const DefaultSegmentSize = 64 * 1024 * 1024
+
+// FsyncPolicy defines when appended records are flushed to the disk
+type FsyncPolicy string
+
+const (
+	// FsyncAlways flushes every record before it's acknowledged
+	FsyncAlways FsyncPolicy = "always"
+
+	// FsyncInterval flushes records periodically,
+	// records appended in the last interval may be lost on crash.
+	FsyncInterval FsyncPolicy = "interval"
+
+	// FsyncNever leaves flushing to the operating system
+	FsyncNever FsyncPolicy = "never"
+)
+
+// IsValid returns whether the policy is supported
+func (p FsyncPolicy) IsValid() bool {
+	switch p {
+	case FsyncAlways, FsyncInterval, FsyncNever:
+		return true
+	}
+	return false
+}
+
+type FileOutboxOpt func(o *FileOutbox)
+
+// WithSegmentSize sets the size in bytes that the active segment is compacted when it exceeds
+func WithSegmentSize(size int64) FileOutboxOpt {
+	return func(o *FileOutbox) {
+		if size > 0 {
+			o.segmentSize = size
+		}
+	}
+}
+
+// WithFsyncPolicy sets the fsync policy, the interval is only used by FsyncInterval policy
+// and it must be positive then.
+func WithFsyncPolicy(policy FsyncPolicy, interval time.Duration) FileOutboxOpt {
+	return func(o *FileOutbox) {
+		o.fsyncPolicy = policy
+		o.fsyncInterval = interval
+	}
+}
+
+// WithDecoder sets the decoder that restores pending events
+func WithDecoder(decoder Decoder) FileOutboxOpt {
+	return func(o *FileOutbox) {
+		o.decoder = decoder
+	}
+}
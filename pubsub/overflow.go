@@ -0,0 +1,34 @@
+package pubsub
+
+// OverflowPolicy defines the behavior when an event
+// is delivered while the event channel is full.
+type OverflowPolicy string
+
+const (
+	// OverflowBlock blocks the delivery until the channel has free space
+	OverflowBlock OverflowPolicy = "block"
+
+	// OverflowBlockTimeout blocks the delivery until the channel has free space or the timeout is reached,
+	// the event is dropped when the timeout is reached
+	OverflowBlockTimeout OverflowPolicy = "block_timeout"
+
+	// OverflowDropNewest drops the delivering event
+	OverflowDropNewest OverflowPolicy = "drop_newest"
+
+	// OverflowDropOldest drops the oldest event in the channel to make space for the delivering event,
+	// the delivering event is dropped when the channel is refilled concurrently.
+	// An unbuffered channel has no oldest event, so the delivery falls back to OverflowBlockTimeout.
+	OverflowDropOldest OverflowPolicy = "drop_oldest"
+
+	// OverflowReject rejects the delivering event with ErrQueueFull
+	OverflowReject OverflowPolicy = "reject"
+)
+
+// IsValid returns whether the policy is supported
+func (p OverflowPolicy) IsValid() bool {
+	switch p {
+	case OverflowBlock, OverflowBlockTimeout, OverflowDropNewest, OverflowDropOldest, OverflowReject:
+		return true
+	}
+	return false
+}
@@ -1,7 +1,30 @@
 package pubsub
 
+import "context"
+
 type Publisher interface {
 
 	// Publish an event
 	Publish(event Event)
 }
+
+// ContextPublisher is an optional interface for a Publisher,
+// that returns an error when the event is not accepted.
+type ContextPublisher interface {
+	Publisher
+
+	// PublishCtx publishes an event and returns an error when the event is not accepted.
+	// The context limits the time to wait when the event queue is full.
+	PublishCtx(ctx context.Context, event Event) error
+}
+
+// PublishCtxOn publishes an event by the publisher, and returns an error when the event is not accepted.
+// The event is published by Publisher.Publish when the publisher is not a ContextPublisher,
+// the context is ignored and no error is returned then.
+func PublishCtxOn(ctx context.Context, publisher Publisher, event Event) error {
+	if contextPublisher, ok := publisher.(ContextPublisher); ok {
+		return contextPublisher.PublishCtx(ctx, event)
+	}
+	publisher.Publish(event)
+	return nil
+}
@@ -0,0 +1,36 @@
+package pubsubtest
+
+import (
+	"context"
+
+	"github.com/golibs-starter/golib/pubsub"
+	"github.com/golibs-starter/golib/pubsub/executor"
+)
+
+// Bus is a pubsub.DefaultEventBus that records accepted events.
+// Subscribers handle events one by one in the dispatching goroutine,
+// unless another executor is given.
+type Bus struct {
+	*pubsub.DefaultEventBus
+	*Recorder
+}
+
+func NewBus(opts ...pubsub.EventBusOpt) *Bus {
+	opts = append([]pubsub.EventBusOpt{pubsub.WithEventExecutor(executor.NewSyncExecutor())}, opts...)
+	return &Bus{
+		DefaultEventBus: pubsub.NewDefaultEventBus(opts...),
+		Recorder:        NewRecorder(),
+	}
+}
+
+func (b *Bus) Deliver(event pubsub.Event) {
+	_ = b.TryDeliver(context.Background(), event)
+}
+
+func (b *Bus) TryDeliver(ctx context.Context, event pubsub.Event) error {
+	if err := b.DefaultEventBus.TryDeliver(ctx, event); err != nil {
+		return err
+	}
+	b.Record(event)
+	return nil
+}
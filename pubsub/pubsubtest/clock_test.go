@@ -0,0 +1,158 @@
+package pubsubtest
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golibs-starter/golib/pubsub"
+	assert "github.com/stretchr/testify/require"
+)
+
+type PaymentExpired struct {
+	PaymentId string
+}
+
+func TestFakeClock_ShouldFireTimersWhenAdvanced(t *testing.T) {
+	clock := NewFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	timer1 := clock.NewTimer(time.Minute)
+	timer2 := clock.NewTimer(time.Hour)
+	assert.Equal(t, 2, clock.Timers())
+
+	clock.Advance(time.Minute)
+	assert.Equal(t, clock.Now(), <-timer1.C())
+	assert.Equal(t, 1, clock.Timers())
+	assert.True(t, timer2.Stop())
+	assert.False(t, timer2.Stop())
+	assert.Equal(t, 0, clock.Timers())
+}
+
+func TestEventScheduler_GivenFakeClock_ShouldPublishWhenDue(t *testing.T) {
+	clock := NewFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	bus := NewBus()
+	bus.Run()
+	defer bus.Stop()
+	scheduler := pubsub.NewEventScheduler(pubsub.WithSchedulerClock(clock))
+	publisher := pubsub.NewDefaultPublisher(bus, pubsub.WithScheduler(scheduler))
+	assert.NoError(t, scheduler.Start(publisher.PublishCtx))
+	defer scheduler.Stop()
+
+	expired, err := publisher.PublishAfter(15*time.Minute, newMessageEvent(PaymentExpired{PaymentId: "1"}))
+	assert.NoError(t, err)
+	assert.Equal(t, clock.Now().Add(15*time.Minute), expired.DueAt())
+	cancelled, err := publisher.PublishAt(clock.Now().Add(10*time.Minute), newMessageEvent(PaymentExpired{PaymentId: "2"}))
+	assert.NoError(t, err)
+	_, err = publisher.PublishAfter(time.Minute, newMessageEvent(PaymentExpired{PaymentId: "3"}))
+	assert.NoError(t, err)
+	assert.Len(t, scheduler.Pending(), 3)
+	assert.Equal(t, cancelled.Id(), scheduler.Pending()[1].Event.Identifier())
+
+	assert.True(t, cancelled.Cancel())
+	assert.False(t, cancelled.Cancel())
+	_, err = publisher.PublishAt(expired.DueAt(), newMessageEvent(PaymentExpired{PaymentId: "4"}))
+	assert.NoError(t, err)
+
+	clock.Advance(time.Minute)
+	e := bus.AwaitEvent(t, EventOf[pubsub.MessageEvent[PaymentExpired]](), time.Second)
+	assert.Equal(t, PaymentExpired{PaymentId: "3"}, e.Payload())
+
+	clock.Advance(13 * time.Minute)
+	time.Sleep(20 * time.Millisecond)
+	assert.Len(t, bus.Events(), 1)
+
+	clock.Advance(time.Minute)
+	assert.Eventually(t, func() bool {
+		return len(bus.Events()) == 3
+	}, time.Second, 5*time.Millisecond)
+	payloads := make([]interface{}, 0)
+	for _, e := range bus.Events() {
+		payloads = append(payloads, e.Payload())
+	}
+	assert.Equal(t, []interface{}{PaymentExpired{PaymentId: "3"}, PaymentExpired{PaymentId: "1"}, PaymentExpired{PaymentId: "4"}}, payloads)
+	assert.Empty(t, scheduler.Pending())
+	assert.Equal(t, int64(3), scheduler.PublishedCount())
+	assert.Equal(t, int64(1), scheduler.CancelledCount())
+	assert.False(t, expired.Cancel())
+}
+
+func TestEventScheduler_WhenBusIsNotRunning_ShouldRetry(t *testing.T) {
+	clock := NewFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	bus := NewBus()
+	scheduler := pubsub.NewEventScheduler(pubsub.WithSchedulerClock(clock), pubsub.WithSchedulerRetryInterval(time.Minute))
+	publisher := pubsub.NewDefaultPublisher(bus, pubsub.WithScheduler(scheduler))
+	assert.NoError(t, scheduler.Start(publisher.PublishCtx))
+	defer scheduler.Stop()
+
+	_, err := publisher.PublishAt(clock.Now(), newMessageEvent(PaymentExpired{PaymentId: "1"}))
+	assert.NoError(t, err)
+	assert.Eventually(t, func() bool {
+		return scheduler.FailedCount() == 1
+	}, time.Second, 5*time.Millisecond)
+	assert.Len(t, scheduler.Pending(), 1)
+	assert.Equal(t, clock.Now().Add(time.Minute), scheduler.Pending()[0].DueAt)
+
+	bus.Run()
+	defer bus.Stop()
+	clock.Advance(time.Minute)
+	bus.AwaitEvent(t, EventNamed("PaymentExpired"), time.Second)
+	assert.Equal(t, int64(1), scheduler.PublishedCount())
+
+	// A shutdown bus never runs again, so the due event is not retried
+	assert.NoError(t, bus.Shutdown(context.Background()))
+	_, err = publisher.PublishAt(clock.Now(), newMessageEvent(PaymentExpired{PaymentId: "2"}))
+	assert.NoError(t, err)
+	assert.Eventually(t, func() bool {
+		return scheduler.FailedCount() == 2
+	}, time.Second, 5*time.Millisecond)
+	assert.Empty(t, scheduler.Pending())
+
+	scheduler.Stop()
+	_, err = publisher.PublishAfter(time.Minute, newMessageEvent(PaymentExpired{PaymentId: "2"}))
+	assert.ErrorIs(t, err, pubsub.ErrSchedulerStopped)
+
+	_, err = pubsub.NewDefaultPublisher(bus).PublishAfter(time.Minute, newMessageEvent(PaymentExpired{PaymentId: "2"}))
+	assert.ErrorIs(t, err, pubsub.ErrSchedulingNotSupported)
+}
+
+func TestEventScheduler_WhenPublishingFailedPermanently_ShouldDropToDeadLetterSink(t *testing.T) {
+	clock := NewFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	deadLetters := pubsub.NewInMemoryDeadLetterStore(10)
+	scheduler := pubsub.NewEventScheduler(pubsub.WithSchedulerClock(clock),
+		pubsub.WithSchedulerRetryInterval(time.Minute),
+		pubsub.WithSchedulerMaxAttempts(2),
+		pubsub.WithSchedulerDeadLetterSink(deadLetters))
+	errs := map[string]error{
+		"invalid":   &pubsub.SchemaValidationError{Event: "PaymentExpired", Err: errors.New("invalid")},
+		"transient": errors.New("transient error"),
+		"stopped":   pubsub.ErrBusStopped,
+	}
+	assert.NoError(t, scheduler.Start(func(ctx context.Context, event pubsub.Event) error {
+		return errs[event.Payload().(PaymentExpired).PaymentId]
+	}))
+	defer scheduler.Stop()
+
+	for _, id := range []string{"invalid", "transient", "stopped"} {
+		_, err := scheduler.Schedule(clock.Now(), newMessageEvent(PaymentExpired{PaymentId: id}))
+		assert.NoError(t, err)
+	}
+	assert.Eventually(t, func() bool {
+		return scheduler.FailedCount() == 3
+	}, time.Second, 5*time.Millisecond)
+	assert.Len(t, scheduler.Pending(), 1)
+	assert.Equal(t, int64(1), scheduler.DroppedCount())
+
+	clock.Advance(time.Minute)
+	assert.Eventually(t, func() bool {
+		return scheduler.DroppedCount() == 2
+	}, time.Second, 5*time.Millisecond)
+	assert.Empty(t, scheduler.Pending())
+	assert.Equal(t, int64(4), scheduler.FailedCount())
+	deadLetterIds := make([]interface{}, 0)
+	for _, deadLetter := range deadLetters.List() {
+		assert.Equal(t, "event_scheduler", deadLetter.SubscriberId)
+		deadLetterIds = append(deadLetterIds, deadLetter.Event.Payload())
+	}
+	assert.Equal(t, []interface{}{PaymentExpired{PaymentId: "invalid"}, PaymentExpired{PaymentId: "transient"}}, deadLetterIds)
+	assert.Equal(t, 2, deadLetters.List()[1].Attempts)
+}
@@ -0,0 +1,24 @@
+package pubsubtest
+
+import (
+	"github.com/golibs-starter/golib/pubsub"
+	"go.uber.org/fx"
+)
+
+// RecorderOpt decorates the pubsub.Publisher by a recording Publisher that
+// forwards events to the original one. Because the global publisher is replaced
+// by the decorated one, events that published by pubsub.Publish are recorded too.
+// pubsub.PublishAt and pubsub.PublishAfter are forwarded to the original publisher,
+// scheduled events are recorded when they are due.
+// Use fx.Populate to get the *Recorder, eg:
+//
+//	var recorder *pubsubtest.Recorder
+//	fx.New(golib.EventOpt(), pubsubtest.RecorderOpt(), fx.Populate(&recorder))
+func RecorderOpt() fx.Option {
+	return fx.Options(
+		fx.Provide(NewRecorder),
+		fx.Decorate(func(publisher pubsub.Publisher, recorder *Recorder) pubsub.Publisher {
+			return NewPublisher(recorder, publisher)
+		}),
+	)
+}
@@ -0,0 +1,68 @@
+package pubsubtest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golibs-starter/golib/pubsub"
+)
+
+// Publisher is a pubsub.Publisher that records published events,
+// then forwards them to the delegate publisher (if any).
+type Publisher struct {
+	*Recorder
+	delegate pubsub.Publisher
+}
+
+// NewPublisher creates a recording Publisher,
+// events are only recorded when delegate is nil.
+func NewPublisher(recorder *Recorder, delegate pubsub.Publisher) *Publisher {
+	return &Publisher{Recorder: recorder, delegate: delegate}
+}
+
+func (p *Publisher) Publish(event pubsub.Event) {
+	p.Record(event)
+	if p.delegate != nil {
+		p.delegate.Publish(event)
+	}
+}
+
+func (p *Publisher) PublishCtx(ctx context.Context, event pubsub.Event) error {
+	p.Record(event)
+	if p.delegate != nil {
+		return pubsub.PublishCtxOn(ctx, p.delegate, event)
+	}
+	return nil
+}
+
+// PublishAt forwards the event to the delegate publisher when it's a pubsub.SchedulingPublisher,
+// the event is recorded when it's due if the scheduler publishes by this publisher (such as in EventOpt).
+func (p *Publisher) PublishAt(at time.Time, event pubsub.Event) (*pubsub.ScheduleHandle, error) {
+	if publisher, ok := p.delegate.(pubsub.SchedulingPublisher); ok {
+		return publisher.PublishAt(at, event)
+	}
+	return nil, pubsub.ErrSchedulingNotSupported
+}
+
+// PublishAfter is the same as PublishAt, but the event is due after the given delay
+func (p *Publisher) PublishAfter(delay time.Duration, event pubsub.Event) (*pubsub.ScheduleHandle, error) {
+	if publisher, ok := p.delegate.(pubsub.SchedulingPublisher); ok {
+		return publisher.PublishAfter(delay, event)
+	}
+	return nil, pubsub.ErrSchedulingNotSupported
+}
+
+// SwapGlobal replaces the global publisher by a recording Publisher
+// until the test and all its subtests complete,
+// so events that published by pubsub.Publish are recorded without being delivered.
+func SwapGlobal(t testing.TB) *Publisher {
+	t.Helper()
+	bus, publisher := pubsub.GetEventBus(), pubsub.GetPublisher()
+	recordingPublisher := NewPublisher(NewRecorder(), nil)
+	pubsub.ReplaceGlobal(bus, recordingPublisher)
+	t.Cleanup(func() {
+		pubsub.ReplaceGlobal(bus, publisher)
+	})
+	return recordingPublisher
+}
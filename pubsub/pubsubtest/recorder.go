@@ -0,0 +1,129 @@
+package pubsubtest
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golibs-starter/golib/pubsub"
+)
+
+// Recorder records events in the order that they are received,
+// it's safe to use concurrently.
+type Recorder struct {
+	events []pubsub.Event
+	mu     sync.RWMutex
+
+	// notifyCh is closed and replaced when an event is recorded
+	notifyCh chan struct{}
+}
+
+func NewRecorder() *Recorder {
+	return &Recorder{
+		events:   make([]pubsub.Event, 0),
+		notifyCh: make(chan struct{}),
+	}
+}
+
+// Record an event
+func (r *Recorder) Record(event pubsub.Event) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, event)
+	close(r.notifyCh)
+	r.notifyCh = make(chan struct{})
+}
+
+// Events returns all recorded events, the oldest first
+func (r *Recorder) Events() []pubsub.Event {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	events := make([]pubsub.Event, len(r.events))
+	copy(events, r.events)
+	return events
+}
+
+// EventNames returns names of all recorded events, the oldest first
+func (r *Recorder) EventNames() []string {
+	events := r.Events()
+	names := make([]string, 0, len(events))
+	for _, event := range events {
+		names = append(names, event.Name())
+	}
+	return names
+}
+
+// Reset removes all recorded events
+func (r *Recorder) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = make([]pubsub.Event, 0)
+}
+
+// AwaitEvent waits until an event that matches the predicate is recorded and returns it,
+// the test fails when no event matched before the timeout.
+func (r *Recorder) AwaitEvent(t testing.TB, predicate func(event pubsub.Event) bool, timeout time.Duration) pubsub.Event {
+	t.Helper()
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	for {
+		r.mu.RLock()
+		notifyCh := r.notifyCh
+		for _, event := range r.events {
+			if predicate(event) {
+				r.mu.RUnlock()
+				return event
+			}
+		}
+		r.mu.RUnlock()
+		select {
+		case <-notifyCh:
+		case <-timer.C:
+			t.Fatalf("No matched event was recorded after [%s], recorded events: %v", timeout, r.EventNames())
+			return nil
+		}
+	}
+}
+
+// AssertOrder asserts that events with the given names were recorded in order,
+// other events in between are ignored.
+func (r *Recorder) AssertOrder(t testing.TB, names ...string) {
+	t.Helper()
+	recordedNames := r.EventNames()
+	i := 0
+	for _, name := range recordedNames {
+		if i < len(names) && names[i] == name {
+			i++
+		}
+	}
+	if i < len(names) {
+		t.Errorf("Events %v were not recorded in order, recorded events: %v", names, recordedNames)
+	}
+}
+
+// EventsOf returns recorded events that are assignable to T, the oldest first.
+// Eg: EventsOf[*event.RequestCompletedEvent](recorder)
+func EventsOf[T any](r *Recorder) []T {
+	matched := make([]T, 0)
+	for _, event := range r.Events() {
+		if e, ok := event.(T); ok {
+			matched = append(matched, e)
+		}
+	}
+	return matched
+}
+
+// EventNamed returns a predicate that matches events by name
+func EventNamed(name string) func(event pubsub.Event) bool {
+	return func(event pubsub.Event) bool {
+		return event.Name() == name
+	}
+}
+
+// EventOf returns a predicate that matches events that are assignable to T
+func EventOf[T any]() func(event pubsub.Event) bool {
+	return func(event pubsub.Event) bool {
+		_, ok := event.(T)
+		return ok
+	}
+}
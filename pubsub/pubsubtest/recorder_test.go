@@ -0,0 +1,168 @@
+package pubsubtest
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/golibs-starter/golib/pubsub"
+	"github.com/golibs-starter/golib/web/event"
+	assert "github.com/stretchr/testify/require"
+	"go.uber.org/fx"
+)
+
+type OrderCreated struct {
+	OrderId string
+}
+
+type OrderCancelled struct {
+	OrderId string
+}
+
+func newMessageEvent[T any](msg T) pubsub.MessageEvent[T] {
+	return pubsub.MessageEvent[T]{
+		AbstractEvent: event.NewAbstractEvent(context.Background(), reflect.TypeOf(msg).Name()),
+		PayloadData:   msg,
+	}
+}
+
+func TestRecorder_AwaitEvent_ShouldReturnWhenEventIsRecorded(t *testing.T) {
+	recorder := NewRecorder()
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		recorder.Record(newMessageEvent(OrderCreated{OrderId: "1"}))
+		recorder.Record(newMessageEvent(OrderCancelled{OrderId: "1"}))
+	}()
+	e := recorder.AwaitEvent(t, EventNamed("OrderCancelled"), time.Second)
+	assert.Equal(t, OrderCancelled{OrderId: "1"}, e.Payload())
+
+	e = recorder.AwaitEvent(t, EventOf[pubsub.MessageEvent[OrderCreated]](), time.Second)
+	assert.Equal(t, OrderCreated{OrderId: "1"}, e.Payload())
+}
+
+func TestRecorder_WhenAwaitEventTimeout_ShouldFail(t *testing.T) {
+	recorder := NewRecorder()
+	mockT := &testing.T{}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		recorder.AwaitEvent(mockT, EventNamed("OrderCreated"), 10*time.Millisecond)
+	}()
+	<-done
+	assert.True(t, mockT.Failed())
+}
+
+func TestRecorder_EventsOfAndAssertOrder(t *testing.T) {
+	recorder := NewRecorder()
+	recorder.Record(newMessageEvent(OrderCreated{OrderId: "1"}))
+	recorder.Record(newMessageEvent(OrderCancelled{OrderId: "1"}))
+	recorder.Record(newMessageEvent(OrderCreated{OrderId: "2"}))
+
+	created := EventsOf[pubsub.MessageEvent[OrderCreated]](recorder)
+	assert.Len(t, created, 2)
+	assert.Equal(t, "1", created[0].PayloadData.OrderId)
+	assert.Equal(t, "2", created[1].PayloadData.OrderId)
+	assert.Len(t, EventsOf[pubsub.Event](recorder), 3)
+
+	recorder.AssertOrder(t, "OrderCreated", "OrderCancelled")
+	recorder.AssertOrder(t, "OrderCreated", "OrderCreated")
+	mockT := &testing.T{}
+	recorder.AssertOrder(mockT, "OrderCancelled", "OrderCancelled")
+	assert.True(t, mockT.Failed())
+
+	recorder.Reset()
+	assert.Empty(t, recorder.Events())
+}
+
+func TestSwapGlobal_ShouldRecordGlobalPublishing(t *testing.T) {
+	original := pubsub.GetPublisher()
+	t.Run("swap", func(t *testing.T) {
+		publisher := SwapGlobal(t)
+		pubsub.PublishEvent(context.Background(), OrderCreated{OrderId: "1"})
+		assert.NoError(t, pubsub.PublishCtx(context.Background(), newMessageEvent(OrderCancelled{OrderId: "1"})))
+		publisher.AssertOrder(t, "OrderCreated", "OrderCancelled")
+	})
+	assert.Equal(t, original, pubsub.GetPublisher())
+}
+
+func TestBus_ShouldRecordDeliveredEvents(t *testing.T) {
+	bus := NewBus()
+	handled := make(chan OrderCreated, 1)
+	_, err := pubsub.Subscribe(bus, func(ctx context.Context, msg OrderCreated) error {
+		handled <- msg
+		return nil
+	})
+	assert.NoError(t, err)
+	bus.Run()
+	defer bus.Stop()
+
+	pub := pubsub.NewDefaultPublisher(bus)
+	pub.Publish(newMessageEvent(OrderCreated{OrderId: "1"}))
+	bus.AwaitEvent(t, EventNamed("OrderCreated"), time.Second)
+	assert.Equal(t, OrderCreated{OrderId: "1"}, <-handled)
+}
+
+func TestRecorderOpt_ShouldDecoratePublisher(t *testing.T) {
+	bus, publisher := pubsub.GetEventBus(), pubsub.GetPublisher()
+	defer pubsub.ReplaceGlobal(bus, publisher)
+
+	var recorder *Recorder
+	app := fx.New(
+		fx.NopLogger,
+		fx.Provide(func() pubsub.EventBus { return NewBus() }),
+		fx.Provide(func(bus pubsub.EventBus) pubsub.Publisher { return pubsub.NewDefaultPublisher(bus) }),
+		RecorderOpt(),
+		fx.Invoke(func(bus pubsub.EventBus, publisher pubsub.Publisher) {
+			pubsub.ReplaceGlobal(bus, publisher)
+			bus.Run()
+		}),
+		fx.Populate(&recorder),
+	)
+	assert.NoError(t, app.Err())
+	defer pubsub.GetEventBus().Stop()
+
+	pubsub.PublishEvent(context.Background(), OrderCreated{OrderId: "1"})
+	recorder.AwaitEvent(t, EventNamed("OrderCreated"), time.Second)
+	pubsub.GetEventBus().(*Bus).AwaitEvent(t, EventNamed("OrderCreated"), time.Second)
+}
+
+func TestRecorderOpt_ShouldForwardScheduledEvents(t *testing.T) {
+	bus, publisher := pubsub.GetEventBus(), pubsub.GetPublisher()
+	defer pubsub.ReplaceGlobal(bus, publisher)
+
+	clock := NewFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	scheduler := pubsub.NewEventScheduler(pubsub.WithSchedulerClock(clock))
+	var recorder *Recorder
+	app := fx.New(
+		fx.NopLogger,
+		fx.Provide(func() pubsub.EventBus { return NewBus() }),
+		fx.Provide(func(bus pubsub.EventBus) pubsub.Publisher {
+			return pubsub.NewDefaultPublisher(bus, pubsub.WithScheduler(scheduler))
+		}),
+		RecorderOpt(),
+		fx.Invoke(func(bus pubsub.EventBus, publisher pubsub.Publisher) error {
+			pubsub.ReplaceGlobal(bus, publisher)
+			bus.Run()
+			return scheduler.Start(publisher.(pubsub.ContextPublisher).PublishCtx)
+		}),
+		fx.Populate(&recorder),
+	)
+	assert.NoError(t, app.Err())
+	defer pubsub.GetEventBus().Stop()
+	defer scheduler.Stop()
+
+	_, err := pubsub.PublishAfter(time.Minute, newMessageEvent(OrderCancelled{OrderId: "1"}))
+	assert.NoError(t, err)
+	assert.Empty(t, recorder.Events())
+	clock.Advance(time.Minute)
+	recorder.AwaitEvent(t, EventNamed("OrderCancelled"), time.Second)
+	pubsub.GetEventBus().(*Bus).AwaitEvent(t, EventNamed("OrderCancelled"), time.Second)
+}
+
+func TestPublisher_WhenDelegateCannotSchedule_ShouldReturnError(t *testing.T) {
+	publisher := NewPublisher(NewRecorder(), nil)
+	_, err := publisher.PublishAfter(time.Minute, newMessageEvent(OrderCancelled{OrderId: "1"}))
+	assert.ErrorIs(t, err, pubsub.ErrSchedulingNotSupported)
+	assert.Empty(t, publisher.Events())
+}
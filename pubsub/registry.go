@@ -0,0 +1,137 @@
+package pubsub
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+
+	baseEvent "github.com/golibs-starter/golib/web/event"
+)
+
+// EventTypeRegistry maps event names to Go types,
+// so that encoded events can be decoded back to their concrete types.
+type EventTypeRegistry struct {
+	types   map[string]reflect.Type
+	mu      sync.RWMutex
+	schemas schemas
+}
+
+func NewEventTypeRegistry() *EventTypeRegistry {
+	return &EventTypeRegistry{
+		types:   make(map[string]reflect.Type),
+		schemas: schemas{items: make(map[string]*registeredSchema)},
+	}
+}
+
+// Register maps an event name to the type of prototype, such as &OrderCreatedEvent{}.
+// Registering the same name with another type returns an error.
+func (r *EventTypeRegistry) Register(name string, prototype Event) error {
+	eventType := reflect.TypeOf(prototype)
+	r.mu.RLock()
+	registeredType, exists := r.types[name]
+	r.mu.RUnlock()
+	if exists && registeredType == eventType {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if registeredType, exists := r.types[name]; exists && registeredType != eventType {
+		return fmt.Errorf("event [%s] is already registered with type [%s]", name, registeredType)
+	}
+	r.types[name] = eventType
+	return nil
+}
+
+// IsRegistered returns whether an event name is registered
+func (r *EventTypeRegistry) IsRegistered(name string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	_, exists := r.types[name]
+	return exists
+}
+
+// Names returns all registered event names, sorted
+func (r *EventTypeRegistry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	names := make([]string, 0, len(r.types))
+	for name := range r.types {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Encode an event to its JSON shape, such as the shape of event.ApplicationEvent
+func (r *EventTypeRegistry) Encode(event Event) ([]byte, error) {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return nil, fmt.Errorf("cannot encode event [%s]: %w", event.Name(), err)
+	}
+	return data, nil
+}
+
+// Decode an event, the name is read from the `event` field of the data (see event.ApplicationEvent).
+func (r *EventTypeRegistry) Decode(ctx context.Context, data []byte) (Event, error) {
+	var header struct {
+		Event string `json:"event"`
+	}
+	if err := json.Unmarshal(data, &header); err != nil {
+		return nil, fmt.Errorf("cannot decode event: %w", err)
+	}
+	return r.DecodeNamed(ctx, header.Event, data)
+}
+
+// DecodeNamed decodes an event to the type that registered with the name,
+// an unregistered event is decoded as *event.AbstractEvent with a generic JSON payload.
+// When the event has a schema, a payload of an older version is converted
+// to the current version by the registered upcasters (see RegisterUpcaster).
+// Attributes of the event (such as request id, user id, device id) are restored
+// into a context derived from ctx, which becomes the context of the event.
+func (r *EventTypeRegistry) DecodeNamed(ctx context.Context, name string, data []byte) (Event, error) {
+	data, err := r.upcast(name, data)
+	if err != nil {
+		return nil, err
+	}
+	r.mu.RLock()
+	eventType, exists := r.types[name]
+	r.mu.RUnlock()
+	if !exists {
+		eventType = reflect.TypeOf(&baseEvent.AbstractEvent{})
+	}
+	var value reflect.Value
+	if eventType.Kind() == reflect.Ptr {
+		value = reflect.New(eventType.Elem())
+	} else {
+		value = reflect.New(eventType)
+	}
+	if err := json.Unmarshal(data, value.Interface()); err != nil {
+		return nil, fmt.Errorf("cannot decode event [%s]: %w", name, err)
+	}
+	if eventType.Kind() != reflect.Ptr {
+		value = value.Elem()
+	}
+	event, ok := value.Interface().(Event)
+	if !ok {
+		return nil, fmt.Errorf("type [%s] of event [%s] is not an Event", eventType, name)
+	}
+	if wrapper, ok := event.(baseEvent.AbstractEventWrapper); ok {
+		if abstractEvent := wrapper.GetAbstractEvent(); abstractEvent != nil && abstractEvent.ApplicationEvent != nil {
+			abstractEvent.Ctx = baseEvent.NewContext(ctx, abstractEvent)
+		}
+	}
+	return event, nil
+}
+
+// RegisterMessage registers MessageEvent[T] with the name of T,
+// which is the name of events published by PublishEvent.
+func RegisterMessage[T any](registry *EventTypeRegistry) error {
+	msgType := reflect.TypeOf((*T)(nil)).Elem()
+	if msgType.Name() == "" {
+		return fmt.Errorf("message type [%s] must be a named type", msgType)
+	}
+	return registry.Register(msgType.Name(), MessageEvent[T]{})
+}
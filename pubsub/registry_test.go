@@ -0,0 +1,90 @@
+package pubsub
+
+import (
+	"context"
+	"github.com/golibs-starter/golib/web/constant"
+	webContext "github.com/golibs-starter/golib/web/context"
+	"github.com/golibs-starter/golib/web/event"
+	assert "github.com/stretchr/testify/require"
+	"testing"
+)
+
+type OrderPaid struct {
+	OrderId string `json:"order_id"`
+	Amount  int64  `json:"amount"`
+}
+
+func newRequestContext() context.Context {
+	return context.WithValue(context.Background(), constant.ContextReqAttribute, &webContext.RequestAttributes{
+		CorrelationId: "request-1",
+		DeviceId:      "device-1",
+		SecurityAttributes: webContext.SecurityAttributes{
+			UserId: "user-1",
+		},
+	})
+}
+
+func TestEventTypeRegistry_WhenRegisterConflictedType_ShouldReturnError(t *testing.T) {
+	registry := NewEventTypeRegistry()
+	assert.NoError(t, RegisterMessage[OrderPaid](registry))
+	assert.NoError(t, RegisterMessage[OrderPaid](registry))
+	assert.Error(t, registry.Register("OrderPaid", &event.RequestCompletedEvent{}))
+	assert.Error(t, RegisterMessage[map[string]string](registry))
+	assert.True(t, registry.IsRegistered("OrderPaid"))
+	assert.Equal(t, []string{"OrderPaid"}, registry.Names())
+}
+
+func TestEventTypeRegistry_ShouldRoundTripMessageEvent(t *testing.T) {
+	registry := NewEventTypeRegistry()
+	assert.NoError(t, RegisterMessage[OrderPaid](registry))
+	original := MessageEvent[OrderPaid]{
+		AbstractEvent: event.NewAbstractEvent(newRequestContext(), "OrderPaid"),
+		PayloadData:   OrderPaid{OrderId: "1", Amount: 100},
+	}
+	data, err := registry.Encode(original)
+	assert.NoError(t, err)
+
+	decoded, err := registry.Decode(context.Background(), data)
+	assert.NoError(t, err)
+	assert.IsType(t, MessageEvent[OrderPaid]{}, decoded)
+	decodedEvent := decoded.(MessageEvent[OrderPaid])
+	assert.Equal(t, original.PayloadData, decodedEvent.PayloadData)
+	assert.Equal(t, original.Identifier(), decodedEvent.Identifier())
+	assert.Equal(t, original.Timestamp, decodedEvent.Timestamp)
+
+	attrs := event.GetAttributes(decoded.Context())
+	assert.NotNil(t, attrs)
+	assert.Equal(t, "request-1", attrs.CorrelationId)
+	assert.Equal(t, "user-1", attrs.UserId)
+	assert.Equal(t, "device-1", attrs.DeviceId)
+}
+
+func TestEventTypeRegistry_ShouldDecodeRegisteredPointerTypeAndFallback(t *testing.T) {
+	registry := NewEventTypeRegistry()
+	assert.NoError(t, registry.Register("RequestCompletedEvent", &event.RequestCompletedEvent{}))
+	original := event.NewRequestCompletedEvent(newRequestContext(), &event.RequestCompletedMessage{Status: 200})
+	data, err := registry.Encode(original)
+	assert.NoError(t, err)
+
+	decoded, err := registry.DecodeNamed(context.Background(), "RequestCompletedEvent", data)
+	assert.NoError(t, err)
+	assert.IsType(t, &event.RequestCompletedEvent{}, decoded)
+	assert.Equal(t, "request-1", event.GetAttributes(decoded.Context()).CorrelationId)
+
+	decoded, err = registry.DecodeNamed(context.Background(), "UnknownEvent", data)
+	assert.NoError(t, err)
+	assert.IsType(t, &event.AbstractEvent{}, decoded)
+	assert.Equal(t, "user-1", event.GetAttributes(decoded.Context()).UserId)
+
+	_, err = registry.Decode(context.Background(), []byte("not json"))
+	assert.Error(t, err)
+}
+
+func TestSubscribe_ShouldRegisterMessageTypeToGlobalRegistry(t *testing.T) {
+	_, err := Subscribe(NewDefaultEventBus(), func(ctx context.Context, msg OrderPaid) error {
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.True(t, GetEventTypeRegistry().IsRegistered("OrderPaid"))
+	assert.True(t, GetEventTypeRegistry().IsRegistered("RequestCompletedEvent"))
+}
@@ -0,0 +1,290 @@
+package pubsub
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"runtime"
+	"sync"
+
+	"github.com/golibs-starter/golib/web/event"
+)
+
+var (
+	ErrNoResponder        = errors.New("no responder is registered")
+	ErrMultipleResponders = errors.New("multiple responders are registered")
+	ErrRequestTimeout     = errors.New("request is timeout")
+	ErrRequestDisabled    = errors.New("request is disabled")
+)
+
+// requestTimeoutError is ErrRequestTimeout that caused by the context error,
+// such as context.DeadlineExceeded.
+type requestTimeoutError struct {
+	query string
+	cause error
+}
+
+func (e *requestTimeoutError) Error() string {
+	return fmt.Sprintf("request [%s] is timeout: %v", e.query, e.cause)
+}
+
+func (e *requestTimeoutError) Is(target error) bool {
+	return target == ErrRequestTimeout
+}
+
+func (e *requestTimeoutError) Unwrap() error {
+	return e.cause
+}
+
+type replyContextKey struct{}
+
+// replySlot receives the first reply of a request.
+// Events that are published from the context of the request inherit the slot,
+// so the slot only accepts the reply of the event with its event id.
+type replySlot struct {
+	eventId string
+	once    sync.Once
+	done    chan struct{}
+	reply   interface{}
+	err     error
+}
+
+func (s *replySlot) set(reply interface{}, err error) {
+	s.once.Do(func() {
+		s.reply, s.err = reply, err
+		close(s.done)
+	})
+}
+
+// requestResponder is implemented by subscribers that registered by Respond
+type requestResponder interface {
+	requestTypes() (queryType reflect.Type, replyType reflect.Type)
+}
+
+// busSubscriptions is implemented by DefaultEventBus and buses that embed it
+type busSubscriptions interface {
+	subscriptions() []*subscription
+}
+
+type responder[Q any, R any] struct {
+	id string
+	fn func(ctx context.Context, query Q) (R, error)
+}
+
+func (r *responder[Q, R]) SubscriberId() string {
+	return r.id
+}
+
+func (r *responder[Q, R]) generatedId() {}
+
+func (r *responder[Q, R]) SupportedEvents() []string {
+	return []string{typeName[Q]()}
+}
+
+func (r *responder[Q, R]) Supports(event Event) bool {
+	if _, ok := event.Payload().(Q); !ok {
+		return false
+	}
+	return replySlotOf(event) != nil
+}
+
+func (r *responder[Q, R]) Handle(event Event) {
+	_ = r.HandleWithError(eventContext(event), event)
+}
+
+// HandleWithError replies the result of the responder function, an error of the function
+// is replied to the requester instead of being reported to the bus.
+func (r *responder[Q, R]) HandleWithError(ctx context.Context, event Event) error {
+	slot := replySlotOf(event)
+	if slot == nil {
+		return fmt.Errorf("event [%s] with id [%s] is not a request", event.Name(), event.Identifier())
+	}
+	query, _ := event.Payload().(Q)
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			slot.set(nil, &PanicError{Value: recovered})
+			panic(recovered)
+		}
+	}()
+	reply, err := r.fn(ctx, query)
+	slot.set(reply, err)
+	return nil
+}
+
+func (r *responder[Q, R]) requestTypes() (reflect.Type, reflect.Type) {
+	return reflect.TypeOf((*Q)(nil)).Elem(), reflect.TypeOf((*R)(nil)).Elem()
+}
+
+// Respond registers the responder of requests with query type Q,
+// only one responder can be registered per query type on a bus,
+// the bus rejects another responder with ErrMultipleResponders.
+func Respond[Q any, R any](bus EventBus, fn func(ctx context.Context, query Q) (R, error)) (Subscription, error) {
+	if fn == nil {
+		return nil, errors.New("responder must not be nil")
+	}
+	if err := RegisterMessage[Q](_registry); err != nil {
+		return nil, err
+	}
+	subscribingBus, ok := bus.(SubscribingBus)
+	if _, hasSubscriptions := bus.(busSubscriptions); !ok || !hasSubscriptions {
+		return nil, fmt.Errorf("EventBus [%T] does not support request/reply", bus)
+	}
+	queryType := reflect.TypeOf((*Q)(nil)).Elem()
+	return subscribingBus.Subscribe(&responder[Q, R]{
+		id: fmt.Sprintf("responder[%s](%s)", queryType, runtime.FuncForPC(reflect.ValueOf(fn).Pointer()).Name()),
+		fn: fn,
+	})
+}
+
+// Request sends a query by the global publisher and waits for the reply of its responder, see RequestVia.
+func Request[Q any, R any](ctx context.Context, query Q) (R, error) {
+	return RequestVia[Q, R](ctx, _publisher, query)
+}
+
+// RequestVia sends a query as a MessageEvent[Q] by the publisher, like other published events,
+// the query goes through interceptors, schema validation and routes of the publisher.
+// The responder is looked up on the bus that the query is routed to (see WithEventRoute),
+// or on the global bus when the publisher is not a DefaultPublisher. See RequestOn for returned errors.
+func RequestVia[Q any, R any](ctx context.Context, publisher Publisher, query Q) (R, error) {
+	return request[Q, R](ctx, query, func(event Event) EventBus {
+		if router, ok := publisher.(eventRouter); ok {
+			return router.busOf(event)
+		}
+		return _bus
+	}, func(ctx context.Context, event Event) error {
+		return PublishCtxOn(ctx, publisher, event)
+	})
+}
+
+// RequestOn sends a query as a MessageEvent[Q] on the bus and waits for the reply of
+// the responder that registered by Respond. It returns:
+//   - ErrNoResponder or ErrMultipleResponders when there isn't exactly one responder
+//   - ErrRequestTimeout when the context is done before the reply
+//   - ErrRequestDisabled when the query event is disabled on the bus (see SetDisabledEvents)
+//   - the error of the responder.
+//
+// The query is delivered to the bus directly, it skips interceptors, schema validation
+// and routes of publishers, use RequestVia to send it by a publisher.
+//
+// Attributes of ctx (such as request id) are kept in the context of the responder,
+// the id of the request is used as the request id when ctx does not have one.
+func RequestOn[Q any, R any](ctx context.Context, bus EventBus, query Q) (R, error) {
+	return request[Q, R](ctx, query, func(event Event) EventBus {
+		return bus
+	}, func(ctx context.Context, event Event) error {
+		return TryDeliverOn(ctx, bus, event)
+	})
+}
+
+// request sends a query by the send function, the responder is looked up on the bus of the query
+func request[Q any, R any](ctx context.Context, query Q, busOf func(event Event) EventBus, send PublishFunc) (R, error) {
+	var zero R
+	abstractEvent := event.NewAbstractEvent(ctx, typeName[Q]())
+	slot := &replySlot{eventId: abstractEvent.Id, done: make(chan struct{})}
+	if abstractEvent.RequestId == "" {
+		abstractEvent.RequestId = abstractEvent.Id
+	}
+	abstractEvent.Ctx = event.NewContext(ctx, abstractEvent)
+	abstractEvent.Ctx = context.WithValue(abstractEvent.Ctx, replyContextKey{}, slot)
+	queryEvent := MessageEvent[Q]{AbstractEvent: abstractEvent, PayloadData: query}
+
+	bus := busOf(queryEvent)
+	queryType := reflect.TypeOf((*Q)(nil)).Elem()
+	responders, err := respondersOf(bus, queryType)
+	if err != nil {
+		return zero, err
+	}
+	if len(responders) == 0 {
+		return zero, fmt.Errorf("%w for request [%s]", ErrNoResponder, queryType)
+	}
+	if len(responders) > 1 {
+		return zero, fmt.Errorf("%w for request [%s]", ErrMultipleResponders, queryType)
+	}
+	if _, replyType := responders[0].requestTypes(); replyType != reflect.TypeOf((*R)(nil)).Elem() {
+		return zero, fmt.Errorf("responder of request [%s] replies [%s], but [%s] is expected",
+			queryType, replyType, reflect.TypeOf((*R)(nil)).Elem())
+	}
+	if !bus.IsRunning() {
+		return zero, ErrBusNotRunning
+	}
+	if err := send(ctx, queryEvent); err != nil {
+		return zero, err
+	}
+	select {
+	case <-slot.done:
+		if slot.err != nil {
+			return zero, slot.err
+		}
+		reply, _ := slot.reply.(R)
+		return reply, nil
+	case <-ctx.Done():
+		return zero, &requestTimeoutError{query: queryType.String(), cause: ctx.Err()}
+	}
+}
+
+// rejectRequest replies the error to the requester when the event is a request that is not handled,
+// it's no-op for other events.
+func rejectRequest(event Event, err error) {
+	if slot := replySlotOf(event); slot != nil {
+		slot.set(nil, fmt.Errorf("%w: event [%s] with id [%s]", err, event.Name(), event.Identifier()))
+	}
+}
+
+// replySlotOf returns the reply slot of a request event,
+// it returns nil when the event is not a request.
+func replySlotOf(event Event) *replySlot {
+	slot, ok := eventContext(event).Value(replyContextKey{}).(*replySlot)
+	if !ok || slot.eventId != event.Identifier() {
+		return nil
+	}
+	return slot
+}
+
+func hasResponder(subs []*subscription) bool {
+	for _, sub := range subs {
+		if _, ok := sub.subscriber.(requestResponder); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// conflictingResponder returns the registered responder that has the same query type
+// as the subscriber, the caller must hold the lock of subscriptions.
+func (b *DefaultEventBus) conflictingResponder(subscriber Subscriber) *subscription {
+	r, ok := subscriber.(requestResponder)
+	if !ok {
+		return nil
+	}
+	queryType, _ := r.requestTypes()
+	for _, sub := range b.subscribers {
+		if existing, ok := sub.subscriber.(requestResponder); ok {
+			if existingQueryType, _ := existing.requestTypes(); existingQueryType == queryType {
+				return sub
+			}
+		}
+	}
+	return nil
+}
+
+// respondersOf returns responders of the query type that subscribed to the bus
+func respondersOf(bus EventBus, queryType reflect.Type) ([]requestResponder, error) {
+	subscriptionsBus, ok := bus.(busSubscriptions)
+	if !ok {
+		return nil, fmt.Errorf("EventBus [%T] does not support request/reply", bus)
+	}
+	responders := make([]requestResponder, 0, 1)
+	for _, sub := range subscriptionsBus.subscriptions() {
+		if r, ok := sub.subscriber.(requestResponder); ok {
+			if subQueryType, _ := r.requestTypes(); subQueryType == queryType {
+				responders = append(responders, r)
+			}
+		}
+	}
+	return responders, nil
+}
+
+func typeName[T any]() string {
+	return reflect.TypeOf((*T)(nil)).Elem().Name()
+}
@@ -0,0 +1,207 @@
+package pubsub
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golibs-starter/golib/pubsub/executor"
+	"github.com/golibs-starter/golib/web/event"
+	assert "github.com/stretchr/testify/require"
+)
+
+type ResolvePricing struct {
+	CartId string
+}
+
+type Pricing struct {
+	CartId string
+	Total  int64
+}
+
+func newRequestBus(t *testing.T) *DefaultEventBus {
+	bus := NewDefaultEventBus(
+		WithEventExecutor(executor.NewAsyncExecutor()),
+		WithEventErrorHandler(func(event Event, subscriberId string, err error, stack []byte) {}),
+	)
+	bus.Run()
+	t.Cleanup(bus.Stop)
+	return bus
+}
+
+func TestRequestOn_ShouldReturnReplyOfResponder(t *testing.T) {
+	bus := newRequestBus(t)
+	_, err := Respond(bus, func(ctx context.Context, query ResolvePricing) (Pricing, error) {
+		// Attributes of the requester are kept in the context of the responder
+		assert.Equal(t, "request-1", event.GetAttributes(ctx).CorrelationId)
+		if query.CartId == "" {
+			return Pricing{}, errors.New("cart id is required")
+		}
+		return Pricing{CartId: query.CartId, Total: 100}, nil
+	})
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(newRequestContext(), time.Second)
+	defer cancel()
+	reply, err := RequestOn[ResolvePricing, Pricing](ctx, bus, ResolvePricing{CartId: "cart-1"})
+	assert.NoError(t, err)
+	assert.Equal(t, Pricing{CartId: "cart-1", Total: 100}, reply)
+
+	_, err = RequestOn[ResolvePricing, Pricing](ctx, bus, ResolvePricing{})
+	assert.EqualError(t, err, "cart id is required")
+
+	_, err = RequestOn[ResolvePricing, *Pricing](ctx, bus, ResolvePricing{CartId: "cart-1"})
+	assert.Error(t, err)
+}
+
+func TestRequestVia_ShouldSendQueryThroughPublisher(t *testing.T) {
+	defaultBus, pricingBus := newRequestBus(t), newRequestBus(t)
+	_, err := Respond(pricingBus, func(ctx context.Context, query ResolvePricing) (Pricing, error) {
+		return Pricing{CartId: query.CartId, Total: 100}, nil
+	})
+	assert.NoError(t, err)
+	var intercepted []string
+	publisher := NewDefaultPublisher(defaultBus,
+		WithEventRoute(pricingBus, "ResolvePricing"),
+		WithPublishInterceptors(func(ctx context.Context, event Event, next PublishFunc) error {
+			intercepted = append(intercepted, event.Name())
+			return next(ctx, event)
+		}),
+	)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	reply, err := RequestVia[ResolvePricing, Pricing](ctx, publisher, ResolvePricing{CartId: "cart-1"})
+	assert.NoError(t, err)
+	assert.Equal(t, Pricing{CartId: "cart-1", Total: 100}, reply)
+	assert.Equal(t, []string{"ResolvePricing"}, intercepted)
+
+	// The responder is looked up on the bus of the route
+	_, err = RequestOn[ResolvePricing, Pricing](ctx, defaultBus, ResolvePricing{CartId: "cart-1"})
+	assert.ErrorIs(t, err, ErrNoResponder)
+}
+
+func TestRequestOn_WhenNotExactlyOneResponder_ShouldReturnError(t *testing.T) {
+	bus := newRequestBus(t)
+	_, err := RequestOn[ResolvePricing, Pricing](context.Background(), bus, ResolvePricing{CartId: "cart-1"})
+	assert.ErrorIs(t, err, ErrNoResponder)
+
+	fn := func(ctx context.Context, query ResolvePricing) (Pricing, error) {
+		return Pricing{}, nil
+	}
+	subscription, err := Respond(bus, fn)
+	assert.NoError(t, err)
+	_, err = Respond(bus, fn)
+	assert.ErrorIs(t, err, ErrMultipleResponders)
+
+	// The bus rejects another responder that bypasses Respond
+	_, err = bus.Subscribe(&responder[ResolvePricing, Pricing]{id: "another-responder", fn: fn})
+	assert.ErrorIs(t, err, ErrMultipleResponders)
+	_, err = RequestOn[ResolvePricing, Pricing](context.Background(), bus, ResolvePricing{CartId: "cart-1"})
+	assert.NoError(t, err)
+
+	subscription.Unsubscribe()
+	_, err = RequestOn[ResolvePricing, Pricing](context.Background(), bus, ResolvePricing{CartId: "cart-1"})
+	assert.ErrorIs(t, err, ErrNoResponder)
+}
+
+func TestRespond_WhenRespondConcurrently_ShouldRegisterOnlyOneResponder(t *testing.T) {
+	bus := newRequestBus(t)
+	errs := make(chan error, 10)
+	for i := 0; i < 10; i++ {
+		go func() {
+			_, err := Respond(bus, func(ctx context.Context, query ResolvePricing) (Pricing, error) {
+				return Pricing{}, nil
+			})
+			errs <- err
+		}()
+	}
+	registered := 0
+	for i := 0; i < 10; i++ {
+		if err := <-errs; err == nil {
+			registered++
+		} else {
+			assert.ErrorIs(t, err, ErrMultipleResponders)
+		}
+	}
+	assert.Equal(t, 1, registered)
+	assert.Len(t, bus.Subscribers(), 1)
+}
+
+func TestRequestOn_WhenRequestIsNotHandled_ShouldReturnError(t *testing.T) {
+	bus := newRequestBus(t)
+	_, err := Respond(bus, func(ctx context.Context, query ResolvePricing) (Pricing, error) {
+		return Pricing{}, nil
+	})
+	assert.NoError(t, err)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	bus.SetDisabledEvents("ResolvePricing")
+	_, err = RequestOn[ResolvePricing, Pricing](ctx, bus, ResolvePricing{CartId: "cart-1"})
+	assert.ErrorIs(t, err, ErrRequestDisabled)
+
+	bus.SetDisabledEvents()
+	bus.SetDisabledSubscribers("responder*")
+	_, err = RequestOn[ResolvePricing, Pricing](ctx, bus, ResolvePricing{CartId: "cart-1"})
+	assert.ErrorIs(t, err, ErrNoResponder)
+	assert.NoError(t, ctx.Err())
+}
+
+func TestRequestOn_WhenResponderPublishesEvent_ShouldNotReplyByFollowUpEvent(t *testing.T) {
+	bus := newRequestBus(t)
+	audited := make(chan struct{})
+	_, err := bus.RegisterHandler("PricingAudited", func(ctx context.Context, e Event) {
+		close(audited)
+	})
+	assert.NoError(t, err)
+	_, err = Respond(bus, func(ctx context.Context, query ResolvePricing) (Pricing, error) {
+		// The follow-up event inherits the context of the request, but it has no responder
+		followUp := &DummyEvent{name: "PricingAudited", ctx: ctx}
+		if err := bus.TryDeliver(ctx, followUp); err != nil {
+			return Pricing{}, err
+		}
+		<-audited
+		return Pricing{CartId: query.CartId, Total: 42}, nil
+	})
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	reply, err := RequestOn[ResolvePricing, Pricing](ctx, bus, ResolvePricing{CartId: "cart-1"})
+	assert.NoError(t, err)
+	assert.Equal(t, Pricing{CartId: "cart-1", Total: 42}, reply)
+}
+
+func TestRequestOn_WhenContextIsDone_ShouldReturnTimeoutError(t *testing.T) {
+	bus := newRequestBus(t)
+	release := make(chan struct{})
+	defer close(release)
+	_, err := Respond(bus, func(ctx context.Context, query ResolvePricing) (Pricing, error) {
+		<-release
+		return Pricing{}, nil
+	})
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err = RequestOn[ResolvePricing, Pricing](ctx, bus, ResolvePricing{CartId: "cart-1"})
+	assert.ErrorIs(t, err, ErrRequestTimeout)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestRequestOn_WhenResponderPanics_ShouldReturnPanicError(t *testing.T) {
+	bus := newRequestBus(t)
+	_, err := Respond(bus, func(ctx context.Context, query ResolvePricing) (Pricing, error) {
+		panic("pricing is broken")
+	})
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	_, err = RequestOn[ResolvePricing, Pricing](ctx, bus, ResolvePricing{CartId: "cart-1"})
+	var panicErr *PanicError
+	assert.ErrorAs(t, err, &panicErr)
+	assert.Equal(t, "pricing is broken", panicErr.Value)
+}
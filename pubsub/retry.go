@@ -0,0 +1,103 @@
+package pubsub
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+	"time"
+)
+
+// RetryPolicy defines how a failed handling is retried.
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of attempts, including the first one.
+	// Zero or one means no retry.
+	MaxAttempts int
+
+	// InitialInterval is the backoff before the first retry.
+	InitialInterval time.Duration
+
+	// MaxInterval caps the backoff between two attempts. Zero means no limit.
+	MaxInterval time.Duration
+
+	// Multiplier is the factor that the backoff is multiplied by after each retry.
+	// Values less than 1 are treated as 1 (constant backoff).
+	Multiplier float64
+
+	// Jitter randomizes the backoff in range [backoff*(1-Jitter), backoff*(1+Jitter)].
+	// Zero means no randomization.
+	Jitter float64
+
+	// Retryable classifies whether an error can be retried.
+	// When it is nil, all errors are retryable except
+	// panics and errors marked by NonRetryable.
+	Retryable func(err error) bool
+}
+
+// RetryableSubscriber is an optional interface for a Subscriber
+// to declare its own RetryPolicy, it takes precedence over configured policies.
+type RetryableSubscriber interface {
+	Subscriber
+
+	// RetryPolicy returns the retry policy for this subscriber
+	RetryPolicy() *RetryPolicy
+}
+
+// Backoff returns the waiting duration before the given retry (start from 1).
+func (p RetryPolicy) Backoff(retry int) time.Duration {
+	multiplier := p.Multiplier
+	if multiplier < 1 {
+		multiplier = 1
+	}
+	backoff := float64(p.InitialInterval) * math.Pow(multiplier, float64(retry-1))
+	if p.MaxInterval > 0 && backoff > float64(p.MaxInterval) {
+		backoff = float64(p.MaxInterval)
+	}
+	if p.Jitter > 0 {
+		jitter := math.Min(p.Jitter, 1)
+		backoff = backoff * (1 - jitter + 2*jitter*rand.Float64())
+	}
+	return time.Duration(backoff)
+}
+
+// ShouldRetry returns whether the handling should be retried
+// after the given attempt (start from 1) was failed by err.
+func (p RetryPolicy) ShouldRetry(attempt int, err error) bool {
+	if attempt >= p.MaxAttempts {
+		return false
+	}
+	if p.Retryable != nil {
+		return p.Retryable(err)
+	}
+	return IsRetryable(err)
+}
+
+// NonRetryable marks an error as not retryable by the default classification.
+func NonRetryable(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &nonRetryableError{err: err}
+}
+
+// IsRetryable is the default retryable classification,
+// panics and errors marked by NonRetryable are not retryable.
+func IsRetryable(err error) bool {
+	var nonRetryableErr *nonRetryableError
+	if errors.As(err, &nonRetryableErr) {
+		return false
+	}
+	var panicErr *PanicError
+	return !errors.As(err, &panicErr)
+}
+
+type nonRetryableError struct {
+	err error
+}
+
+func (e *nonRetryableError) Error() string {
+	return e.err.Error()
+}
+
+func (e *nonRetryableError) Unwrap() error {
+	return e.err
+}
@@ -0,0 +1,66 @@
+package pubsub
+
+import (
+	"errors"
+	"fmt"
+	assert "github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestRetryPolicy_Backoff_ShouldIncreaseExponentiallyAndCapByMaxInterval(t *testing.T) {
+	policy := RetryPolicy{
+		InitialInterval: 100 * time.Millisecond,
+		MaxInterval:     time.Second,
+		Multiplier:      2,
+	}
+	assert.Equal(t, 100*time.Millisecond, policy.Backoff(1))
+	assert.Equal(t, 200*time.Millisecond, policy.Backoff(2))
+	assert.Equal(t, 400*time.Millisecond, policy.Backoff(3))
+	assert.Equal(t, 800*time.Millisecond, policy.Backoff(4))
+	assert.Equal(t, time.Second, policy.Backoff(5))
+}
+
+func TestRetryPolicy_Backoff_WhenJitterIsSet_ShouldRandomizeInRange(t *testing.T) {
+	policy := RetryPolicy{
+		InitialInterval: 100 * time.Millisecond,
+		Multiplier:      1,
+		Jitter:          0.5,
+	}
+	for i := 0; i < 100; i++ {
+		backoff := policy.Backoff(1)
+		assert.GreaterOrEqual(t, backoff, 50*time.Millisecond)
+		assert.LessOrEqual(t, backoff, 150*time.Millisecond)
+	}
+}
+
+func TestRetryPolicy_ShouldRetry(t *testing.T) {
+	policy := RetryPolicy{MaxAttempts: 3}
+	assert.True(t, policy.ShouldRetry(1, errors.New("transient")))
+	assert.True(t, policy.ShouldRetry(2, errors.New("transient")))
+	assert.False(t, policy.ShouldRetry(3, errors.New("transient")))
+	assert.False(t, policy.ShouldRetry(1, NonRetryable(errors.New("permanent"))))
+	assert.False(t, policy.ShouldRetry(1, fmt.Errorf("wrapped: %w", NonRetryable(errors.New("permanent")))))
+	assert.False(t, policy.ShouldRetry(1, &PanicError{Value: "panic"}))
+	assert.False(t, RetryPolicy{}.ShouldRetry(1, errors.New("transient")))
+
+	policy.Retryable = func(err error) bool {
+		return err.Error() == "retryable"
+	}
+	assert.True(t, policy.ShouldRetry(1, errors.New("retryable")))
+	assert.False(t, policy.ShouldRetry(1, errors.New("transient")))
+}
+
+func TestDefaultEventBus_retryPolicyOf_ShouldMatchSubscribersByIdOrFullName(t *testing.T) {
+	bus := NewDefaultEventBus(
+		WithRetryPolicy(RetryPolicy{MaxAttempts: 1}),
+		WithSubscriberRetryPolicy("pubsub.DummySubscriber1", RetryPolicy{MaxAttempts: 3}),
+		WithSubscriberRetryPolicy("custom-*", RetryPolicy{MaxAttempts: 5}),
+		WithSubscriberHandlerTimeout("pubsub.DummySubscriber*", time.Second),
+	)
+	// Duplicated instances have suffixed ids, they are matched by full name
+	assert.Equal(t, 3, bus.retryPolicyOf("pubsub.DummySubscriber1#2", &DummySubscriber1{}).MaxAttempts)
+	assert.Equal(t, 5, bus.retryPolicyOf("custom-subscriber", &DummySubscriber2{}).MaxAttempts)
+	assert.Equal(t, 1, bus.retryPolicyOf("pubsub.DummySubscriber2", &DummySubscriber2{}).MaxAttempts)
+	assert.Equal(t, time.Second, bus.handlerTimeoutOf("pubsub.DummySubscriber2#3", &DummySubscriber2{}))
+}
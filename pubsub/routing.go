@@ -0,0 +1,42 @@
+package pubsub
+
+import (
+	"reflect"
+
+	"github.com/golibs-starter/golib/utils"
+)
+
+// eventRoute delivers events that match its event name patterns, event types
+// or type name patterns to a bus
+type eventRoute struct {
+	bus       EventBus
+	patterns  []string
+	types     map[reflect.Type]bool
+	typeNames []string
+}
+
+func (r eventRoute) matches(event Event) bool {
+	if r.types[reflect.TypeOf(event)] {
+		return true
+	}
+	if len(r.typeNames) > 0 && utils.MatchAnyWildcard(r.typeNames, utils.GetStructFullname(event)) {
+		return true
+	}
+	return len(r.patterns) > 0 && utils.MatchAnyWildcard(r.patterns, event.Name())
+}
+
+// eventRouter is implemented by publishers that route events to buses, such as DefaultPublisher
+type eventRouter interface {
+	busOf(event Event) EventBus
+}
+
+// busOf returns the bus that the event is delivered to,
+// it's the bus of the first matched route or the bus of the publisher.
+func (p *DefaultPublisher) busOf(event Event) EventBus {
+	for _, route := range p.routes {
+		if route.matches(event) {
+			return route.bus
+		}
+	}
+	return p.bus
+}
@@ -0,0 +1,72 @@
+package pubsub
+
+import (
+	"context"
+	"testing"
+
+	"github.com/golibs-starter/golib/web/event"
+	assert "github.com/stretchr/testify/require"
+)
+
+func newRoutingBus(subscriber Subscriber) *DefaultEventBus {
+	bus := NewDefaultEventBus()
+	bus.Register(subscriber)
+	bus.Run()
+	return bus
+}
+
+func TestDefaultPublisher_WhenEventMatchesRoute_ShouldDeliverToRoutedBus(t *testing.T) {
+	defaultSubscriber, telemetrySubscriber, auditSubscriber := &DummySubscriber1{}, &DummySubscriber1{}, &DummySubscriber1{}
+	defaultBus := newRoutingBus(defaultSubscriber)
+	telemetryBus := newRoutingBus(telemetrySubscriber)
+	auditBus := newRoutingBus(auditSubscriber)
+	pub := NewDefaultPublisher(defaultBus,
+		WithEventRoute(telemetryBus, "Request*"),
+		WithEventTypeRoute(auditBus, &event.RequestCompletedEvent{}),
+	)
+
+	assert.NoError(t, pub.PublishCtx(context.Background(), &DummyEvent{name: "OrderCreated"}))
+	assert.NoError(t, pub.PublishCtx(context.Background(), &DummyEvent{name: "RequestStarted"}))
+	// The first matched route is used
+	assert.NoError(t, pub.PublishCtx(context.Background(),
+		event.NewRequestCompletedEvent(context.Background(), &event.RequestCompletedMessage{})))
+	defaultBus.Stop()
+	telemetryBus.Stop()
+	auditBus.Stop()
+
+	assert.Equal(t, map[string]bool{"OrderCreated": true}, defaultSubscriber.eventRun)
+	assert.Equal(t, map[string]bool{"RequestStarted": true, "RequestCompletedEvent": true}, telemetrySubscriber.eventRun)
+	assert.Nil(t, auditSubscriber.eventRun)
+}
+
+func TestDefaultPublisher_WhenRoutedBusIsNotRunning_ShouldReturnError(t *testing.T) {
+	defaultBus := newRoutingBus(&DummySubscriber1{})
+	defer defaultBus.Stop()
+	pub := NewDefaultPublisher(defaultBus, WithEventTypeRoute(NewDefaultEventBus(), &DummyEvent{}))
+
+	assert.ErrorIs(t, pub.PublishCtx(context.Background(), &DummyEvent{name: "OrderCreated"}), ErrBusNotRunning)
+	assert.NoError(t, pub.PublishCtx(context.Background(), DummyEvent{name: "OrderCreated"}))
+}
+
+func TestDefaultPublisher_WhenEventTypeNameMatchesRoute_ShouldDeliverToRoutedBus(t *testing.T) {
+	defaultSubscriber, auditSubscriber := &DummySubscriber1{}, &DummySubscriber1{}
+	defaultBus := newRoutingBus(defaultSubscriber)
+	auditBus := newRoutingBus(auditSubscriber)
+	pub := NewDefaultPublisher(defaultBus, WithEventTypeNameRoute(auditBus, "event.Request*"))
+
+	assert.NoError(t, pub.PublishCtx(context.Background(), &DummyEvent{name: "OrderCreated"}))
+	assert.NoError(t, pub.PublishCtx(context.Background(),
+		event.NewRequestCompletedEvent(context.Background(), &event.RequestCompletedMessage{})))
+	defaultBus.Stop()
+	auditBus.Stop()
+
+	assert.Equal(t, map[string]bool{"OrderCreated": true}, defaultSubscriber.eventRun)
+	assert.Equal(t, map[string]bool{"RequestCompletedEvent": true}, auditSubscriber.eventRun)
+}
+
+func TestReplaceGlobalNamed_ShouldReturnBusByName(t *testing.T) {
+	bus := NewDefaultEventBus()
+	ReplaceGlobalNamed("telemetry", bus)
+	assert.Equal(t, bus, GetNamedEventBus("telemetry"))
+	assert.Nil(t, GetNamedEventBus("audit"))
+}
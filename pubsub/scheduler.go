@@ -0,0 +1,393 @@
+package pubsub
+
+import (
+	"container/heap"
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ScheduledEvent is an event that is published when it's due
+type ScheduledEvent struct {
+	Event Event
+	DueAt time.Time
+}
+
+// ScheduleStore persists scheduled events, so that they are
+// scheduled again when the application restarts.
+type ScheduleStore interface {
+	// Save a scheduled event, it's called before the event is scheduled
+	Save(scheduled *ScheduledEvent) error
+
+	// Delete a scheduled event after it's published or cancelled
+	Delete(eventId string) error
+
+	// List returns all scheduled events that are not deleted
+	List() ([]*ScheduledEvent, error)
+}
+
+// SchedulingPublisher is an optional interface for a Publisher,
+// that publishes events at a later time.
+type SchedulingPublisher interface {
+	// PublishAt publishes the event at the given time,
+	// the event is published immediately when the time is in the past.
+	PublishAt(at time.Time, event Event) (*ScheduleHandle, error)
+
+	// PublishAfter publishes the event after the given delay
+	PublishAfter(delay time.Duration, event Event) (*ScheduleHandle, error)
+}
+
+// ScheduleHandle is returned when an event is scheduled, it's used to cancel the event
+type ScheduleHandle struct {
+	id        string
+	dueAt     time.Time
+	scheduler *EventScheduler
+}
+
+// Id returns the identifier of the scheduled event
+func (h *ScheduleHandle) Id() string {
+	return h.id
+}
+
+func (h *ScheduleHandle) DueAt() time.Time {
+	return h.dueAt
+}
+
+// Cancel the scheduled event, it returns false when the event was published or cancelled already
+func (h *ScheduleHandle) Cancel() bool {
+	return h.scheduler.Cancel(h.id)
+}
+
+// schedulerSubscriberId is the subscriber id of dead letters of scheduled events
+const schedulerSubscriberId = "event_scheduler"
+
+type scheduleEntry struct {
+	scheduled *ScheduledEvent
+	seq       int64
+	index     int
+
+	// errs are errors of failed publishing attempts
+	errs []error
+}
+
+// scheduleQueue is a min-heap of entries ordered by due time,
+// entries with the same due time are ordered by scheduling order.
+type scheduleQueue []*scheduleEntry
+
+func (q scheduleQueue) Len() int {
+	return len(q)
+}
+
+func (q scheduleQueue) Less(i, j int) bool {
+	if q[i].scheduled.DueAt.Equal(q[j].scheduled.DueAt) {
+		return q[i].seq < q[j].seq
+	}
+	return q[i].scheduled.DueAt.Before(q[j].scheduled.DueAt)
+}
+
+func (q scheduleQueue) Swap(i, j int) {
+	q[i], q[j] = q[j], q[i]
+	q[i].index = i
+	q[j].index = j
+}
+
+func (q *scheduleQueue) Push(x interface{}) {
+	entry := x.(*scheduleEntry)
+	entry.index = len(*q)
+	*q = append(*q, entry)
+}
+
+func (q *scheduleQueue) Pop() interface{} {
+	old := *q
+	n := len(old)
+	entry := old[n-1]
+	old[n-1] = nil
+	*q = old[:n-1]
+	return entry
+}
+
+// EventScheduler keeps scheduled events in a heap ordered by due time,
+// and publishes them when they are due. Events that failed to publish,
+// such as when the bus is not running, are retried after the retry interval.
+//
+// An event is dropped when it failed by a non-retryable error (see IsRetryable),
+// such as a SchemaValidationError, or when it failed after the max attempts,
+// the dropped event is put to the dead letter sink (if any). An event that failed since
+// the bus is stopped is not retried, but it's kept in the store to be scheduled again at restart.
+type EventScheduler struct {
+	clock          Clock
+	store          ScheduleStore
+	retryInterval  time.Duration
+	maxAttempts    int
+	deadLetterSink DeadLetterSink
+	debugLog       DebugLog
+
+	queue          scheduleQueue
+	entries        map[string]*scheduleEntry
+	entrySeq       int64
+	publish        PublishFunc
+	running        bool
+	stopped        bool
+	mu             sync.Mutex
+	wakeCh         chan struct{}
+	stopCh         chan struct{}
+	doneCh         chan struct{}
+	publishedCount int64
+	cancelledCount int64
+	failedCount    int64
+	droppedCount   int64
+}
+
+func NewEventScheduler(opts ...EventSchedulerOpt) *EventScheduler {
+	s := &EventScheduler{
+		clock:         SystemClock(),
+		retryInterval: time.Second,
+		maxAttempts:   10,
+		entries:       make(map[string]*scheduleEntry),
+		wakeCh:        make(chan struct{}, 1),
+		stopCh:        make(chan struct{}),
+		doneCh:        make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	if s.debugLog == nil {
+		s.debugLog = defaultDebugLog
+	}
+	return s
+}
+
+// Schedule an event to be published at the given time,
+// an event cannot be scheduled twice while it's pending.
+func (s *EventScheduler) Schedule(at time.Time, event Event) (*ScheduleHandle, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stopped {
+		return nil, ErrSchedulerStopped
+	}
+	if _, exists := s.entries[event.Identifier()]; exists {
+		return nil, fmt.Errorf("event [%s] with id [%s] is already scheduled", event.Name(), event.Identifier())
+	}
+	scheduled := &ScheduledEvent{Event: event, DueAt: at}
+	if s.store != nil {
+		if err := s.store.Save(scheduled); err != nil {
+			return nil, fmt.Errorf("cannot save scheduled event [%s]: %w", event.Identifier(), err)
+		}
+	}
+	s.push(scheduled)
+	return &ScheduleHandle{id: event.Identifier(), dueAt: at, scheduler: s}, nil
+}
+
+// Cancel a pending event by its id, it returns false when the event is not pending.
+func (s *EventScheduler) Cancel(eventId string) bool {
+	s.mu.Lock()
+	entry, exists := s.entries[eventId]
+	if !exists {
+		s.mu.Unlock()
+		return false
+	}
+	heap.Remove(&s.queue, entry.index)
+	delete(s.entries, eventId)
+	s.mu.Unlock()
+	atomic.AddInt64(&s.cancelledCount, 1)
+	s.deleteFromStore(entry.scheduled)
+	s.wakeup()
+	return true
+}
+
+// Pending returns pending events, ordered by due time
+func (s *EventScheduler) Pending() []*ScheduledEvent {
+	s.mu.Lock()
+	entries := make(scheduleQueue, len(s.queue))
+	copy(entries, s.queue)
+	s.mu.Unlock()
+	sort.Slice(entries, func(i, j int) bool {
+		return entries.Less(i, j)
+	})
+	pending := make([]*ScheduledEvent, 0, len(entries))
+	for _, entry := range entries {
+		pending = append(pending, entry.scheduled)
+	}
+	return pending
+}
+
+func (s *EventScheduler) PublishedCount() int64 {
+	return atomic.LoadInt64(&s.publishedCount)
+}
+
+func (s *EventScheduler) CancelledCount() int64 {
+	return atomic.LoadInt64(&s.cancelledCount)
+}
+
+// FailedCount returns the number of failed publishing attempts
+func (s *EventScheduler) FailedCount() int64 {
+	return atomic.LoadInt64(&s.failedCount)
+}
+
+// DroppedCount returns the number of events that were dropped after failed attempts
+func (s *EventScheduler) DroppedCount() int64 {
+	return atomic.LoadInt64(&s.droppedCount)
+}
+
+// Start publishing due events by the publish function, such as ContextPublisher.PublishCtx.
+// Events in the store are scheduled again before it starts.
+func (s *EventScheduler) Start(publish PublishFunc) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.running || s.stopped {
+		return nil
+	}
+	if s.store != nil {
+		stored, err := s.store.List()
+		if err != nil {
+			return fmt.Errorf("cannot load scheduled events: %w", err)
+		}
+		for _, scheduled := range stored {
+			if _, exists := s.entries[scheduled.Event.Identifier()]; !exists {
+				s.push(scheduled)
+			}
+		}
+	}
+	s.publish = publish
+	s.running = true
+	go s.loop()
+	return nil
+}
+
+// Stop publishing due events, pending events are kept in the store (if any).
+func (s *EventScheduler) Stop() {
+	s.mu.Lock()
+	if s.stopped {
+		s.mu.Unlock()
+		return
+	}
+	s.stopped = true
+	running := s.running
+	s.mu.Unlock()
+	close(s.stopCh)
+	if running {
+		<-s.doneCh
+	}
+}
+
+// push adds an entry to the queue, the caller must hold the lock
+func (s *EventScheduler) push(scheduled *ScheduledEvent) {
+	s.pushEntry(&scheduleEntry{scheduled: scheduled})
+}
+
+// pushEntry adds an entry to the queue in scheduling order, the caller must hold the lock
+func (s *EventScheduler) pushEntry(entry *scheduleEntry) {
+	s.entrySeq++
+	entry.seq = s.entrySeq
+	scheduled := entry.scheduled
+	heap.Push(&s.queue, entry)
+	s.entries[scheduled.Event.Identifier()] = entry
+	s.wakeup()
+}
+
+func (s *EventScheduler) wakeup() {
+	select {
+	case s.wakeCh <- struct{}{}:
+	default:
+	}
+}
+
+func (s *EventScheduler) loop() {
+	defer close(s.doneCh)
+	for {
+		s.mu.Lock()
+		var timer Timer
+		var timerCh <-chan time.Time
+		if len(s.queue) > 0 {
+			delay := s.queue[0].scheduled.DueAt.Sub(s.clock.Now())
+			if delay <= 0 {
+				entry := heap.Pop(&s.queue).(*scheduleEntry)
+				delete(s.entries, entry.scheduled.Event.Identifier())
+				s.mu.Unlock()
+				s.fire(entry)
+				continue
+			}
+			timer = s.clock.NewTimer(delay)
+			timerCh = timer.C()
+		}
+		s.mu.Unlock()
+		select {
+		case <-timerCh:
+		case <-s.wakeCh:
+		case <-s.stopCh:
+			if timer != nil {
+				timer.Stop()
+			}
+			return
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+	}
+}
+
+func (s *EventScheduler) fire(entry *scheduleEntry) {
+	scheduled := entry.scheduled
+	event := scheduled.Event
+	err := s.publish(context.Background(), event)
+	if err == nil {
+		atomic.AddInt64(&s.publishedCount, 1)
+		s.deleteFromStore(scheduled)
+		return
+	}
+	atomic.AddInt64(&s.failedCount, 1)
+	entry.errs = append(entry.errs, err)
+	if errors.Is(err, ErrBusStopped) {
+		s.debugLog(event.Context(), "Scheduled event [%s] with id [%s] was not published since the bus is stopped, "+
+			"it's not retried", event.Name(), event.Identifier())
+		return
+	}
+	if !s.shouldRetry(len(entry.errs), err) {
+		s.drop(entry)
+		return
+	}
+	s.debugLog(event.Context(), "Scheduled event [%s] with id [%s] was not published, retry after [%s], error [%v]",
+		event.Name(), event.Identifier(), s.retryInterval, err)
+	s.mu.Lock()
+	if _, exists := s.entries[event.Identifier()]; !exists && !s.stopped {
+		scheduled.DueAt = s.clock.Now().Add(s.retryInterval)
+		s.pushEntry(entry)
+	}
+	s.mu.Unlock()
+}
+
+// shouldRetry returns whether a failed publishing is retried,
+// an invalid payload is not retried since it fails again.
+func (s *EventScheduler) shouldRetry(attempts int, err error) bool {
+	if s.maxAttempts > 0 && attempts >= s.maxAttempts {
+		return false
+	}
+	return IsRetryable(err) && !errors.Is(err, ErrSchemaValidation)
+}
+
+// drop an event that is not retried, it's put to the dead letter sink (if any)
+func (s *EventScheduler) drop(entry *scheduleEntry) {
+	atomic.AddInt64(&s.droppedCount, 1)
+	event := entry.scheduled.Event
+	s.debugLog(event.Context(), "Scheduled event [%s] with id [%s] was dropped after [%d] attempts, error [%v]",
+		event.Name(), event.Identifier(), len(entry.errs), entry.errs[len(entry.errs)-1])
+	if s.deadLetterSink != nil {
+		s.deadLetterSink.Put(NewDeadLetter(schedulerSubscriberId, event, entry.errs))
+	}
+	s.deleteFromStore(entry.scheduled)
+}
+
+func (s *EventScheduler) deleteFromStore(scheduled *ScheduledEvent) {
+	if s.store == nil {
+		return
+	}
+	event := scheduled.Event
+	if err := s.store.Delete(event.Identifier()); err != nil {
+		s.debugLog(event.Context(), "Scheduled event [%s] with id [%s] cannot be deleted from the store, error [%v]",
+			event.Name(), event.Identifier(), err)
+	}
+}
@@ -0,0 +1,50 @@
+package pubsub
+
+import "time"
+
+type EventSchedulerOpt func(s *EventScheduler)
+
+// WithSchedulerClock replaces the system clock, such as by a fake clock in tests
+func WithSchedulerClock(clock Clock) EventSchedulerOpt {
+	return func(s *EventScheduler) {
+		s.clock = clock
+	}
+}
+
+// WithScheduleStore persists scheduled events to the store
+func WithScheduleStore(store ScheduleStore) EventSchedulerOpt {
+	return func(s *EventScheduler) {
+		s.store = store
+	}
+}
+
+// WithSchedulerRetryInterval sets the time to wait before publishing
+// an event again when it failed to publish. Default is 1s.
+func WithSchedulerRetryInterval(interval time.Duration) EventSchedulerOpt {
+	return func(s *EventScheduler) {
+		if interval > 0 {
+			s.retryInterval = interval
+		}
+	}
+}
+
+// WithSchedulerMaxAttempts sets the maximum number of publishing attempts of an event,
+// the event is dropped when all attempts failed. Zero or negative means unlimited, default is 10.
+func WithSchedulerMaxAttempts(maxAttempts int) EventSchedulerOpt {
+	return func(s *EventScheduler) {
+		s.maxAttempts = maxAttempts
+	}
+}
+
+// WithSchedulerDeadLetterSink puts events that are dropped after failed attempts to the sink
+func WithSchedulerDeadLetterSink(sink DeadLetterSink) EventSchedulerOpt {
+	return func(s *EventScheduler) {
+		s.deadLetterSink = sink
+	}
+}
+
+func WithSchedulerDebugLog(debugLog DebugLog) EventSchedulerOpt {
+	return func(s *EventScheduler) {
+		s.debugLog = debugLog
+	}
+}
@@ -0,0 +1,301 @@
+package pubsub
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"sync/atomic"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/golibs-starter/golib/log"
+)
+
+var ErrSchemaValidation = errors.New("event payload does not match its schema")
+
+// ValidationMode defines the behavior when a payload does not match its schema
+type ValidationMode string
+
+const (
+	// ValidationFail rejects the event, PublishCtx returns a SchemaValidationError
+	ValidationFail ValidationMode = "fail"
+
+	// ValidationWarn logs a warning and publishes the event
+	ValidationWarn ValidationMode = "warn"
+
+	// ValidationOff does not validate payloads, the schema version is still stamped
+	ValidationOff ValidationMode = "off"
+)
+
+func (m ValidationMode) IsValid() bool {
+	switch m {
+	case ValidationFail, ValidationWarn, ValidationOff:
+		return true
+	}
+	return false
+}
+
+// PayloadValidator validates the payload of an event
+type PayloadValidator interface {
+	Validate(payload interface{}) error
+}
+
+// PayloadValidatorFunc is a function that implements PayloadValidator
+type PayloadValidatorFunc func(payload interface{}) error
+
+func (f PayloadValidatorFunc) Validate(payload interface{}) error {
+	return f(payload)
+}
+
+var structValidator = validator.New()
+
+// NewStructValidator validates a struct payload by its `validate` tags,
+// see github.com/go-playground/validator.
+func NewStructValidator() PayloadValidator {
+	return PayloadValidatorFunc(func(payload interface{}) error {
+		if payload == nil {
+			return errors.New("payload is missing")
+		}
+		value := reflect.ValueOf(payload)
+		for value.Kind() == reflect.Ptr && !value.IsNil() {
+			value = value.Elem()
+		}
+		if value.Kind() != reflect.Struct {
+			return fmt.Errorf("payload of type [%T] is not a struct", payload)
+		}
+		return structValidator.Struct(payload)
+	})
+}
+
+// Upcaster converts a payload of a version to the next version, such as from 1 to 2
+type Upcaster func(payload json.RawMessage) (json.RawMessage, error)
+
+// EventSchema declares the current version of an event payload and its validator
+type EventSchema struct {
+	// Version of the payload, start from 1.
+	// Events without version are considered as version 1.
+	Version int
+
+	// Validator of the payload, the payload is not validated when it's nil
+	Validator PayloadValidator
+}
+
+// SchemaValidationError is returned when a payload does not match its schema
+type SchemaValidationError struct {
+	Event   string
+	Version int
+	Err     error
+}
+
+func (e *SchemaValidationError) Error() string {
+	return fmt.Sprintf("payload of event [%s] does not match schema version [%d]: %v", e.Event, e.Version, e.Err)
+}
+
+func (e *SchemaValidationError) Is(target error) bool {
+	return target == ErrSchemaValidation
+}
+
+func (e *SchemaValidationError) Unwrap() error {
+	return e.Err
+}
+
+// SchemaStats are counters of an event schema
+type SchemaStats struct {
+	Version int `json:"version"`
+
+	// ValidationFailures is the number of payloads that do not match the schema
+	ValidationFailures int64 `json:"validation_failures"`
+
+	// VersionMismatches is the number of decoded events that their version
+	// is different from the current one, includes upcasted events
+	VersionMismatches int64 `json:"version_mismatches"`
+
+	// Upcasted is the number of decoded events that are converted to the current version
+	Upcasted int64 `json:"upcasted"`
+}
+
+type registeredSchema struct {
+	schema             EventSchema
+	upcasters          map[int]Upcaster
+	validationFailures int64
+	versionMismatches  int64
+	upcasted           int64
+}
+
+// versionedEvent is implemented by event.ApplicationEvent and events that embed it
+type versionedEvent interface {
+	GetSchemaVersion() int
+	SetSchemaVersion(version int)
+}
+
+// schemas of event names, it's part of EventTypeRegistry
+type schemas struct {
+	items map[string]*registeredSchema
+	mu    sync.RWMutex
+}
+
+// RegisterSchema declares the schema of an event name
+func (r *EventTypeRegistry) RegisterSchema(name string, schema EventSchema) error {
+	if schema.Version < 1 {
+		return fmt.Errorf("schema version of event [%s] must be greater than 0", name)
+	}
+	r.schemas.mu.Lock()
+	defer r.schemas.mu.Unlock()
+	if existing, exists := r.schemas.items[name]; exists {
+		existing.schema = schema
+		return nil
+	}
+	r.schemas.items[name] = &registeredSchema{schema: schema, upcasters: make(map[int]Upcaster)}
+	return nil
+}
+
+// RegisterUpcaster registers a function that converts payloads of an event name
+// from a version to the next version, the schema must be registered first.
+func (r *EventTypeRegistry) RegisterUpcaster(name string, fromVersion int, upcaster Upcaster) error {
+	r.schemas.mu.Lock()
+	defer r.schemas.mu.Unlock()
+	registered, exists := r.schemas.items[name]
+	if !exists {
+		return fmt.Errorf("schema of event [%s] is not registered", name)
+	}
+	if fromVersion < 1 || fromVersion >= registered.schema.Version {
+		return fmt.Errorf("upcaster of event [%s] must convert from a version in [1, %d)",
+			name, registered.schema.Version)
+	}
+	registered.upcasters[fromVersion] = upcaster
+	return nil
+}
+
+// SchemaOf returns the schema of an event name
+func (r *EventTypeRegistry) SchemaOf(name string) (EventSchema, bool) {
+	registered := r.registeredSchema(name)
+	if registered == nil {
+		return EventSchema{}, false
+	}
+	return registered.schema, true
+}
+
+// SchemaStats returns counters of registered schemas by event name
+func (r *EventTypeRegistry) SchemaStats() map[string]SchemaStats {
+	r.schemas.mu.RLock()
+	defer r.schemas.mu.RUnlock()
+	stats := make(map[string]SchemaStats, len(r.schemas.items))
+	for name, registered := range r.schemas.items {
+		stats[name] = SchemaStats{
+			Version:            registered.schema.Version,
+			ValidationFailures: atomic.LoadInt64(&registered.validationFailures),
+			VersionMismatches:  atomic.LoadInt64(&registered.versionMismatches),
+			Upcasted:           atomic.LoadInt64(&registered.upcasted),
+		}
+	}
+	return stats
+}
+
+// Validate stamps the current schema version to the event (when it has no version),
+// then validates its payload. A SchemaValidationError is returned when the payload does not match.
+// Events without schema are always valid.
+func (r *EventTypeRegistry) Validate(event Event) error {
+	registered := r.registeredSchema(event.Name())
+	if registered == nil {
+		return nil
+	}
+	version := stampSchemaVersion(registered, event)
+	if registered.schema.Validator == nil {
+		return nil
+	}
+	if err := registered.schema.Validator.Validate(event.Payload()); err != nil {
+		atomic.AddInt64(&registered.validationFailures, 1)
+		return &SchemaValidationError{Event: event.Name(), Version: version, Err: err}
+	}
+	return nil
+}
+
+// stampSchemaVersion sets the current schema version to the event when it has no version,
+// it returns the version of the event.
+func stampSchemaVersion(registered *registeredSchema, event Event) int {
+	versioned, ok := event.(versionedEvent)
+	if !ok {
+		return registered.schema.Version
+	}
+	if versioned.GetSchemaVersion() == 0 {
+		versioned.SetSchemaVersion(registered.schema.Version)
+	}
+	return versioned.GetSchemaVersion()
+}
+
+func (r *EventTypeRegistry) registeredSchema(name string) *registeredSchema {
+	r.schemas.mu.RLock()
+	defer r.schemas.mu.RUnlock()
+	return r.schemas.items[name]
+}
+
+// upcast converts the encoded event to the current schema version,
+// events of a newer version are kept as they are.
+func (r *EventTypeRegistry) upcast(name string, data []byte) ([]byte, error) {
+	registered := r.registeredSchema(name)
+	if registered == nil {
+		return data, nil
+	}
+	var doc map[string]json.RawMessage
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("cannot decode event [%s]: %w", name, err)
+	}
+	version := 1
+	if raw, exists := doc["schema_version"]; exists {
+		if err := json.Unmarshal(raw, &version); err != nil {
+			return nil, fmt.Errorf("cannot decode schema version of event [%s]: %w", name, err)
+		}
+		if version < 1 {
+			version = 1
+		}
+	}
+	current := registered.schema.Version
+	if version == current {
+		return data, nil
+	}
+	atomic.AddInt64(&registered.versionMismatches, 1)
+	if version > current {
+		return data, nil
+	}
+	r.schemas.mu.RLock()
+	upcasters := make([]Upcaster, 0, current-version)
+	for v := version; v < current; v++ {
+		upcaster, exists := registered.upcasters[v]
+		if !exists {
+			r.schemas.mu.RUnlock()
+			return nil, fmt.Errorf("no upcaster converts event [%s] from schema version [%d]", name, v)
+		}
+		upcasters = append(upcasters, upcaster)
+	}
+	r.schemas.mu.RUnlock()
+	payload := doc["payload"]
+	for i, upcaster := range upcasters {
+		var err error
+		if payload, err = upcaster(payload); err != nil {
+			return nil, fmt.Errorf("cannot upcast event [%s] from schema version [%d]: %w", name, version+i, err)
+		}
+	}
+	doc["payload"] = payload
+	doc["schema_version"], _ = json.Marshal(current)
+	atomic.AddInt64(&registered.upcasted, 1)
+	return json.Marshal(doc)
+}
+
+// validateSchema validates the event before it's delivered, according to the validation mode
+func (p *DefaultPublisher) validateSchema(ctx context.Context, event Event) error {
+	if p.validationMode == ValidationOff {
+		if registered := p.schemaRegistry.registeredSchema(event.Name()); registered != nil {
+			stampSchemaVersion(registered, event)
+		}
+		return nil
+	}
+	err := p.schemaRegistry.Validate(event)
+	if err == nil || p.validationMode == ValidationFail {
+		return err
+	}
+	log.WithCtx(ctx).Warnf("Event [%s] with id [%s] is published with invalid payload, error [%v]",
+		event.Name(), event.Identifier(), err)
+	return nil
+}
@@ -0,0 +1,121 @@
+package pubsub
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/golibs-starter/golib/pubsub/executor"
+	"github.com/golibs-starter/golib/web/event"
+	assert "github.com/stretchr/testify/require"
+)
+
+type ShipmentCreated struct {
+	ShipmentId string `json:"shipment_id" validate:"required"`
+	Carrier    string `json:"carrier" validate:"required"`
+}
+
+func newShipmentCreated(payload ShipmentCreated) MessageEvent[ShipmentCreated] {
+	return MessageEvent[ShipmentCreated]{
+		AbstractEvent: event.NewAbstractEvent(context.Background(), "ShipmentCreated"),
+		PayloadData:   payload,
+	}
+}
+
+func newSchemaRegistry(t *testing.T) *EventTypeRegistry {
+	registry := NewEventTypeRegistry()
+	assert.NoError(t, RegisterMessage[ShipmentCreated](registry))
+	assert.NoError(t, registry.RegisterSchema("ShipmentCreated", EventSchema{Version: 3, Validator: NewStructValidator()}))
+	return registry
+}
+
+func newSchemaPublisher(t *testing.T, registry *EventTypeRegistry, mode ValidationMode) *DefaultPublisher {
+	bus := NewDefaultEventBus(WithEventExecutor(executor.NewAsyncExecutor()))
+	bus.Run()
+	t.Cleanup(bus.Stop)
+	return NewDefaultPublisher(bus, WithSchemaValidation(registry, mode))
+}
+
+func TestDefaultPublisher_WhenValidationFail_ShouldRejectInvalidPayload(t *testing.T) {
+	registry := newSchemaRegistry(t)
+	pub := newSchemaPublisher(t, registry, ValidationFail)
+
+	err := pub.PublishCtx(context.Background(), newShipmentCreated(ShipmentCreated{ShipmentId: "1"}))
+	assert.ErrorIs(t, err, ErrSchemaValidation)
+	var validationErr *SchemaValidationError
+	assert.ErrorAs(t, err, &validationErr)
+	assert.Equal(t, "ShipmentCreated", validationErr.Event)
+	assert.Equal(t, 3, validationErr.Version)
+
+	valid := newShipmentCreated(ShipmentCreated{ShipmentId: "1", Carrier: "dhl"})
+	assert.NoError(t, pub.PublishCtx(context.Background(), valid))
+	assert.Equal(t, 3, valid.GetSchemaVersion())
+	assert.Equal(t, int64(1), registry.SchemaStats()["ShipmentCreated"].ValidationFailures)
+}
+
+func TestDefaultPublisher_WhenValidationWarnOrOff_ShouldPublishInvalidPayload(t *testing.T) {
+	registry := newSchemaRegistry(t)
+	invalid := newShipmentCreated(ShipmentCreated{})
+	assert.NoError(t, newSchemaPublisher(t, registry, ValidationWarn).PublishCtx(context.Background(), invalid))
+	assert.Equal(t, int64(1), registry.SchemaStats()["ShipmentCreated"].ValidationFailures)
+
+	invalid = newShipmentCreated(ShipmentCreated{})
+	assert.NoError(t, newSchemaPublisher(t, registry, ValidationOff).PublishCtx(context.Background(), invalid))
+	assert.Equal(t, 3, invalid.GetSchemaVersion())
+	assert.Equal(t, int64(1), registry.SchemaStats()["ShipmentCreated"].ValidationFailures)
+}
+
+func TestEventTypeRegistry_WhenDecodeOlderVersion_ShouldUpcastPayload(t *testing.T) {
+	registry := newSchemaRegistry(t)
+	// Version 1 has no carrier, version 2 renamed id to shipment_id
+	assert.NoError(t, registry.RegisterUpcaster("ShipmentCreated", 1, func(payload json.RawMessage) (json.RawMessage, error) {
+		var v map[string]interface{}
+		if err := json.Unmarshal(payload, &v); err != nil {
+			return nil, err
+		}
+		v["carrier"] = "unknown"
+		return json.Marshal(v)
+	}))
+	assert.NoError(t, registry.RegisterUpcaster("ShipmentCreated", 2, func(payload json.RawMessage) (json.RawMessage, error) {
+		var v map[string]interface{}
+		if err := json.Unmarshal(payload, &v); err != nil {
+			return nil, err
+		}
+		v["shipment_id"] = v["id"]
+		delete(v, "id")
+		return json.Marshal(v)
+	}))
+
+	decoded, err := registry.DecodeNamed(context.Background(), "ShipmentCreated",
+		[]byte(`{"id":"event-1","event":"ShipmentCreated","payload":{"id":"1"}}`))
+	assert.NoError(t, err)
+	shipment := decoded.(MessageEvent[ShipmentCreated])
+	assert.Equal(t, ShipmentCreated{ShipmentId: "1", Carrier: "unknown"}, shipment.PayloadData)
+	assert.Equal(t, 3, shipment.GetSchemaVersion())
+
+	_, err = registry.DecodeNamed(context.Background(), "ShipmentCreated",
+		[]byte(`{"id":"event-2","event":"ShipmentCreated","schema_version":3,"payload":{"shipment_id":"2","carrier":"dhl"}}`))
+	assert.NoError(t, err)
+
+	// A newer version is kept as it is
+	decoded, err = registry.DecodeNamed(context.Background(), "ShipmentCreated",
+		[]byte(`{"id":"event-3","event":"ShipmentCreated","schema_version":4,"payload":{"shipment_id":"3"}}`))
+	assert.NoError(t, err)
+	assert.Equal(t, 4, decoded.(MessageEvent[ShipmentCreated]).GetSchemaVersion())
+
+	stats := registry.SchemaStats()["ShipmentCreated"]
+	assert.Equal(t, SchemaStats{Version: 3, VersionMismatches: 2, Upcasted: 1}, stats)
+}
+
+func TestEventTypeRegistry_WhenUpcasterIsMissing_ShouldReturnError(t *testing.T) {
+	registry := newSchemaRegistry(t)
+	assert.NoError(t, registry.RegisterUpcaster("ShipmentCreated", 1, func(payload json.RawMessage) (json.RawMessage, error) {
+		return payload, nil
+	}))
+	assert.Error(t, registry.RegisterUpcaster("ShipmentCreated", 3, nil))
+	assert.Error(t, registry.RegisterUpcaster("ShipmentDelivered", 1, nil))
+
+	_, err := registry.DecodeNamed(context.Background(), "ShipmentCreated",
+		[]byte(`{"id":"event-1","event":"ShipmentCreated","schema_version":1,"payload":{"shipment_id":"1"}}`))
+	assert.ErrorContains(t, err, "from schema version [2]")
+}
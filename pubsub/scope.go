@@ -0,0 +1,146 @@
+package pubsub
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+var ErrEventScopeClosed = errors.New("event scope is committed or rolled back")
+
+type eventScopeContextKey struct{}
+
+// EventScope is a unit of work that buffers events of Collect, the events are published in order
+// when the scope is committed, or they are discarded when it's rolled back.
+//
+// A scope that created inside another scope is nested, its events are moved to the outer scope
+// when it's committed, so that they are published only when the outer scope is committed.
+type EventScope struct {
+	ctx       context.Context
+	publisher Publisher
+	parent    *EventScope
+	events    []Event
+	closed    bool
+	mu        sync.Mutex
+}
+
+// ScopeCommitError is returned when some events of a scope are not published,
+// the other events are still published.
+type ScopeCommitError struct {
+	// Failed are events that are not published
+	Failed []Event
+
+	// Err is the error of the first failed event
+	Err error
+}
+
+func (e *ScopeCommitError) Error() string {
+	return fmt.Sprintf("[%d] events of the scope are not published: %v", len(e.Failed), e.Err)
+}
+
+func (e *ScopeCommitError) Unwrap() error {
+	return e.Err
+}
+
+// WithEventScope returns a context that carries a new EventScope,
+// events are published by the global publisher when the scope is committed.
+// Use GetEventScope to commit or roll back the scope.
+func WithEventScope(ctx context.Context) context.Context {
+	return WithEventScopeOn(ctx, nil)
+}
+
+// WithEventScopeOn is the same as WithEventScope, but events are published by the given publisher.
+func WithEventScopeOn(ctx context.Context, publisher Publisher) context.Context {
+	scope := &EventScope{publisher: publisher, parent: GetEventScope(ctx)}
+	scope.ctx = context.WithValue(ctx, eventScopeContextKey{}, scope)
+	return scope.ctx
+}
+
+// GetEventScope returns the scope of the context, it returns nil when the context has no scope.
+func GetEventScope(ctx context.Context) *EventScope {
+	if ctx == nil {
+		return nil
+	}
+	scope, _ := ctx.Value(eventScopeContextKey{}).(*EventScope)
+	return scope
+}
+
+// Collect buffers an event to the scope of the context until the scope is committed.
+// When the context has no scope, the event is published immediately by the global publisher.
+func Collect(ctx context.Context, event Event) error {
+	scope := GetEventScope(ctx)
+	if scope == nil {
+		return PublishCtx(ctx, event)
+	}
+	return scope.Collect(event)
+}
+
+// Collect buffers an event until the scope is committed
+func (s *EventScope) Collect(event Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return ErrEventScopeClosed
+	}
+	s.events = append(s.events, event)
+	return nil
+}
+
+// Events returns buffered events in the collected order
+func (s *EventScope) Events() []Event {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	events := make([]Event, len(s.events))
+	copy(events, s.events)
+	return events
+}
+
+// Commit publishes buffered events in the collected order, or moves them to the outer scope
+// when the scope is nested. A ScopeCommitError is returned when some events are not published.
+func (s *EventScope) Commit() error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return ErrEventScopeClosed
+	}
+	s.closed = true
+	events := s.events
+	s.events = nil
+	s.mu.Unlock()
+
+	if s.parent != nil {
+		s.parent.mu.Lock()
+		defer s.parent.mu.Unlock()
+		if s.parent.closed {
+			return fmt.Errorf("outer scope cannot collect [%d] events: %w", len(events), ErrEventScopeClosed)
+		}
+		s.parent.events = append(s.parent.events, events...)
+		return nil
+	}
+	publisher := s.publisher
+	if publisher == nil {
+		publisher = GetPublisher()
+	}
+	var commitErr *ScopeCommitError
+	for _, event := range events {
+		if err := PublishCtxOn(s.ctx, publisher, event); err != nil {
+			if commitErr == nil {
+				commitErr = &ScopeCommitError{Err: err}
+			}
+			commitErr.Failed = append(commitErr.Failed, event)
+		}
+	}
+	if commitErr != nil {
+		return commitErr
+	}
+	return nil
+}
+
+// Rollback discards buffered events, it's no-op when the scope is committed or rolled back already.
+func (s *EventScope) Rollback() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	s.events = nil
+}
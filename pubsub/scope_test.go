@@ -0,0 +1,99 @@
+package pubsub
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	assert "github.com/stretchr/testify/require"
+)
+
+type recordingPublisher struct {
+	published []string
+	failed    map[string]bool
+	mu        sync.Mutex
+}
+
+func (p *recordingPublisher) Publish(event Event) {
+	_ = p.PublishCtx(context.Background(), event)
+}
+
+func (p *recordingPublisher) PublishCtx(ctx context.Context, event Event) error {
+	if p.failed[event.Name()] {
+		return errors.New("publisher is broken")
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.published = append(p.published, event.Name())
+	return nil
+}
+
+func TestEventScope_WhenCommit_ShouldPublishCollectedEventsInOrder(t *testing.T) {
+	publisher := &recordingPublisher{}
+	ctx := WithEventScopeOn(context.Background(), publisher)
+	assert.NoError(t, Collect(ctx, &DummyEvent{name: "OrderCreated"}))
+	assert.NoError(t, Collect(ctx, &DummyEvent{name: "OrderPaid"}))
+	assert.Len(t, GetEventScope(ctx).Events(), 2)
+	assert.Empty(t, publisher.published)
+
+	assert.NoError(t, GetEventScope(ctx).Commit())
+	assert.Equal(t, []string{"OrderCreated", "OrderPaid"}, publisher.published)
+	assert.ErrorIs(t, GetEventScope(ctx).Commit(), ErrEventScopeClosed)
+	assert.ErrorIs(t, Collect(ctx, &DummyEvent{name: "OrderShipped"}), ErrEventScopeClosed)
+}
+
+func TestEventScope_WhenRollback_ShouldDiscardCollectedEvents(t *testing.T) {
+	publisher := &recordingPublisher{}
+	ctx := WithEventScopeOn(context.Background(), publisher)
+	assert.NoError(t, Collect(ctx, &DummyEvent{name: "OrderCreated"}))
+	GetEventScope(ctx).Rollback()
+	GetEventScope(ctx).Rollback()
+
+	assert.ErrorIs(t, GetEventScope(ctx).Commit(), ErrEventScopeClosed)
+	assert.Empty(t, publisher.published)
+	assert.Empty(t, GetEventScope(ctx).Events())
+}
+
+func TestEventScope_WhenNested_ShouldPublishWhenOuterScopeIsCommitted(t *testing.T) {
+	publisher := &recordingPublisher{}
+	outerCtx := WithEventScopeOn(context.Background(), publisher)
+	assert.NoError(t, Collect(outerCtx, &DummyEvent{name: "OrderCreated"}))
+
+	innerCtx := WithEventScope(outerCtx)
+	assert.NoError(t, Collect(innerCtx, &DummyEvent{name: "StockReserved"}))
+	assert.NoError(t, GetEventScope(innerCtx).Commit())
+	rolledBackCtx := WithEventScope(outerCtx)
+	assert.NoError(t, Collect(rolledBackCtx, &DummyEvent{name: "StockReleased"}))
+	GetEventScope(rolledBackCtx).Rollback()
+	assert.Empty(t, publisher.published)
+
+	assert.NoError(t, GetEventScope(outerCtx).Commit())
+	assert.Equal(t, []string{"OrderCreated", "StockReserved"}, publisher.published)
+}
+
+func TestEventScope_WhenSomeEventsAreNotPublished_ShouldPublishOthersAndReturnError(t *testing.T) {
+	publisher := &recordingPublisher{failed: map[string]bool{"OrderPaid": true}}
+	ctx := WithEventScopeOn(context.Background(), publisher)
+	assert.NoError(t, Collect(ctx, &DummyEvent{name: "OrderCreated"}))
+	assert.NoError(t, Collect(ctx, &DummyEvent{name: "OrderPaid"}))
+	assert.NoError(t, Collect(ctx, &DummyEvent{name: "OrderShipped"}))
+
+	err := GetEventScope(ctx).Commit()
+	var commitErr *ScopeCommitError
+	assert.ErrorAs(t, err, &commitErr)
+	assert.Len(t, commitErr.Failed, 1)
+	assert.EqualError(t, commitErr.Err, "publisher is broken")
+	assert.Equal(t, []string{"OrderCreated", "OrderShipped"}, publisher.published)
+}
+
+func TestCollect_WhenContextHasNoScope_ShouldPublishImmediately(t *testing.T) {
+	bus, publisher := GetEventBus(), GetPublisher()
+	defer ReplaceGlobal(bus, publisher)
+	recording := &recordingPublisher{}
+	ReplaceGlobal(bus, recording)
+
+	assert.Nil(t, GetEventScope(context.Background()))
+	assert.NoError(t, Collect(context.Background(), &DummyEvent{name: "OrderCreated"}))
+	assert.Equal(t, []string{"OrderCreated"}, recording.published)
+}
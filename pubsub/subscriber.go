@@ -1,5 +1,7 @@
 package pubsub
 
+import "context"
+
 type Subscriber interface {
 
 	// Supports indicates whether an event is supported by this Subscriber or not.
@@ -7,7 +9,77 @@ type Subscriber interface {
 
 	// Handle a supported Event that indicated by the Supports function.
 	Handle(event Event)
+}
+
+// IdentifiableSubscriber is an optional interface for a Subscriber to provide its own id.
+// By default, the full name of the subscriber's struct is used as the id.
+type IdentifiableSubscriber interface {
+	Subscriber
+
+	// SubscriberId returns the id of this subscriber
+	SubscriberId() string
+}
+
+// ErrorAwareSubscriber is an optional interface for a Subscriber.
+// When implemented, the bus calls HandleWithError instead of Handle,
+// the returned error is reported to the ErrorHandler of the bus.
+type ErrorAwareSubscriber interface {
+	Subscriber
+
+	// HandleWithError handles a supported Event and returns error if any.
+	HandleWithError(ctx context.Context, event Event) error
+}
+
+// PrioritizedSubscriber is an optional interface for a Subscriber to declare its priority.
+// Subscribers with higher priority are dispatched first, subscribers with the same priority
+// are dispatched in the registration order. The default priority is 0.
+type PrioritizedSubscriber interface {
+	Subscriber
+
+	// Priority returns the priority of this subscriber, it's read once when the subscriber is registered
+	Priority() int
+}
+
+// SynchronousSubscriber is an optional interface for a Subscriber to run in the synchronous phase.
+// Synchronous subscribers run inline when an event is delivered (eg: inside Publish),
+// before the event is queued for other subscribers, so that they can validate or enrich the event.
+// Changes on a pointer event are seen by the other subscribers.
+//
+// An error (or a panic) of a synchronous subscriber rejects the event: it's returned to the publisher
+// as a PreDispatchError, and the event is not queued. Synchronous subscribers are not retried.
+type SynchronousSubscriber interface {
+	Subscriber
+
+	// Synchronous returns true to run in the synchronous phase,
+	// it's read once when the subscriber is registered
+	Synchronous() bool
+}
+
+// StoppableSubscriber is an optional interface for a Subscriber that runs in background,
+// such as BatchingSubscriber. The bus calls Stop when it's shut down,
+// after all in-flight handlings are done.
+type StoppableSubscriber interface {
+	Subscriber
+
+	// Stop the background work, it's called once by the bus
+	Stop()
+}
+
+// startableSubscriber is implemented by subscribers that run in background, such as BatchingSubscriber.
+// The bus starts them when it runs or when they are registered to a running bus,
+// ctx is cancelled when the bus shutdown is aborted.
+type startableSubscriber interface {
+	start(ctx context.Context)
+}
+
+// BatchSubscriber is a variant of Subscriber that handles events in batches,
+// it's registered to the bus by wrapping with NewBatchingSubscriber.
+type BatchSubscriber interface {
+
+	// Supports indicates whether an event is supported by this BatchSubscriber or not.
+	Supports(event Event) bool
 
-	// Register a handler for a specific topic
-	RegisterHandler(topicName string, handler any)
+	// HandleBatch handles supported events in the order they are received,
+	// the returned error is reported to the BatchErrorHandler.
+	HandleBatch(ctx context.Context, events []Event) error
 }
@@ -0,0 +1,147 @@
+package pubsub
+
+import (
+	"reflect"
+	"sort"
+	"sync"
+)
+
+// Subscription is a handle of a registered subscriber
+type Subscription interface {
+
+	// Id returns the subscriber id of this subscription
+	Id() string
+
+	// Unsubscribe removes the subscriber from the bus,
+	// handlings that are already running are not affected.
+	Unsubscribe()
+}
+
+// SupportedEventsProvider is an optional interface for a Subscriber
+// to declare names of events that it supports, it's used for informational purposes.
+type SupportedEventsProvider interface {
+
+	// SupportedEvents returns names (or patterns) of supported events
+	SupportedEvents() []string
+}
+
+// SubscriberInfo describes a registered subscriber
+type SubscriberInfo struct {
+	Id              string   `json:"id"`
+	Type            string   `json:"type"`
+	Priority        int      `json:"priority"`
+	Synchronous     bool     `json:"synchronous"`
+	Disabled        bool     `json:"disabled"`
+	SupportedEvents []string `json:"supported_events,omitempty"`
+
+	// Latency of handlings, it's nil when the subscriber did not handle any event
+	Latency *LatencyStats `json:"latency,omitempty"`
+}
+
+type subscription struct {
+	id         string
+	subscriber Subscriber
+	bus        *DefaultEventBus
+
+	// seq is the registration order
+	seq         int64
+	priority    int
+	synchronous bool
+
+	// seenEvents holds names of events that
+	// are supported by the subscriber at runtime
+	seenEvents   map[string]bool
+	seenEventsMu sync.RWMutex
+}
+
+func newSubscription(id string, seq int64, subscriber Subscriber, bus *DefaultEventBus) *subscription {
+	sub := &subscription{
+		id:         id,
+		seq:        seq,
+		subscriber: subscriber,
+		bus:        bus,
+		seenEvents: make(map[string]bool),
+	}
+	if prioritized, ok := subscriber.(PrioritizedSubscriber); ok {
+		sub.priority = prioritized.Priority()
+	}
+	if synchronous, ok := subscriber.(SynchronousSubscriber); ok {
+		sub.synchronous = synchronous.Synchronous()
+	}
+	return sub
+}
+
+func (s *subscription) Id() string {
+	return s.id
+}
+
+func (s *subscription) Unsubscribe() {
+	s.bus.unsubscribe(s)
+}
+
+// supports returns whether the subscriber supports the event,
+// names of supported events are recorded.
+func (s *subscription) supports(event Event) bool {
+	if !s.subscriber.Supports(event) {
+		return false
+	}
+	s.seenEventsMu.RLock()
+	seen := s.seenEvents[event.Name()]
+	s.seenEventsMu.RUnlock()
+	if !seen {
+		s.seenEventsMu.Lock()
+		s.seenEvents[event.Name()] = true
+		s.seenEventsMu.Unlock()
+	}
+	return true
+}
+
+func (s *subscription) info() SubscriberInfo {
+	supportedEvents := make(map[string]bool)
+	if provider, ok := s.subscriber.(SupportedEventsProvider); ok {
+		for _, name := range provider.SupportedEvents() {
+			supportedEvents[name] = true
+		}
+	}
+	s.seenEventsMu.RLock()
+	for name := range s.seenEvents {
+		supportedEvents[name] = true
+	}
+	s.seenEventsMu.RUnlock()
+	info := SubscriberInfo{
+		Id:              s.id,
+		Type:            reflect.TypeOf(s.subscriber).String(),
+		Priority:        s.priority,
+		Synchronous:     s.synchronous,
+		SupportedEvents: make([]string, 0, len(supportedEvents)),
+	}
+	for name := range supportedEvents {
+		info.SupportedEvents = append(info.SupportedEvents, name)
+	}
+	sort.Strings(info.SupportedEvents)
+	return info
+}
+
+// dispatchedBefore reports whether the subscription is dispatched before the other,
+// by priority (higher first) then by registration order.
+func (s *subscription) dispatchedBefore(other *subscription) bool {
+	if s.priority != other.priority {
+		return s.priority > other.priority
+	}
+	return s.seq < other.seq
+}
+
+// sameInstance reports whether two subscribers are the same instance
+func sameInstance(s1 Subscriber, s2 Subscriber) bool {
+	v1, v2 := reflect.ValueOf(s1), reflect.ValueOf(s2)
+	if v1.Type() != v2.Type() {
+		return false
+	}
+	if v1.Kind() == reflect.Ptr {
+		return v1.Pointer() == v2.Pointer()
+	}
+	if v1.Type().Comparable() {
+		return s1 == s2
+	}
+	return false
+}
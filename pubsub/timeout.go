@@ -0,0 +1,162 @@
+package pubsub
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// TimeoutSubscriber is an optional interface for a Subscriber
+// to declare its own handler timeout, it takes precedence over configured timeouts.
+type TimeoutSubscriber interface {
+	Subscriber
+
+	// HandlerTimeout returns the maximum duration of a handling attempt, zero means no timeout
+	HandlerTimeout() time.Duration
+}
+
+// HandlerTimeoutError is returned when a handling attempt exceeded the handler timeout
+type HandlerTimeoutError struct {
+	SubscriberId string
+	Timeout      time.Duration
+	Err          error
+
+	// Abandoned is true when the handler did not return at the deadline, it keeps running in background.
+	// An abandoned attempt is not retried, so that the handler never runs concurrently for an event.
+	Abandoned bool
+}
+
+func (e *HandlerTimeoutError) Error() string {
+	return fmt.Sprintf("subscriber [%s] did not finish handling in [%s]: %v", e.SubscriberId, e.Timeout, e.Err)
+}
+
+func (e *HandlerTimeoutError) Is(target error) bool {
+	return target == ErrHandlerTimeout
+}
+
+func (e *HandlerTimeoutError) Unwrap() error {
+	return e.Err
+}
+
+// handlerContext carries values of the event context, but it's only done when the bus is aborted,
+// so that a handler isn't cancelled when the request that published the event is finished.
+type handlerContext struct {
+	context.Context
+	values context.Context
+}
+
+func (c handlerContext) Value(key interface{}) interface{} {
+	return c.values.Value(key)
+}
+
+func (b *DefaultEventBus) handlerTimeoutOf(subscriberId string, subscriber Subscriber) time.Duration {
+	if timeoutSubscriber, ok := subscriber.(TimeoutSubscriber); ok {
+		if timeout := timeoutSubscriber.HandlerTimeout(); timeout > 0 {
+			return timeout
+		}
+	}
+	if timeout, exists := overrideOf(b.subscriberHandlerTimeouts, subscriberId, subscriber); exists {
+		return timeout
+	}
+	return b.handlerTimeout
+}
+
+// handleAttempt runs a handling attempt with a context derived from ctx,
+// the context is cancelled when the handler timeout is exceeded or the bus shutdown is aborted.
+//
+// When the subscriber has a handler timeout, the handler runs in another goroutine,
+// the attempt is failed with HandlerTimeoutError at the deadline even if the handler
+// does not respect the context, such as Subscriber.Handle. The executor slot is
+// released then, but the handler keeps running until it returns,
+// the bus shutdown waits for it as an in-flight handling.
+func (b *DefaultEventBus) handleAttempt(ctx context.Context, subscriberId string, subscriber Subscriber, event Event) error {
+	handlerCtx := context.Context(handlerContext{Context: b.ctx, values: ctx})
+	timeout := b.handlerTimeoutOf(subscriberId, subscriber)
+	if timeout <= 0 {
+		return b.intercept(handlerCtx, subscriberId, subscriber, event)
+	}
+	handlerCtx, cancel := context.WithTimeout(handlerCtx, timeout)
+	defer cancel()
+	result := make(chan error, 1)
+	b.attempts.add()
+	go func() {
+		defer b.attempts.done()
+		result <- b.intercept(handlerCtx, subscriberId, subscriber, event)
+	}()
+	var err error
+	abandoned := false
+	select {
+	case err = <-result:
+	case <-handlerCtx.Done():
+		// The handler may return at the same time
+		select {
+		case err = <-result:
+		default:
+			err, abandoned = handlerCtx.Err(), true
+		}
+	}
+	// A failure after the context is done is caused by the timeout or the shutdown
+	if err == nil || handlerCtx.Err() == nil {
+		return err
+	}
+	if !errors.Is(handlerCtx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("subscriber [%s] is cancelled by the bus shutdown: %w", subscriberId, handlerCtx.Err())
+	}
+	atomic.AddInt64(&b.timeoutCount, 1)
+	b.metrics.timedOut(event)
+	b.debugLog(ctx, "Subscriber [%s] timed out handling event [%s] with id [%s] after [%s]",
+		subscriberId, event.Name(), event.Identifier(), timeout)
+	return &HandlerTimeoutError{SubscriberId: subscriberId, Timeout: timeout, Err: handlerCtx.Err(), Abandoned: abandoned}
+}
+
+// isAbandoned returns whether the error is of an attempt that is still running
+func isAbandoned(err error) bool {
+	var timeoutErr *HandlerTimeoutError
+	return errors.As(err, &timeoutErr) && timeoutErr.Abandoned
+}
+
+// attemptGroup tracks handler goroutines of handling attempts,
+// that may keep running after their attempts are timeout.
+type attemptGroup struct {
+	running int
+	idleCh  chan struct{}
+	mu      sync.Mutex
+}
+
+func (g *attemptGroup) add() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.running == 0 {
+		g.idleCh = make(chan struct{})
+	}
+	g.running++
+}
+
+func (g *attemptGroup) done() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.running--
+	if g.running == 0 {
+		close(g.idleCh)
+	}
+}
+
+// idle returns a channel that is closed when no handler goroutine is running
+func (g *attemptGroup) idle() <-chan struct{} {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.running == 0 {
+		idleCh := make(chan struct{})
+		close(idleCh)
+		return idleCh
+	}
+	return g.idleCh
+}
+
+// TimeoutCount returns the number of handling attempts that exceeded the handler timeout
+func (b *DefaultEventBus) TimeoutCount() int64 {
+	return atomic.LoadInt64(&b.timeoutCount)
+}
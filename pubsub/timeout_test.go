@@ -0,0 +1,194 @@
+package pubsub
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golibs-starter/golib/pubsub/executor"
+	assert "github.com/stretchr/testify/require"
+)
+
+type DummyTimeoutSubscriber struct {
+	id      string
+	timeout time.Duration
+	handle  func(ctx context.Context, event Event) error
+}
+
+func (d *DummyTimeoutSubscriber) SubscriberId() string {
+	return d.id
+}
+
+func (d *DummyTimeoutSubscriber) Supports(event Event) bool {
+	return true
+}
+
+func (d *DummyTimeoutSubscriber) Handle(event Event) {
+}
+
+func (d *DummyTimeoutSubscriber) HandleWithError(ctx context.Context, event Event) error {
+	return d.handle(ctx, event)
+}
+
+func (d *DummyTimeoutSubscriber) HandlerTimeout() time.Duration {
+	return d.timeout
+}
+
+type reportedError struct {
+	subscriberId string
+	err          error
+}
+
+func newTimeoutBus(t *testing.T, opts ...EventBusOpt) (*DefaultEventBus, chan reportedError) {
+	errCh := make(chan reportedError, 10)
+	bus := NewDefaultEventBus(append([]EventBusOpt{
+		WithEventExecutor(executor.NewAsyncExecutor()),
+		WithEventErrorHandler(func(event Event, subscriberId string, err error, stack []byte) {
+			errCh <- reportedError{subscriberId: subscriberId, err: err}
+		}),
+	}, opts...)...)
+	bus.Run()
+	t.Cleanup(bus.Stop)
+	return bus, errCh
+}
+
+func TestDefaultEventBus_WhenHandlerTimeoutExceeded_ShouldCancelContextAndReportError(t *testing.T) {
+	bus, errCh := newTimeoutBus(t, WithHandlerTimeout(20*time.Millisecond))
+	bus.Register(&DummyTimeoutSubscriber{id: "slow", handle: func(ctx context.Context, event Event) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}})
+	assert.NoError(t, bus.TryDeliver(context.Background(), &DummyEvent{name: "event-1"}))
+
+	select {
+	case reported := <-errCh:
+		assert.Equal(t, "slow", reported.subscriberId)
+		assert.ErrorIs(t, reported.err, ErrHandlerTimeout)
+		assert.ErrorIs(t, reported.err, context.DeadlineExceeded)
+		var timeoutErr *HandlerTimeoutError
+		assert.ErrorAs(t, reported.err, &timeoutErr)
+		assert.Equal(t, 20*time.Millisecond, timeoutErr.Timeout)
+	case <-time.After(time.Second):
+		t.Fatal("timeout is not reported")
+	}
+	assert.Equal(t, int64(1), bus.TimeoutCount())
+	assert.Equal(t, int64(1), bus.EventMetrics()["event-1"].TimedOut)
+}
+
+func TestDefaultEventBus_WhenHandlerIgnoresContext_ShouldReleaseAttemptAtDeadline(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	bus, errCh := newTimeoutBus(t,
+		WithRetryPolicy(RetryPolicy{MaxAttempts: 2}),
+		WithSubscriberHandlerTimeout("stuck", 20*time.Millisecond))
+	bus.Register(&DummyTimeoutSubscriber{id: "stuck", handle: func(ctx context.Context, event Event) error {
+		<-release
+		return nil
+	}})
+	assert.NoError(t, bus.TryDeliver(context.Background(), &DummyEvent{name: "event-1"}))
+
+	select {
+	case reported := <-errCh:
+		assert.ErrorIs(t, reported.err, ErrHandlerTimeout)
+		var timeoutErr *HandlerTimeoutError
+		assert.ErrorAs(t, reported.err, &timeoutErr)
+		assert.True(t, timeoutErr.Abandoned)
+	case <-time.After(time.Second):
+		t.Fatal("timeout is not reported")
+	}
+	// The abandoned attempt is not retried
+	assert.Equal(t, int64(1), bus.TimeoutCount())
+}
+
+func TestDefaultEventBus_WhenAttemptIsAbandoned_ShouldNotRunHandlerConcurrently(t *testing.T) {
+	var running, maxRunning int32
+	release := make(chan struct{})
+	bus, errCh := newTimeoutBus(t,
+		WithRetryPolicy(RetryPolicy{MaxAttempts: 3}),
+		WithSubscriberHandlerTimeout("stuck", 20*time.Millisecond))
+	bus.Register(&DummyTimeoutSubscriber{id: "stuck", handle: func(ctx context.Context, event Event) error {
+		current := atomic.AddInt32(&running, 1)
+		defer atomic.AddInt32(&running, -1)
+		for {
+			observed := atomic.LoadInt32(&maxRunning)
+			if current <= observed || atomic.CompareAndSwapInt32(&maxRunning, observed, current) {
+				break
+			}
+		}
+		<-release
+		return nil
+	}})
+	assert.NoError(t, bus.TryDeliver(context.Background(), &DummyEvent{name: "event-1"}))
+	select {
+	case <-errCh:
+	case <-time.After(time.Second):
+		t.Fatal("timeout is not reported")
+	}
+
+	// Shutdown waits for the abandoned handler
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, bus.Shutdown(ctx), context.DeadlineExceeded)
+	close(release)
+	assert.Eventually(t, func() bool {
+		return atomic.LoadInt32(&running) == 0
+	}, time.Second, 5*time.Millisecond)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&maxRunning))
+}
+
+func TestDefaultEventBus_handlerTimeoutOf_ShouldPreferSubscriberTimeout(t *testing.T) {
+	bus := NewDefaultEventBus(
+		WithHandlerTimeout(time.Second),
+		WithSubscriberHandlerTimeout("configured", 2*time.Second),
+	)
+	assert.Equal(t, time.Second, bus.handlerTimeoutOf("other", &DummySubscriber1{}))
+	assert.Equal(t, 2*time.Second, bus.handlerTimeoutOf("configured", &DummyTimeoutSubscriber{}))
+	assert.Equal(t, 3*time.Second, bus.handlerTimeoutOf("configured", &DummyTimeoutSubscriber{timeout: 3 * time.Second}))
+}
+
+func TestDefaultEventBus_WhenShutdownIsAborted_ShouldCancelHandlerContext(t *testing.T) {
+	bus, errCh := newTimeoutBus(t)
+	started := make(chan struct{})
+	bus.Register(&DummyTimeoutSubscriber{id: "waiting", handle: func(ctx context.Context, event Event) error {
+		close(started)
+		<-ctx.Done()
+		return ctx.Err()
+	}})
+	assert.NoError(t, bus.TryDeliver(context.Background(), &DummyEvent{name: "event-1"}))
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	assert.Error(t, bus.Shutdown(ctx))
+	select {
+	case reported := <-errCh:
+		assert.ErrorIs(t, reported.err, context.Canceled)
+	case <-time.After(time.Second):
+		t.Fatal("handler context is not cancelled")
+	}
+}
+
+func TestDefaultEventBus_WhenEventContextIsCancelled_ShouldKeepHandlerContext(t *testing.T) {
+	bus, _ := newTimeoutBus(t, WithHandlerTimeout(time.Second))
+	type key struct{}
+	handled := make(chan error, 1)
+	bus.Register(&DummyTimeoutSubscriber{id: "subscriber", handle: func(ctx context.Context, event Event) error {
+		if ctx.Value(key{}) != "value" {
+			handled <- errors.New("value of the event context is missing")
+			return nil
+		}
+		handled <- ctx.Err()
+		return nil
+	}})
+	eventCtx, cancel := context.WithCancel(context.WithValue(context.Background(), key{}, "value"))
+	cancel()
+	assert.NoError(t, bus.TryDeliver(context.Background(), &DummyEvent{name: "event-1", ctx: eventCtx}))
+	select {
+	case err := <-handled:
+		assert.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("event is not handled")
+	}
+}
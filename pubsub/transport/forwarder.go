@@ -0,0 +1,113 @@
+package transport
+
+import (
+	"context"
+	"reflect"
+
+	"github.com/golibs-starter/golib/pubsub"
+	"github.com/golibs-starter/golib/utils"
+)
+
+// Forwarder is a subscriber that sends selected events to a transport.
+// Because it's a regular subscriber, failed sending is retried by the retry policy
+// of the bus and sent to the dead letter sink. Events that are received from
+// a transport are never forwarded, to avoid loops.
+type Forwarder struct {
+	id        string
+	transport EventTransport
+	encoder   Encoder
+	names     []string
+	types     []reflect.Type
+}
+
+type ForwarderOpt func(f *Forwarder)
+
+// NewForwarder creates a Forwarder, it forwards nothing until events are selected
+// by ForwardEvents or ForwardType.
+func NewForwarder(transport EventTransport, opts ...ForwarderOpt) *Forwarder {
+	f := &Forwarder{
+		id:        "transport.Forwarder",
+		transport: transport,
+	}
+	for _, opt := range opts {
+		opt(f)
+	}
+	if f.encoder == nil {
+		f.encoder = DefaultEncoder
+	}
+	return f
+}
+
+// WithForwarderId sets the subscriber id, it's required when there are many forwarders
+func WithForwarderId(id string) ForwarderOpt {
+	return func(f *Forwarder) {
+		f.id = id
+	}
+}
+
+// WithForwarderEncoder sets the encoder of events
+func WithForwarderEncoder(encoder Encoder) ForwarderOpt {
+	return func(f *Forwarder) {
+		f.encoder = encoder
+	}
+}
+
+// ForwardEvents selects events by name, `*` in a pattern matches any sequence of characters
+func ForwardEvents(patterns ...string) ForwarderOpt {
+	return func(f *Forwarder) {
+		f.names = append(f.names, patterns...)
+	}
+}
+
+// ForwardType selects events that the event or its payload is assignable to T
+func ForwardType[T any]() ForwarderOpt {
+	return func(f *Forwarder) {
+		f.types = append(f.types, reflect.TypeOf((*T)(nil)).Elem())
+	}
+}
+
+func (f *Forwarder) SubscriberId() string {
+	return f.id
+}
+
+func (f *Forwarder) SupportedEvents() []string {
+	events := append([]string{}, f.names...)
+	for _, t := range f.types {
+		events = append(events, t.String())
+	}
+	return events
+}
+
+func (f *Forwarder) Supports(event pubsub.Event) bool {
+	if IsInbound(event) {
+		return false
+	}
+	if utils.MatchAnyWildcard(f.names, event.Name()) {
+		return true
+	}
+	for _, t := range f.types {
+		if reflect.TypeOf(event).AssignableTo(t) {
+			return true
+		}
+		if payload := event.Payload(); payload != nil && reflect.TypeOf(payload).AssignableTo(t) {
+			return true
+		}
+	}
+	return false
+}
+
+func (f *Forwarder) Handle(event pubsub.Event) {
+	ctx := event.Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	_ = f.HandleWithError(ctx, event)
+}
+
+func (f *Forwarder) HandleWithError(ctx context.Context, event pubsub.Event) error {
+	msg, err := f.encoder(event)
+	if err != nil {
+		return pubsub.NonRetryable(err)
+	}
+	return f.transport.Send(ctx, msg)
+}
@@ -0,0 +1,70 @@
+package transport
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/golibs-starter/golib/log"
+	"github.com/golibs-starter/golib/pubsub"
+)
+
+// Receiver receives messages from a transport, decodes them
+// and delivers the events on the local bus.
+type Receiver struct {
+	transport EventTransport
+	bus       pubsub.EventBus
+	decoder   Decoder
+	cancel    context.CancelFunc
+	done      chan struct{}
+	mu        sync.Mutex
+}
+
+// NewReceiver creates a Receiver, messages are decoded by DefaultDecoder when the decoder is nil
+func NewReceiver(transport EventTransport, bus pubsub.EventBus, decoder Decoder) *Receiver {
+	if decoder == nil {
+		decoder = DefaultDecoder
+	}
+	return &Receiver{transport: transport, bus: bus, decoder: decoder}
+}
+
+// Handle decodes a message and delivers the event on the bus,
+// a message that cannot be decoded or delivered is not acknowledged.
+func (r *Receiver) Handle(ctx context.Context, msg *Message) error {
+	inboundCtx, mark := withInbound(ctx)
+	event, err := r.decoder(inboundCtx, msg)
+	if err != nil {
+		return err
+	}
+	mark.eventId = event.Identifier()
+	return pubsub.TryDeliverOn(ctx, r.bus, event)
+}
+
+// Start receiving messages in background until Stop is called
+func (r *Receiver) Start() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.cancel != nil {
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	r.cancel, r.done = cancel, make(chan struct{})
+	go func() {
+		defer close(r.done)
+		if err := r.transport.Receive(ctx, r.Handle); err != nil && !errors.Is(err, context.Canceled) {
+			log.WithErrors(err).Error("Event transport stopped receiving messages")
+		}
+	}()
+}
+
+// Stop receiving messages and wait until the receiving is stopped
+func (r *Receiver) Stop() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.cancel == nil {
+		return
+	}
+	r.cancel()
+	<-r.done
+	r.cancel = nil
+}
@@ -0,0 +1,148 @@
+package transport
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// StreamTransport writes messages as JSON lines to a writer
+// and reads messages from a reader. It's mainly used in tests and local tools.
+type StreamTransport struct {
+	writer  io.Writer
+	reader  io.Reader
+	closers []io.Closer
+	writeMu sync.Mutex
+}
+
+// NewStreamTransport creates a StreamTransport, the writer or the reader can be nil
+// if the transport is only used to receive or send.
+func NewStreamTransport(writer io.Writer, reader io.Reader, closers ...io.Closer) *StreamTransport {
+	return &StreamTransport{writer: writer, reader: reader, closers: closers}
+}
+
+// NewPipeTransport creates a StreamTransport that receives messages sent by itself
+func NewPipeTransport() *StreamTransport {
+	reader, writer := io.Pipe()
+	return NewStreamTransport(writer, reader, writer, reader)
+}
+
+// NewFileTransport creates a StreamTransport that appends messages to a file
+// and receives messages in the file, it keeps waiting for new messages at the end of file.
+func NewFileTransport(path string) (*StreamTransport, error) {
+	writer, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		_ = writer.Close()
+		return nil, err
+	}
+	reader := &tailReader{file: file, interval: 50 * time.Millisecond, closeCh: make(chan struct{})}
+	return NewStreamTransport(writer, reader, writer, reader), nil
+}
+
+func (t *StreamTransport) Send(ctx context.Context, messages ...*Message) error {
+	if t.writer == nil {
+		return errors.New("stream transport is not writable")
+	}
+	t.writeMu.Lock()
+	defer t.writeMu.Unlock()
+	for _, msg := range messages {
+		line, err := json.Marshal(msg)
+		if err != nil {
+			return err
+		}
+		if _, err := t.writer.Write(append(line, '\n')); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Receive messages line by line, there is no redelivery in a stream,
+// so messages that failed to handle are dropped.
+func (t *StreamTransport) Receive(ctx context.Context, handler MessageHandler) error {
+	if t.reader == nil {
+		return errors.New("stream transport is not readable")
+	}
+	messages := make(chan *Message)
+	readErr := make(chan error, 1)
+	go func() {
+		reader := bufio.NewReader(t.reader)
+		for {
+			line, err := reader.ReadBytes('\n')
+			if err != nil {
+				readErr <- err
+				return
+			}
+			var msg Message
+			if err := json.Unmarshal(line, &msg); err != nil {
+				continue
+			}
+			select {
+			case messages <- &msg:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	for {
+		select {
+		case msg := <-messages:
+			_ = handler(ctx, msg)
+		case err := <-readErr:
+			if err == io.EOF || errors.Is(err, io.ErrClosedPipe) || errors.Is(err, os.ErrClosed) {
+				return nil
+			}
+			return err
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func (t *StreamTransport) Close() error {
+	var firstErr error
+	for _, closer := range t.closers {
+		if err := closer.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// tailReader reads a file and waits for new data at the end of file until it's closed
+type tailReader struct {
+	file     *os.File
+	interval time.Duration
+	closeCh  chan struct{}
+	once     sync.Once
+}
+
+func (r *tailReader) Read(p []byte) (int, error) {
+	for {
+		n, err := r.file.Read(p)
+		if n > 0 || err != io.EOF {
+			return n, err
+		}
+		select {
+		case <-r.closeCh:
+			return 0, io.EOF
+		case <-time.After(r.interval):
+		}
+	}
+}
+
+func (r *tailReader) Close() error {
+	r.once.Do(func() {
+		close(r.closeCh)
+	})
+	return r.file.Close()
+}
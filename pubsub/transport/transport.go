@@ -0,0 +1,119 @@
+package transport
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/golibs-starter/golib/event"
+	"github.com/golibs-starter/golib/pubsub"
+	webEvent "github.com/golibs-starter/golib/web/event"
+)
+
+// Message is an encoded event that is sent over a transport
+type Message struct {
+	Id      string            `json:"id"`
+	Name    string            `json:"name"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Data    json.RawMessage   `json:"data"`
+}
+
+// MessageHandler handles a received message,
+// the message is acknowledged only when it returns no error.
+type MessageHandler func(ctx context.Context, msg *Message) error
+
+// EventTransport moves events out of the process and back.
+// Broker specific adapters (such as Kafka or NATS) implement this interface.
+type EventTransport interface {
+
+	// Send messages, a transport may batch messages,
+	// it returns when the messages are sent or failed to send.
+	Send(ctx context.Context, messages ...*Message) error
+
+	// Receive messages and call the handler for each one,
+	// it blocks until the context is done or the transport is closed.
+	Receive(ctx context.Context, handler MessageHandler) error
+
+	// Close the transport
+	Close() error
+}
+
+// Encoder encodes an event to a Message
+type Encoder func(event pubsub.Event) (*Message, error)
+
+// Decoder decodes a Message to an event, ctx should be used as the context of the event.
+type Decoder func(ctx context.Context, msg *Message) (pubsub.Event, error)
+
+// DefaultEncoder encodes an event by the global pubsub.EventTypeRegistry
+func DefaultEncoder(event pubsub.Event) (*Message, error) {
+	data, err := pubsub.GetEventTypeRegistry().Encode(event)
+	if err != nil {
+		return nil, err
+	}
+	return &Message{Id: event.Identifier(), Name: event.Name(), Data: data}, nil
+}
+
+// DefaultDecoder decodes a message by the global pubsub.EventTypeRegistry,
+// an unregistered event is decoded as *webEvent.AbstractEvent with a generic JSON payload.
+func DefaultDecoder(ctx context.Context, msg *Message) (pubsub.Event, error) {
+	return RegistryDecoder(pubsub.GetEventTypeRegistry())(ctx, msg)
+}
+
+// RegistryDecoder returns a Decoder that decodes messages by the registry
+func RegistryDecoder(registry *pubsub.EventTypeRegistry) Decoder {
+	return func(ctx context.Context, msg *Message) (pubsub.Event, error) {
+		return registry.DecodeNamed(ctx, msg.Name, msg.Data)
+	}
+}
+
+// DecodeAs returns a Decoder that decodes messages as pubsub.MessageEvent[T],
+// so they are handled by typed handlers, such as pubsub.Subscribe[T].
+func DecodeAs[T any]() Decoder {
+	return func(ctx context.Context, msg *Message) (pubsub.Event, error) {
+		e := pubsub.MessageEvent[T]{
+			AbstractEvent: &webEvent.AbstractEvent{ApplicationEvent: &event.ApplicationEvent{}},
+		}
+		if err := json.Unmarshal(msg.Data, &e); err != nil {
+			return nil, fmt.Errorf("cannot decode message [%s]: %w", msg.Name, err)
+		}
+		e.Ctx = webEvent.NewContext(ctx, e.AbstractEvent)
+		return e, nil
+	}
+}
+
+// DecoderByName returns a Decoder that selects a decoder by the message name,
+// messages without a decoder are decoded by DefaultDecoder.
+func DecoderByName(decoders map[string]Decoder) Decoder {
+	return func(ctx context.Context, msg *Message) (pubsub.Event, error) {
+		if decoder, exists := decoders[msg.Name]; exists {
+			return decoder(ctx, msg)
+		}
+		return DefaultDecoder(ctx, msg)
+	}
+}
+
+type inboundContextKey struct{}
+
+// inboundMark marks the event that is received from a transport. Events that are created
+// from the context of the received event inherit the mark, so it only matches the id of the received event.
+type inboundMark struct {
+	eventId string
+}
+
+// withInbound returns a context to decode a received message,
+// the mark is bound to the decoded event by its id.
+func withInbound(ctx context.Context) (context.Context, *inboundMark) {
+	mark := &inboundMark{}
+	return context.WithValue(ctx, inboundContextKey{}, mark), mark
+}
+
+// IsInbound returns whether the event is received from a transport,
+// events that are published while handling a received event are not inbound.
+func IsInbound(event pubsub.Event) bool {
+	ctx := event.Context()
+	if ctx == nil {
+		return false
+	}
+	mark, ok := ctx.Value(inboundContextKey{}).(*inboundMark)
+	return ok && mark.eventId != "" && mark.eventId == event.Identifier()
+}
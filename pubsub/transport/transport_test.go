@@ -0,0 +1,333 @@
+package transport
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golibs-starter/golib/event"
+	"github.com/golibs-starter/golib/pubsub"
+	"github.com/golibs-starter/golib/pubsub/executor"
+	webEvent "github.com/golibs-starter/golib/web/event"
+	assert "github.com/stretchr/testify/require"
+)
+
+type OrderShipped struct {
+	OrderId string `json:"order_id"`
+}
+
+type OrderRefunded struct {
+	OrderId string `json:"order_id"`
+}
+
+func newEvent[T any](name string, msg T) pubsub.MessageEvent[T] {
+	return pubsub.MessageEvent[T]{
+		AbstractEvent: webEvent.NewAbstractEvent(context.Background(), name),
+		PayloadData:   msg,
+	}
+}
+
+func newEventCtx[T any](ctx context.Context, name string, msg T) pubsub.MessageEvent[T] {
+	return pubsub.MessageEvent[T]{
+		AbstractEvent: webEvent.NewAbstractEvent(ctx, name),
+		PayloadData:   msg,
+	}
+}
+
+func newMessage(t *testing.T, id string) *Message {
+	msg, err := DefaultEncoder(newEvent("OrderPacked", OrderShipped{OrderId: id}))
+	assert.NoError(t, err)
+	return msg
+}
+
+func TestForwarder_ShouldSelectEventsByNameOrType(t *testing.T) {
+	forwarder := NewForwarder(NewPipeTransport(), ForwardEvents("Order*"), ForwardType[OrderRefunded]())
+	assert.True(t, forwarder.Supports(newEvent("OrderShipped", OrderShipped{})))
+	assert.True(t, forwarder.Supports(newEvent("Refunded", OrderRefunded{})))
+	assert.False(t, forwarder.Supports(newEvent("Shipped", OrderShipped{})))
+	assert.Equal(t, []string{"Order*", "transport.OrderRefunded"}, forwarder.SupportedEvents())
+
+	inbound := newEvent("OrderShipped", OrderShipped{})
+	ctx, mark := withInbound(context.Background())
+	inbound.Ctx, mark.eventId = ctx, inbound.Identifier()
+	assert.False(t, forwarder.Supports(inbound))
+}
+
+// sentTransport records names of sent messages
+type sentTransport struct {
+	names []string
+	mu    sync.Mutex
+}
+
+func (s *sentTransport) Send(ctx context.Context, messages ...*Message) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, msg := range messages {
+		s.names = append(s.names, msg.Name)
+	}
+	return nil
+}
+
+func (s *sentTransport) Receive(ctx context.Context, handler MessageHandler) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func (s *sentTransport) Close() error {
+	return nil
+}
+
+func TestForwarder_WhenReceivedEventIsHandled_ShouldForwardFollowUpEvents(t *testing.T) {
+	sent := &sentTransport{}
+	bus := pubsub.NewDefaultEventBus(pubsub.WithEventExecutor(executor.NewAsyncExecutor()))
+	bus.Register(NewForwarder(sent, ForwardEvents("Order*")))
+	inbound := make(chan bool, 1)
+	_, err := bus.RegisterHandler("OrderPacked", func(ctx context.Context, e pubsub.Event) error {
+		inbound <- IsInbound(e)
+		// The follow-up event is created from the context of the received event
+		return bus.TryDeliver(ctx, newEventCtx(ctx, "OrderRefunded", OrderRefunded{OrderId: "1"}))
+	})
+	assert.NoError(t, err)
+	bus.Run()
+
+	assert.NoError(t, NewReceiver(NewPipeTransport(), bus, nil).Handle(context.Background(), newMessage(t, "1")))
+	select {
+	case isInbound := <-inbound:
+		assert.True(t, isInbound)
+	case <-time.After(time.Second):
+		t.Fatal("event is not received")
+	}
+	assert.NoError(t, bus.Shutdown(context.Background()))
+	assert.Equal(t, []string{"OrderRefunded"}, sent.names)
+}
+
+func TestPipeTransport_ShouldForwardAndReceiveTypedEvents(t *testing.T) {
+	pipe := NewPipeTransport()
+
+	// The local bus forwards events to the pipe
+	localBus := pubsub.NewDefaultEventBus(pubsub.WithEventExecutor(executor.NewSyncExecutor()))
+	localBus.Register(NewForwarder(pipe, ForwardEvents("OrderShipped")))
+	localBus.Run()
+	defer localBus.Stop()
+
+	// The remote bus receives typed events from the pipe
+	received := make(chan OrderShipped, 1)
+	remoteBus := pubsub.NewDefaultEventBus()
+	_, err := pubsub.Subscribe(remoteBus, func(ctx context.Context, msg OrderShipped) error {
+		received <- msg
+		return nil
+	})
+	assert.NoError(t, err)
+	remoteBus.Run()
+	defer remoteBus.Stop()
+	receiver := NewReceiver(pipe, remoteBus, DecoderByName(map[string]Decoder{
+		"OrderShipped": DecodeAs[OrderShipped](),
+	}))
+	receiver.Start()
+
+	localBus.Deliver(newEvent("OrderShipped", OrderShipped{OrderId: "1"}))
+	select {
+	case msg := <-received:
+		assert.Equal(t, OrderShipped{OrderId: "1"}, msg)
+	case <-time.After(time.Second):
+		t.Fatal("event is not received")
+	}
+	assert.NoError(t, pipe.Close())
+	receiver.Stop()
+}
+
+func TestFileTransport_ShouldReceiveAppendedMessages(t *testing.T) {
+	transport, err := NewFileTransport(filepath.Join(t.TempDir(), "events.jsonl"))
+	assert.NoError(t, err)
+	assert.NoError(t, transport.Send(context.Background(), newMessage(t, "1")))
+
+	var mu sync.Mutex
+	received := make([]pubsub.Event, 0)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- transport.Receive(ctx, func(ctx context.Context, msg *Message) error {
+			e, err := DefaultDecoder(ctx, msg)
+			mu.Lock()
+			defer mu.Unlock()
+			received = append(received, e)
+			return err
+		})
+	}()
+	assert.NoError(t, transport.Send(context.Background(), newMessage(t, "2")))
+	assert.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(received) == 2
+	}, time.Second, 10*time.Millisecond)
+	cancel()
+	assert.ErrorIs(t, <-done, context.Canceled)
+	assert.NoError(t, transport.Close())
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.IsType(t, &webEvent.AbstractEvent{}, received[0])
+	assert.Equal(t, map[string]interface{}{"order_id": "1"}, received[0].Payload())
+	assert.Equal(t, "OrderPacked", received[1].Name())
+}
+
+func TestWebhookTransport_ShouldBatchMessages(t *testing.T) {
+	var batches [][]*Message
+	var mu sync.Mutex
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var messages []*Message
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&messages))
+		assert.Equal(t, "secret", r.Header.Get("X-Token"))
+		mu.Lock()
+		batches = append(batches, messages)
+		mu.Unlock()
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	transport := NewWebhookTransport(server.URL,
+		WithWebhookBatch(3, 20*time.Millisecond),
+		WithWebhookHeader("X-Token", "secret"),
+	)
+	defer transport.Close()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			assert.NoError(t, transport.Send(context.Background(), newMessage(t, string(rune('1'+i)))))
+		}(i)
+	}
+	wg.Wait()
+
+	mu.Lock()
+	defer mu.Unlock()
+	total := 0
+	for _, b := range batches {
+		assert.LessOrEqual(t, len(b), 3)
+		total += len(b)
+	}
+	assert.Equal(t, 4, total)
+	assert.GreaterOrEqual(t, len(batches), 2)
+}
+
+func TestWebhookTransport_ShouldRetryFailedPosts(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	policy := pubsub.RetryPolicy{MaxAttempts: 3, InitialInterval: time.Millisecond, MaxInterval: time.Millisecond, Multiplier: 1}
+	transport := NewWebhookTransport(server.URL, WithWebhookBatch(1, time.Millisecond), WithWebhookRetryPolicy(policy))
+	defer transport.Close()
+	assert.NoError(t, transport.Send(context.Background(), newMessage(t, "1")))
+	assert.Equal(t, int32(3), atomic.LoadInt32(&calls))
+
+	atomic.StoreInt32(&calls, -10)
+	err := transport.Send(context.Background(), newMessage(t, "2"))
+	var webhookErr *WebhookError
+	assert.ErrorAs(t, err, &webhookErr)
+	assert.Equal(t, http.StatusServiceUnavailable, webhookErr.StatusCode)
+}
+
+func TestWebhookTransport_WhenClientError_ShouldNotRetry(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	transport := NewWebhookTransport(server.URL, WithWebhookBatch(1, time.Millisecond))
+	defer transport.Close()
+	err := transport.Send(context.Background(), newMessage(t, "1"))
+	assert.Error(t, err)
+	assert.False(t, pubsub.IsRetryable(err))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+}
+
+func TestWebhookTransport_ShouldReceiveFromAnotherWebhookTransport(t *testing.T) {
+	receiving := NewWebhookTransport("")
+	defer receiving.Close()
+	server := httptest.NewServer(receiving)
+	defer server.Close()
+
+	bus := pubsub.NewDefaultEventBus()
+	received := make(chan pubsub.Event, 2)
+	_, err := bus.RegisterHandler("OrderPacked", func(e pubsub.Event) {
+		received <- e
+	})
+	assert.NoError(t, err)
+	bus.Run()
+	defer bus.Stop()
+	receiver := NewReceiver(receiving, bus, nil)
+
+	sending := NewWebhookTransport(server.URL, WithWebhookBatch(2, time.Second),
+		WithWebhookRetryPolicy(pubsub.RetryPolicy{MaxAttempts: 1}))
+	defer sending.Close()
+	err = sending.Send(context.Background(), newMessage(t, "1"), newMessage(t, "2"))
+	var webhookErr *WebhookError
+	assert.ErrorAs(t, err, &webhookErr)
+	assert.Equal(t, http.StatusServiceUnavailable, webhookErr.StatusCode)
+
+	receiver.Start()
+	defer receiver.Stop()
+	assert.Eventually(t, func() bool {
+		return sending.Send(context.Background(), newMessage(t, "1"), newMessage(t, "2")) == nil
+	}, time.Second, 10*time.Millisecond)
+	for i := 0; i < 2; i++ {
+		e := <-received
+		assert.IsType(t, &webEvent.AbstractEvent{}, e)
+		assert.IsType(t, &event.ApplicationEvent{}, e.(*webEvent.AbstractEvent).ApplicationEvent)
+	}
+}
+
+func TestWebhookTransport_WhenBatchIsRetried_ShouldSkipHandledMessages(t *testing.T) {
+	receiving := NewWebhookTransport("")
+	defer receiving.Close()
+	server := httptest.NewServer(receiving)
+	defer server.Close()
+
+	var mu sync.Mutex
+	handled := make(map[string]int)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		_ = receiving.Receive(ctx, func(ctx context.Context, msg *Message) error {
+			mu.Lock()
+			defer mu.Unlock()
+			handled[msg.Id]++
+			if handled[msg.Id] == 1 && len(handled) == 2 {
+				return errors.New("second message failed at the first time")
+			}
+			return nil
+		})
+	}()
+	msg1, msg2 := newMessage(t, "1"), newMessage(t, "2")
+	policy := pubsub.RetryPolicy{MaxAttempts: 2, InitialInterval: time.Millisecond, MaxInterval: time.Millisecond, Multiplier: 1}
+	sending := NewWebhookTransport(server.URL, WithWebhookBatch(2, time.Second), WithWebhookRetryPolicy(policy))
+	defer sending.Close()
+	assert.Eventually(t, func() bool {
+		receiving.mu.Lock()
+		defer receiving.mu.Unlock()
+		return receiving.handler != nil
+	}, time.Second, time.Millisecond)
+	assert.NoError(t, sending.Send(context.Background(), msg1, msg2))
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, map[string]int{msg1.Id: 1, msg2.Id: 2}, handled)
+}
@@ -0,0 +1,324 @@
+package transport
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/golibs-starter/golib/pubsub"
+)
+
+var ErrTransportClosed = errors.New("transport is closed")
+
+// WebhookError is returned when the webhook responds an unexpected status
+type WebhookError struct {
+	StatusCode int
+}
+
+func (e *WebhookError) Error() string {
+	return fmt.Sprintf("webhook responded with status [%d]", e.StatusCode)
+}
+
+// batch is a group of messages that are posted together,
+// senders wait until the batch is done.
+type batch struct {
+	messages []*Message
+	done     chan struct{}
+	err      error
+}
+
+// WebhookTransport posts messages as a JSON array to a webhook url.
+// Messages are batched until the batch size is reached or the flush interval elapsed,
+// failed posts are retried by the retry policy. Senders wait for the result of their batch.
+//
+// WebhookTransport is also a http.Handler, that receives batches posted by another
+// WebhookTransport and passes messages to the handler registered by Receive.
+// Ids of handled messages are remembered, so that messages of a retried batch
+// that were handled already are skipped.
+type WebhookTransport struct {
+	url           string
+	client        *http.Client
+	headers       map[string]string
+	batchSize     int
+	flushInterval time.Duration
+	retryPolicy   pubsub.RetryPolicy
+
+	current   *batch
+	timer     *time.Timer
+	handler   MessageHandler
+	closed    bool
+	mu        sync.Mutex
+	ctx       context.Context
+	cancel    context.CancelFunc
+	inFlights sync.WaitGroup
+
+	// handledIds is a bounded set of handled message ids, the oldest id is evicted first
+	handledIds   map[string]struct{}
+	handledOrder []string
+	handledSize  int
+	handledIdsMu sync.Mutex
+}
+
+type WebhookOpt func(t *WebhookTransport)
+
+func NewWebhookTransport(url string, opts ...WebhookOpt) *WebhookTransport {
+	t := &WebhookTransport{
+		url:           url,
+		client:        http.DefaultClient,
+		headers:       make(map[string]string),
+		batchSize:     100,
+		flushInterval: 100 * time.Millisecond,
+		retryPolicy: pubsub.RetryPolicy{
+			MaxAttempts:     3,
+			InitialInterval: 100 * time.Millisecond,
+			MaxInterval:     5 * time.Second,
+			Multiplier:      2,
+		},
+		handledIds:  make(map[string]struct{}),
+		handledSize: 10000,
+	}
+	for _, opt := range opts {
+		opt(t)
+	}
+	if t.batchSize < 1 {
+		t.batchSize = 1
+	}
+	t.ctx, t.cancel = context.WithCancel(context.Background())
+	return t
+}
+
+func WithWebhookClient(client *http.Client) WebhookOpt {
+	return func(t *WebhookTransport) {
+		t.client = client
+	}
+}
+
+// WithWebhookHeader adds a header to requests, such as an authorization header
+func WithWebhookHeader(key string, value string) WebhookOpt {
+	return func(t *WebhookTransport) {
+		t.headers[key] = value
+	}
+}
+
+// WithWebhookBatch sets the maximum number of messages in a request
+// and the maximum time that a message waits for its batch.
+func WithWebhookBatch(size int, flushInterval time.Duration) WebhookOpt {
+	return func(t *WebhookTransport) {
+		t.batchSize = size
+		t.flushInterval = flushInterval
+	}
+}
+
+// WithWebhookRetryPolicy sets the retry policy of failed posts
+func WithWebhookRetryPolicy(policy pubsub.RetryPolicy) WebhookOpt {
+	return func(t *WebhookTransport) {
+		t.retryPolicy = policy
+	}
+}
+
+// WithWebhookHandledIds sets the number of handled message ids that are remembered
+// to skip duplicated messages of retried batches, zero disables the skipping.
+func WithWebhookHandledIds(size int) WebhookOpt {
+	return func(t *WebhookTransport) {
+		t.handledSize = size
+	}
+}
+
+func (t *WebhookTransport) Send(ctx context.Context, messages ...*Message) error {
+	if len(messages) == 0 {
+		return nil
+	}
+	t.mu.Lock()
+	if t.closed {
+		t.mu.Unlock()
+		return ErrTransportClosed
+	}
+	if t.current == nil {
+		t.current = &batch{done: make(chan struct{})}
+		t.timer = time.AfterFunc(t.flushInterval, t.flushCurrent)
+	}
+	b := t.current
+	b.messages = append(b.messages, messages...)
+	if len(b.messages) >= t.batchSize {
+		t.takeCurrent()
+		t.mu.Unlock()
+		t.flush(b)
+	} else {
+		t.mu.Unlock()
+	}
+	select {
+	case <-b.done:
+		return b.err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// takeCurrent detaches the current batch, the caller must hold the lock
+func (t *WebhookTransport) takeCurrent() *batch {
+	b := t.current
+	t.current = nil
+	if t.timer != nil {
+		t.timer.Stop()
+		t.timer = nil
+	}
+	if b != nil {
+		t.inFlights.Add(1)
+	}
+	return b
+}
+
+func (t *WebhookTransport) flushCurrent() {
+	t.mu.Lock()
+	b := t.takeCurrent()
+	t.mu.Unlock()
+	if b != nil {
+		t.flush(b)
+	}
+}
+
+// flush posts a batch with retries, then releases its senders
+func (t *WebhookTransport) flush(b *batch) {
+	defer t.inFlights.Done()
+	defer close(b.done)
+	body, err := json.Marshal(b.messages)
+	if err != nil {
+		b.err = err
+		return
+	}
+	for attempt := 1; ; attempt++ {
+		b.err = t.post(body)
+		if b.err == nil || !t.retryPolicy.ShouldRetry(attempt, b.err) {
+			return
+		}
+		timer := time.NewTimer(t.retryPolicy.Backoff(attempt))
+		select {
+		case <-timer.C:
+		case <-t.ctx.Done():
+			timer.Stop()
+			return
+		}
+	}
+}
+
+func (t *WebhookTransport) post(body []byte) error {
+	req, err := http.NewRequestWithContext(t.ctx, http.MethodPost, t.url, bytes.NewReader(body))
+	if err != nil {
+		return pubsub.NonRetryable(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range t.headers {
+		req.Header.Set(key, value)
+	}
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+	webhookErr := &WebhookError{StatusCode: resp.StatusCode}
+	if resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests {
+		return webhookErr
+	}
+	return pubsub.NonRetryable(webhookErr)
+}
+
+// Receive registers the handler for requests that served by ServeHTTP,
+// it blocks until the context is done or the transport is closed.
+func (t *WebhookTransport) Receive(ctx context.Context, handler MessageHandler) error {
+	t.mu.Lock()
+	t.handler = handler
+	t.mu.Unlock()
+	defer func() {
+		t.mu.Lock()
+		t.handler = nil
+		t.mu.Unlock()
+	}()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.ctx.Done():
+		return nil
+	}
+}
+
+// ServeHTTP receives a batch of messages. It responds 202 when all messages are handled,
+// 503 when no receiver is registered, or 500 when a message failed to handle
+// so that the sender will retry the batch. Messages that were handled before are skipped,
+// so a retried batch doesn't deliver them again.
+func (t *WebhookTransport) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	t.mu.Lock()
+	handler := t.handler
+	t.mu.Unlock()
+	if handler == nil {
+		http.Error(w, "no receiver", http.StatusServiceUnavailable)
+		return
+	}
+	var messages []*Message
+	if err := json.NewDecoder(r.Body).Decode(&messages); err != nil {
+		http.Error(w, "invalid messages", http.StatusBadRequest)
+		return
+	}
+	for _, msg := range messages {
+		if t.isHandled(msg.Id) {
+			continue
+		}
+		if err := handler(r.Context(), msg); err != nil {
+			http.Error(w, fmt.Sprintf("cannot handle message [%s]", msg.Id), http.StatusInternalServerError)
+			return
+		}
+		t.markHandled(msg.Id)
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func (t *WebhookTransport) isHandled(id string) bool {
+	t.handledIdsMu.Lock()
+	defer t.handledIdsMu.Unlock()
+	_, exists := t.handledIds[id]
+	return exists
+}
+
+func (t *WebhookTransport) markHandled(id string) {
+	if id == "" || t.handledSize <= 0 {
+		return
+	}
+	t.handledIdsMu.Lock()
+	defer t.handledIdsMu.Unlock()
+	if _, exists := t.handledIds[id]; exists {
+		return
+	}
+	if len(t.handledOrder) >= t.handledSize {
+		delete(t.handledIds, t.handledOrder[0])
+		t.handledOrder = t.handledOrder[1:]
+	}
+	t.handledIds[id] = struct{}{}
+	t.handledOrder = append(t.handledOrder, id)
+}
+
+// Close flushes the pending batch and stops retries
+func (t *WebhookTransport) Close() error {
+	t.mu.Lock()
+	if t.closed {
+		t.mu.Unlock()
+		return nil
+	}
+	t.closed = true
+	b := t.takeCurrent()
+	t.mu.Unlock()
+	if b != nil {
+		t.flush(b)
+	}
+	t.cancel()
+	t.inFlights.Wait()
+	return nil
+}
@@ -0,0 +1,38 @@
+package utils
+
+// MatchWildcard reports whether str matches the pattern,
+// where `*` in the pattern matches any sequence of characters (including empty).
+// Example: `order.*` matches `order.created` and `order.item.added`.
+func MatchWildcard(pattern string, str string) bool {
+	p, s := 0, 0
+	starIdx, matchIdx := -1, 0
+	for s < len(str) {
+		if p < len(pattern) && pattern[p] == '*' {
+			starIdx, matchIdx = p, s
+			p++
+		} else if p < len(pattern) && pattern[p] == str[s] {
+			p++
+			s++
+		} else if starIdx >= 0 {
+			p = starIdx + 1
+			matchIdx++
+			s = matchIdx
+		} else {
+			return false
+		}
+	}
+	for p < len(pattern) && pattern[p] == '*' {
+		p++
+	}
+	return p == len(pattern)
+}
+
+// MatchAnyWildcard reports whether str matches any of the patterns (see MatchWildcard).
+func MatchAnyWildcard(patterns []string, str string) bool {
+	for _, pattern := range patterns {
+		if MatchWildcard(pattern, str) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,46 @@
+package utils
+
+import "testing"
+
+func TestMatchWildcard(t *testing.T) {
+	tests := []struct {
+		name    string
+		pattern string
+		str     string
+		want    bool
+	}{
+		{name: "Test exact match", pattern: "order.created", str: "order.created", want: true},
+		{name: "Test exact not match", pattern: "order.created", str: "order.updated", want: false},
+		{name: "Test suffix wildcard", pattern: "order.*", str: "order.created", want: true},
+		{name: "Test suffix wildcard with nested name", pattern: "order.*", str: "order.item.added", want: true},
+		{name: "Test suffix wildcard not match", pattern: "order.*", str: "payment.created", want: false},
+		{name: "Test prefix wildcard", pattern: "*Event", str: "OrderCreatedEvent", want: true},
+		{name: "Test middle wildcard", pattern: "Order*Event", str: "OrderCreatedEvent", want: true},
+		{name: "Test middle wildcard not match", pattern: "Order*Event", str: "OrderCreated", want: false},
+		{name: "Test multiple wildcards", pattern: "*.item.*", str: "order.item.added", want: true},
+		{name: "Test only wildcard", pattern: "*", str: "anything", want: true},
+		{name: "Test wildcard match empty", pattern: "order*", str: "order", want: true},
+		{name: "Test empty pattern", pattern: "", str: "order", want: false},
+		{name: "Test empty pattern and empty string", pattern: "", str: "", want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := MatchWildcard(tt.pattern, tt.str); got != tt.want {
+				t.Errorf("MatchWildcard() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatchAnyWildcard(t *testing.T) {
+	patterns := []string{"order.*", "PaymentEvent"}
+	if !MatchAnyWildcard(patterns, "order.created") {
+		t.Errorf("MatchAnyWildcard() should match order.created")
+	}
+	if !MatchAnyWildcard(patterns, "PaymentEvent") {
+		t.Errorf("MatchAnyWildcard() should match PaymentEvent")
+	}
+	if MatchAnyWildcard(patterns, "RefundEvent") {
+		t.Errorf("MatchAnyWildcard() should not match RefundEvent")
+	}
+}
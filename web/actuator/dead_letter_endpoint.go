@@ -0,0 +1,46 @@
+package actuator
+
+import (
+	"errors"
+	"github.com/golibs-starter/golib/exception"
+	"github.com/golibs-starter/golib/pubsub"
+	"github.com/golibs-starter/golib/web/response"
+	"net/http"
+)
+
+// DeadLetterEndpoint exposes dead letters of the event bus,
+// and allows to re-drive them to the subscriber that failed to handle.
+type DeadLetterEndpoint struct {
+	store    pubsub.DeadLetterStore
+	redriver pubsub.DeadLetterRedriver
+}
+
+func NewDeadLetterEndpoint(store pubsub.DeadLetterStore, redriver pubsub.DeadLetterRedriver) *DeadLetterEndpoint {
+	return &DeadLetterEndpoint{
+		store:    store,
+		redriver: redriver,
+	}
+}
+
+// DeadLetters returns all dead letters
+func (c DeadLetterEndpoint) DeadLetters(w http.ResponseWriter, r *http.Request) {
+	response.Write(w, response.Ok(c.store.List()))
+}
+
+// Redrive re-delivers a dead letter, the dead letter id is passed by `id` query param
+func (c DeadLetterEndpoint) Redrive(w http.ResponseWriter, r *http.Request) {
+	id := r.URL.Query().Get("id")
+	if id == "" {
+		response.WriteError(w, exception.NewWithCause(exception.BadRequest, "id is required"))
+		return
+	}
+	if err := c.redriver.Redrive(id); err != nil {
+		if errors.Is(err, pubsub.ErrDeadLetterNotFound) {
+			response.WriteError(w, exception.NewWithCause(exception.NotFound, err.Error()))
+			return
+		}
+		response.WriteError(w, err)
+		return
+	}
+	response.Write(w, response.New(http.StatusAccepted, "Dead letter is re-driven", nil))
+}
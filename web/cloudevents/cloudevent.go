@@ -0,0 +1,211 @@
+package cloudevents
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"strings"
+	"time"
+)
+
+const SpecVersion = "1.0"
+
+const (
+	ContentTypeStructured = "application/cloudevents+json"
+	ContentTypeBatch      = "application/cloudevents-batch+json"
+	ContentTypeJSON       = "application/json"
+)
+
+// Extension attributes that carry the request attributes and the correlation chain of an event
+const (
+	ExtensionRequestId     = "requestid"
+	ExtensionUserId        = "userid"
+	ExtensionDeviceId      = "deviceid"
+	ExtensionServiceCode   = "servicecode"
+	ExtensionCorrelationId = "correlationid"
+	ExtensionCausationId   = "causationid"
+)
+
+const (
+	attrSpecVersion     = "specversion"
+	attrId              = "id"
+	attrSource          = "source"
+	attrType            = "type"
+	attrSubject         = "subject"
+	attrTime            = "time"
+	attrDataContentType = "datacontenttype"
+	attrDataSchema      = "dataschema"
+	attrData            = "data"
+	attrDataBase64      = "data_base64"
+)
+
+// CloudEvent is an event in the CloudEvents 1.0 format,
+// see https://github.com/cloudevents/spec/blob/v1.0.2/cloudevents/spec.md
+type CloudEvent struct {
+	SpecVersion     string
+	Id              string
+	Source          string
+	Type            string
+	Subject         string
+	Time            time.Time
+	DataContentType string
+	DataSchema      string
+	Data            []byte
+	Extensions      map[string]string
+}
+
+// Validate checks the required attributes of the event
+func (e *CloudEvent) Validate() error {
+	if e.SpecVersion != SpecVersion {
+		return fmt.Errorf("unsupported specversion [%s]", e.SpecVersion)
+	}
+	if e.Id == "" {
+		return errors.New("id is required")
+	}
+	if e.Source == "" {
+		return errors.New("source is required")
+	}
+	if e.Type == "" {
+		return errors.New("type is required")
+	}
+	return nil
+}
+
+// Extension returns value of an extension attribute, or empty when it's not set
+func (e *CloudEvent) Extension(name string) string {
+	return e.Extensions[name]
+}
+
+// SetExtension sets an extension attribute, an empty value removes the attribute
+func (e *CloudEvent) SetExtension(name string, value string) {
+	if value == "" {
+		delete(e.Extensions, name)
+		return
+	}
+	if e.Extensions == nil {
+		e.Extensions = make(map[string]string)
+	}
+	e.Extensions[name] = value
+}
+
+// MarshalJSON encodes the event in structured mode,
+// JSON data is embedded as is, other data is encoded in base64.
+func (e CloudEvent) MarshalJSON() ([]byte, error) {
+	doc := make(map[string]interface{}, len(e.Extensions)+8)
+	for name, value := range e.Extensions {
+		doc[name] = value
+	}
+	doc[attrSpecVersion] = e.SpecVersion
+	doc[attrId] = e.Id
+	doc[attrSource] = e.Source
+	doc[attrType] = e.Type
+	if e.Subject != "" {
+		doc[attrSubject] = e.Subject
+	}
+	if !e.Time.IsZero() {
+		doc[attrTime] = e.Time.Format(time.RFC3339Nano)
+	}
+	if e.DataContentType != "" {
+		doc[attrDataContentType] = e.DataContentType
+	}
+	if e.DataSchema != "" {
+		doc[attrDataSchema] = e.DataSchema
+	}
+	if len(e.Data) > 0 {
+		if isJSON(e.DataContentType) {
+			doc[attrData] = json.RawMessage(e.Data)
+		} else {
+			doc[attrDataBase64] = base64.StdEncoding.EncodeToString(e.Data)
+		}
+	}
+	return json.Marshal(doc)
+}
+
+// UnmarshalJSON decodes an event in structured mode,
+// unknown attributes are read as extensions.
+func (e *CloudEvent) UnmarshalJSON(data []byte) error {
+	var doc map[string]json.RawMessage
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return err
+	}
+	*e = CloudEvent{}
+	for name, raw := range doc {
+		switch name {
+		case attrData:
+			if string(raw) != "null" {
+				e.Data = raw
+			}
+			continue
+		case attrDataBase64:
+			var encoded string
+			if err := json.Unmarshal(raw, &encoded); err != nil {
+				return fmt.Errorf("invalid attribute [%s]: %w", name, err)
+			}
+			decoded, err := base64.StdEncoding.DecodeString(encoded)
+			if err != nil {
+				return fmt.Errorf("invalid attribute [%s]: %w", name, err)
+			}
+			e.Data = decoded
+			continue
+		}
+		var value string
+		if err := json.Unmarshal(raw, &value); err != nil {
+			// Extensions can be boolean or integer, keeps its JSON text
+			value = string(raw)
+		}
+		if err := e.setAttribute(name, value); err != nil {
+			return err
+		}
+	}
+	if len(e.Data) > 0 && !isJSON(e.DataContentType) && doc[attrData] != nil {
+		// Data of a non JSON content type is encoded as a JSON string
+		var value string
+		if err := json.Unmarshal(e.Data, &value); err == nil {
+			e.Data = []byte(value)
+		}
+	}
+	return nil
+}
+
+// setAttribute sets a context attribute or an extension by its name
+func (e *CloudEvent) setAttribute(name string, value string) error {
+	switch name {
+	case attrSpecVersion:
+		e.SpecVersion = value
+	case attrId:
+		e.Id = value
+	case attrSource:
+		e.Source = value
+	case attrType:
+		e.Type = value
+	case attrSubject:
+		e.Subject = value
+	case attrDataContentType:
+		e.DataContentType = value
+	case attrDataSchema:
+		e.DataSchema = value
+	case attrTime:
+		t, err := time.Parse(time.RFC3339Nano, value)
+		if err != nil {
+			return fmt.Errorf("invalid attribute [%s]: %w", name, err)
+		}
+		e.Time = t
+	default:
+		e.SetExtension(name, value)
+	}
+	return nil
+}
+
+// isJSON returns whether the content type is JSON, an empty content type is JSON by default
+func isJSON(contentType string) bool {
+	if contentType == "" {
+		return true
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return mediaType == ContentTypeJSON || mediaType == "text/json" || strings.HasSuffix(mediaType, "+json")
+}
@@ -0,0 +1,235 @@
+package cloudevents
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golibs-starter/golib/pubsub"
+	"github.com/golibs-starter/golib/pubsub/executor"
+	"github.com/golibs-starter/golib/web/constant"
+	webContext "github.com/golibs-starter/golib/web/context"
+	webEvent "github.com/golibs-starter/golib/web/event"
+	assert "github.com/stretchr/testify/require"
+)
+
+type InvoiceIssued struct {
+	InvoiceId string `json:"invoice_id"`
+	Amount    int64  `json:"amount"`
+}
+
+func newInvoiceEvent() pubsub.MessageEvent[InvoiceIssued] {
+	ctx := context.WithValue(context.Background(), constant.ContextReqAttribute, &webContext.RequestAttributes{
+		ServiceCode:   "billing",
+		CorrelationId: "request-1",
+		DeviceId:      "device 1",
+		SecurityAttributes: webContext.SecurityAttributes{
+			UserId: "user-1",
+		},
+	})
+	return pubsub.MessageEvent[InvoiceIssued]{
+		AbstractEvent: webEvent.NewAbstractEvent(ctx, "InvoiceIssued"),
+		PayloadData:   InvoiceIssued{InvoiceId: "1", Amount: 100},
+	}
+}
+
+func newRegistry(t *testing.T) *pubsub.EventTypeRegistry {
+	registry := pubsub.NewEventTypeRegistry()
+	assert.NoError(t, pubsub.RegisterMessage[InvoiceIssued](registry))
+	return registry
+}
+
+func TestFromEvent_ShouldMapApplicationEvent(t *testing.T) {
+	e := newInvoiceEvent()
+	ce, err := FromEvent(e)
+	assert.NoError(t, err)
+	assert.NoError(t, ce.Validate())
+	assert.Equal(t, e.Id, ce.Id)
+	assert.Equal(t, "InvoiceIssued", ce.Type)
+	assert.Equal(t, e.Source, ce.Source)
+	assert.Equal(t, e.Timestamp, ce.Time.UnixMilli())
+	assert.Equal(t, ContentTypeJSON, ce.DataContentType)
+	assert.JSONEq(t, `{"invoice_id":"1","amount":100}`, string(ce.Data))
+	assert.Equal(t, map[string]string{
+		ExtensionRequestId:     "request-1",
+		ExtensionCorrelationId: "request-1",
+		ExtensionUserId:        "user-1",
+		ExtensionDeviceId:      "device 1",
+		ExtensionServiceCode:   "billing",
+	}, ce.Extensions)
+}
+
+func TestCloudEvent_ShouldRoundTripStructuredMode(t *testing.T) {
+	ce, err := FromEvent(newInvoiceEvent())
+	assert.NoError(t, err)
+	ce.Subject = "invoices/1"
+	data, err := json.Marshal(ce)
+	assert.NoError(t, err)
+
+	var doc map[string]interface{}
+	assert.NoError(t, json.Unmarshal(data, &doc))
+	assert.Equal(t, "1.0", doc["specversion"])
+	assert.Equal(t, "request-1", doc["requestid"])
+	assert.Equal(t, map[string]interface{}{"invoice_id": "1", "amount": float64(100)}, doc["data"])
+
+	var decoded CloudEvent
+	assert.NoError(t, json.Unmarshal(data, &decoded))
+	assert.True(t, ce.Time.Equal(decoded.Time))
+	decoded.Time = ce.Time
+	assert.JSONEq(t, string(ce.Data), string(decoded.Data))
+	decoded.Data = ce.Data
+	assert.Equal(t, *ce, decoded)
+}
+
+func TestCloudEvent_GivenBinaryData_ShouldEncodeBase64(t *testing.T) {
+	ce := CloudEvent{SpecVersion: SpecVersion, Id: "1", Source: "test", Type: "Text", DataContentType: "text/plain", Data: []byte("hello")}
+	data, err := json.Marshal(ce)
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), `"data_base64":"aGVsbG8="`)
+
+	var decoded CloudEvent
+	assert.NoError(t, json.Unmarshal(data, &decoded))
+	assert.Equal(t, []byte("hello"), decoded.Data)
+
+	assert.NoError(t, json.Unmarshal([]byte(`{"specversion":"1.0","id":"2","source":"test","type":"Text","datacontenttype":"text/plain","data":"hi","sampled":true}`), &decoded))
+	assert.Equal(t, []byte("hi"), decoded.Data)
+	assert.Equal(t, "true", decoded.Extension("sampled"))
+}
+
+func TestHTTP_ShouldRoundTripBinaryMode(t *testing.T) {
+	ce, err := FromEvent(newInvoiceEvent())
+	assert.NoError(t, err)
+	req, err := NewRequest(context.Background(), "http://localhost/events", ce, ModeBinary)
+	assert.NoError(t, err)
+	assert.Equal(t, "1.0", req.Header.Get("ce-specversion"))
+	assert.Equal(t, "InvoiceIssued", req.Header.Get("ce-type"))
+	assert.Equal(t, "device%201", req.Header.Get("ce-deviceid"))
+	assert.Equal(t, ContentTypeJSON, req.Header.Get("Content-Type"))
+
+	body := make([]byte, req.ContentLength)
+	_, _ = req.Body.Read(body)
+	events, err := ReadHTTP(req.Header, body)
+	assert.NoError(t, err)
+	assert.Len(t, events, 1)
+	assert.True(t, ce.Time.Equal(events[0].Time))
+	events[0].Time = ce.Time
+	assert.Equal(t, ce, events[0])
+
+	_, err = ReadHTTP(http.Header{"Content-Type": []string{ContentTypeJSON}}, body)
+	assert.ErrorIs(t, err, ErrUnsupportedContentType)
+}
+
+func TestToEvent_ShouldRestoreTypedEventAndAttributes(t *testing.T) {
+	original := newInvoiceEvent()
+	ce, err := FromEvent(original)
+	assert.NoError(t, err)
+
+	e, err := ToEvent(context.Background(), ce, newRegistry(t))
+	assert.NoError(t, err)
+	assert.IsType(t, pubsub.MessageEvent[InvoiceIssued]{}, e)
+	decoded := e.(pubsub.MessageEvent[InvoiceIssued])
+	assert.Equal(t, original.PayloadData, decoded.PayloadData)
+	assert.Equal(t, original.Id, decoded.Id)
+	assert.Equal(t, original.Timestamp, decoded.Timestamp)
+	assert.Equal(t, original.ServiceCode, decoded.ServiceCode)
+	assert.Equal(t, &webEvent.Attributes{
+		CorrelationId: "request-1",
+		UserId:        "user-1",
+		DeviceId:      "device 1",
+	}, webEvent.GetAttributes(decoded.Context()))
+
+	_, err = ToEvent(context.Background(), &CloudEvent{SpecVersion: "0.3", Id: "1", Source: "test", Type: "Test"}, newRegistry(t))
+	assert.Error(t, err)
+}
+
+func TestHandler_ShouldPublishEventsOnLocalBus(t *testing.T) {
+	bus := pubsub.NewDefaultEventBus(pubsub.WithEventExecutor(executor.NewSyncExecutor()))
+	received := make(chan InvoiceIssued, 3)
+	_, err := bus.RegisterHandler("InvoiceIssued", func(ctx context.Context, msg InvoiceIssued) error {
+		assert.Equal(t, "request-1", webEvent.GetAttributes(ctx).CorrelationId)
+		received <- msg
+		return nil
+	})
+	assert.NoError(t, err)
+	handler := NewHandler(WithPublisher(pubsub.NewDefaultPublisher(bus)), WithRegistry(newRegistry(t)))
+
+	ce, err := FromEvent(newInvoiceEvent())
+	assert.NoError(t, err)
+	structured, err := json.Marshal(ce)
+	assert.NoError(t, err)
+	serve := func(contentType string, body string) int {
+		req := httptest.NewRequest(http.MethodPost, "/events", strings.NewReader(body))
+		req.Header.Set("Content-Type", contentType)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	// The bus is not running yet
+	assert.Equal(t, http.StatusServiceUnavailable, serve(ContentTypeStructured, string(structured)))
+
+	bus.Run()
+	defer bus.Stop()
+	assert.Equal(t, http.StatusAccepted, serve(ContentTypeStructured, string(structured)))
+	assert.Equal(t, http.StatusAccepted, serve(ContentTypeBatch, "["+string(structured)+","+string(structured)+"]"))
+	assert.Equal(t, http.StatusBadRequest, serve(ContentTypeStructured, `{"specversion":"1.0","id":"1"}`))
+	assert.Equal(t, http.StatusBadRequest, serve(ContentTypeStructured, `{`))
+	assert.Equal(t, http.StatusUnsupportedMediaType, serve("text/plain", "hello"))
+	for i := 0; i < 3; i++ {
+		select {
+		case msg := <-received:
+			assert.Equal(t, InvoiceIssued{InvoiceId: "1", Amount: 100}, msg)
+		case <-time.After(time.Second):
+			t.Fatal("event is not received")
+		}
+	}
+}
+
+type limitedPublisher struct {
+	pubsub.Publisher
+	remaining int
+}
+
+func (p *limitedPublisher) PublishCtx(ctx context.Context, event pubsub.Event) error {
+	if p.remaining == 0 {
+		return pubsub.ErrQueueFull
+	}
+	p.remaining--
+	return nil
+}
+
+func TestHandler_WhenBatchIsPartiallyPublished_ShouldReportAcceptedEvents(t *testing.T) {
+	handler := NewHandler(WithPublisher(&limitedPublisher{remaining: 1}), WithRegistry(newRegistry(t)))
+	events := make([]string, 0, 3)
+	ids := make([]string, 0, 3)
+	for i := 0; i < 3; i++ {
+		ce, err := FromEvent(newInvoiceEvent())
+		assert.NoError(t, err)
+		structured, err := json.Marshal(ce)
+		assert.NoError(t, err)
+		events = append(events, string(structured))
+		ids = append(ids, ce.Id)
+	}
+	// The last event is invalid, so no event is published
+	req := httptest.NewRequest(http.MethodPost, "/events",
+		strings.NewReader("["+strings.Join(events, ",")+`,{"specversion":"1.0","id":"1"}]`))
+	req.Header.Set("Content-Type", ContentTypeBatch)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	req = httptest.NewRequest(http.MethodPost, "/events", strings.NewReader("["+strings.Join(events, ",")+"]"))
+	req.Header.Set("Content-Type", ContentTypeBatch)
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	var res struct {
+		Data PublishResult `json:"data"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+	assert.Equal(t, PublishResult{Accepted: ids[:1], NotAccepted: ids[1:]}, res.Data)
+}
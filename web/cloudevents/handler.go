@@ -0,0 +1,133 @@
+package cloudevents
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/golibs-starter/golib/exception"
+	"github.com/golibs-starter/golib/log"
+	"github.com/golibs-starter/golib/pubsub"
+	"github.com/golibs-starter/golib/web/response"
+)
+
+const DefaultMaxBodySize = 1 << 20
+
+// Handler is a http.Handler that accepts CloudEvents in structured, batch or binary mode
+// and publishes them on the local bus. All events are decoded before any of them is published,
+// then they are published in order until an event is failed. It responds:
+//   - 202 when all events are published
+//   - 400 when events are invalid
+//   - 415 when the content type is not supported
+//   - 503 when the bus does not accept events, so that the sender can retry.
+//
+// When an event is failed, the data of the response is a PublishResult,
+// so that the sender only retries events that are not accepted.
+type Handler struct {
+	publisher   pubsub.Publisher
+	registry    *pubsub.EventTypeRegistry
+	maxBodySize int64
+}
+
+// PublishResult reports ids of events that are accepted and not accepted in a request
+type PublishResult struct {
+	Accepted    []string `json:"accepted"`
+	NotAccepted []string `json:"not_accepted"`
+}
+
+type HandlerOpt func(h *Handler)
+
+// NewHandler creates a Handler, events are published by the global publisher
+// and decoded by the global EventTypeRegistry by default.
+func NewHandler(opts ...HandlerOpt) *Handler {
+	h := &Handler{
+		registry:    pubsub.GetEventTypeRegistry(),
+		maxBodySize: DefaultMaxBodySize,
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
+}
+
+func WithPublisher(publisher pubsub.Publisher) HandlerOpt {
+	return func(h *Handler) {
+		h.publisher = publisher
+	}
+}
+
+func WithRegistry(registry *pubsub.EventTypeRegistry) HandlerOpt {
+	return func(h *Handler) {
+		h.registry = registry
+	}
+}
+
+// WithMaxBodySize limits the size of request body, default is DefaultMaxBodySize
+func WithMaxBodySize(size int64) HandlerOpt {
+	return func(h *Handler) {
+		h.maxBodySize = size
+	}
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, h.maxBodySize))
+	if err != nil {
+		response.WriteError(w, exception.NewWithCause(exception.BadRequest, err.Error()))
+		return
+	}
+	cloudEvents, err := ReadHTTP(r.Header, body)
+	if err != nil {
+		if errors.Is(err, ErrUnsupportedContentType) {
+			response.WriteError(w, exception.New(http.StatusUnsupportedMediaType, err.Error()))
+			return
+		}
+		response.WriteError(w, exception.NewWithCause(exception.BadRequest, err.Error()))
+		return
+	}
+	events := make([]pubsub.Event, 0, len(cloudEvents))
+	for _, ce := range cloudEvents {
+		// The event outlives the request, so its context is not derived from the request
+		e, err := ToEvent(context.Background(), ce, h.registry)
+		if err != nil {
+			response.WriteError(w, exception.NewWithCause(exception.BadRequest, err.Error()))
+			return
+		}
+		events = append(events, e)
+	}
+	publisher := h.publisher
+	if publisher == nil {
+		publisher = pubsub.GetPublisher()
+	}
+	for i, e := range events {
+		if err := pubsub.PublishCtxOn(r.Context(), publisher, e); err != nil {
+			log.Warnf("Cannot publish cloud event [%s] with id [%s]: %v", e.Name(), e.Identifier(), err)
+			result := PublishResult{Accepted: eventIds(events[:i]), NotAccepted: eventIds(events[i:])}
+			code, message := publishError(err)
+			response.Write(w, response.New(code, message, result))
+			return
+		}
+	}
+	response.Write(w, response.New(http.StatusAccepted, "Events are published", nil))
+}
+
+// publishError returns the status code and the message of a publishing error
+func publishError(err error) (int, string) {
+	if errors.Is(err, pubsub.ErrBusNotRunning) || errors.Is(err, pubsub.ErrBusStopped) ||
+		errors.Is(err, pubsub.ErrQueueFull) {
+		return http.StatusServiceUnavailable, "Event bus is unavailable"
+	}
+	if errors.Is(err, pubsub.ErrSchemaValidation) {
+		return http.StatusBadRequest, err.Error()
+	}
+	meta := response.Error(err).Meta
+	return meta.Code, meta.Message
+}
+
+func eventIds(events []pubsub.Event) []string {
+	ids := make([]string, 0, len(events))
+	for _, e := range events {
+		ids = append(ids, e.Identifier())
+	}
+	return ids
+}
@@ -0,0 +1,145 @@
+package cloudevents
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+var ErrUnsupportedContentType = errors.New("unsupported content type")
+
+const headerPrefix = "ce-"
+
+// Mode is the content mode of a CloudEvent in a HTTP message
+type Mode int
+
+const (
+	// ModeStructured encodes the whole event in the body as application/cloudevents+json
+	ModeStructured Mode = iota
+
+	// ModeBinary encodes attributes in `ce-*` headers and data in the body
+	ModeBinary
+)
+
+// WriteHTTP writes headers of the event to header and returns the body
+func WriteHTTP(header http.Header, ce *CloudEvent, mode Mode) ([]byte, error) {
+	if mode == ModeStructured {
+		body, err := json.Marshal(ce)
+		if err != nil {
+			return nil, err
+		}
+		header.Set("Content-Type", ContentTypeStructured)
+		return body, nil
+	}
+	setHeader(header, attrSpecVersion, ce.SpecVersion)
+	setHeader(header, attrId, ce.Id)
+	setHeader(header, attrSource, ce.Source)
+	setHeader(header, attrType, ce.Type)
+	setHeader(header, attrSubject, ce.Subject)
+	setHeader(header, attrDataSchema, ce.DataSchema)
+	if !ce.Time.IsZero() {
+		setHeader(header, attrTime, ce.Time.Format(time.RFC3339Nano))
+	}
+	for name, value := range ce.Extensions {
+		setHeader(header, name, value)
+	}
+	if ce.DataContentType != "" {
+		header.Set("Content-Type", ce.DataContentType)
+	}
+	return ce.Data, nil
+}
+
+// NewRequest creates a POST request that carries the event
+func NewRequest(ctx context.Context, url string, ce *CloudEvent, mode Mode) (*http.Request, error) {
+	header := make(http.Header)
+	body, err := WriteHTTP(header, ce, mode)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	return req, nil
+}
+
+// ReadHTTP reads events from a HTTP message, the mode is detected by the content type:
+//   - application/cloudevents+json is a structured event
+//   - application/cloudevents-batch+json is a batch of structured events
+//   - otherwise it's a binary event, which requires the `ce-specversion` header.
+func ReadHTTP(header http.Header, body []byte) ([]*CloudEvent, error) {
+	contentType := header.Get("Content-Type")
+	mediaType := ""
+	if contentType != "" {
+		var err error
+		if mediaType, _, err = mime.ParseMediaType(contentType); err != nil {
+			return nil, fmt.Errorf("%w [%s]", ErrUnsupportedContentType, contentType)
+		}
+	}
+	switch mediaType {
+	case ContentTypeStructured:
+		var ce CloudEvent
+		if err := json.Unmarshal(body, &ce); err != nil {
+			return nil, fmt.Errorf("invalid structured cloud event: %w", err)
+		}
+		return []*CloudEvent{&ce}, nil
+	case ContentTypeBatch:
+		var events []*CloudEvent
+		if err := json.Unmarshal(body, &events); err != nil {
+			return nil, fmt.Errorf("invalid batch of cloud events: %w", err)
+		}
+		return events, nil
+	}
+	if header.Get(headerPrefix+attrSpecVersion) == "" {
+		return nil, fmt.Errorf("%w [%s]", ErrUnsupportedContentType, contentType)
+	}
+	ce := CloudEvent{DataContentType: contentType}
+	for key, values := range header {
+		name := strings.ToLower(key)
+		if !strings.HasPrefix(name, headerPrefix) || len(values) == 0 {
+			continue
+		}
+		value, err := url.PathUnescape(values[0])
+		if err != nil {
+			return nil, fmt.Errorf("invalid header [%s]: %w", key, err)
+		}
+		if err := ce.setAttribute(strings.TrimPrefix(name, headerPrefix), value); err != nil {
+			return nil, err
+		}
+	}
+	if len(body) > 0 {
+		ce.Data = body
+	}
+	return []*CloudEvent{&ce}, nil
+}
+
+func setHeader(header http.Header, name string, value string) {
+	if value != "" {
+		header.Set(headerPrefix+name, escapeHeaderValue(value))
+	}
+}
+
+// escapeHeaderValue percent-encodes characters that are not printable ASCII,
+// as well as space, double quote and percent.
+func escapeHeaderValue(value string) string {
+	var sb strings.Builder
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+		if c <= ' ' || c >= 0x7f || c == '"' || c == '%' {
+			_, _ = fmt.Fprintf(&sb, "%%%02X", c)
+			continue
+		}
+		sb.WriteByte(c)
+	}
+	return sb.String()
+}
@@ -0,0 +1,109 @@
+package cloudevents
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/golibs-starter/golib/event"
+	"github.com/golibs-starter/golib/pubsub"
+	"github.com/golibs-starter/golib/web/constant"
+	webEvent "github.com/golibs-starter/golib/web/event"
+)
+
+// FromEvent maps an event to a CloudEvent:
+//   - id, event, source and timestamp of event.ApplicationEvent
+//     become id, type, source and time
+//   - the payload becomes JSON data
+//   - request id, user id, device id, service code, correlation id and causation id become extensions.
+func FromEvent(e pubsub.Event) (*CloudEvent, error) {
+	ce := &CloudEvent{
+		SpecVersion: SpecVersion,
+		Id:          e.Identifier(),
+		Source:      event.DefaultEventSource,
+		Type:        e.Name(),
+	}
+	if payload := e.Payload(); payload != nil {
+		data, err := json.Marshal(payload)
+		if err != nil {
+			return nil, fmt.Errorf("cannot encode payload of event [%s]: %w", e.Name(), err)
+		}
+		ce.DataContentType = ContentTypeJSON
+		ce.Data = data
+	}
+	var attrs *webEvent.Attributes
+	if wrapper, ok := e.(webEvent.AbstractEventWrapper); ok && wrapper.GetAbstractEvent() != nil {
+		abstractEvent := wrapper.GetAbstractEvent()
+		if abstractEvent.ApplicationEvent != nil {
+			setApplicationEvent(ce, abstractEvent.ApplicationEvent)
+			attrs = webEvent.MakeAttributes(abstractEvent)
+		}
+	} else if appEvent, ok := e.(*event.ApplicationEvent); ok {
+		setApplicationEvent(ce, appEvent)
+	}
+	if attrs == nil && e.Context() != nil {
+		attrs = webEvent.GetAttributes(e.Context())
+	}
+	if attrs != nil {
+		ce.SetExtension(ExtensionRequestId, attrs.CorrelationId)
+		ce.SetExtension(ExtensionUserId, attrs.UserId)
+		ce.SetExtension(ExtensionDeviceId, attrs.DeviceId)
+	}
+	return ce, nil
+}
+
+func setApplicationEvent(ce *CloudEvent, appEvent *event.ApplicationEvent) {
+	if appEvent.Source != "" {
+		ce.Source = appEvent.Source
+	}
+	if appEvent.Timestamp > 0 {
+		ce.Time = time.UnixMilli(appEvent.Timestamp).UTC()
+	}
+	ce.SetExtension(ExtensionServiceCode, appEvent.ServiceCode)
+	ce.SetExtension(ExtensionCorrelationId, appEvent.CorrelationId)
+	ce.SetExtension(ExtensionCausationId, appEvent.CausationId)
+}
+
+// ToEvent maps a CloudEvent to an event by the registry, so that registered types
+// such as MessageEvent[OrderCreated] are restored, other types are mapped to *event.AbstractEvent.
+// Attributes of the event are restored into a context derived from ctx.
+func ToEvent(ctx context.Context, ce *CloudEvent, registry *pubsub.EventTypeRegistry) (pubsub.Event, error) {
+	if err := ce.Validate(); err != nil {
+		return nil, err
+	}
+	timestamp := ce.Time
+	if timestamp.IsZero() {
+		timestamp = time.Now()
+	}
+	doc := map[string]interface{}{
+		"id":           ce.Id,
+		"event":        ce.Type,
+		"source":       ce.Source,
+		"service_code": ce.Extension(ExtensionServiceCode),
+		"timestamp":    timestamp.UnixMilli(),
+		"request_id":   ce.Extension(ExtensionRequestId),
+		"user_id":      ce.Extension(ExtensionUserId),
+	}
+	if correlationId := ce.Extension(ExtensionCorrelationId); correlationId != "" {
+		doc["correlation_id"] = correlationId
+	}
+	if causationId := ce.Extension(ExtensionCausationId); causationId != "" {
+		doc["causation_id"] = causationId
+	}
+	if deviceId := ce.Extension(ExtensionDeviceId); deviceId != "" {
+		doc["additional_data"] = map[string]interface{}{constant.HeaderDeviceId: deviceId}
+	}
+	if len(ce.Data) > 0 {
+		if isJSON(ce.DataContentType) {
+			doc["payload"] = json.RawMessage(ce.Data)
+		} else {
+			doc["payload"] = string(ce.Data)
+		}
+	}
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return nil, fmt.Errorf("cannot map cloud event [%s]: %w", ce.Id, err)
+	}
+	return registry.DecodeNamed(ctx, ce.Type, data)
+}
@@ -37,12 +37,21 @@ func NewAbstractEvent(ctx context.Context, name string, options ...event.AppEven
 			evt.AddAdditionData(constant.HeaderDeviceSessionId, reqAttrs.DeviceSessionId)
 		}
 	}
-	if attrs := MakeAttributes(&evt); attrs != nil {
-		evt.Ctx = context.WithValue(ctx, constant.ContextEventAttributes, MakeAttributes(&evt))
+	// The first event of a request correlates to the request
+	if evt.CausationId == "" && evt.CorrelationId == evt.Id && evt.RequestId != "" {
+		evt.CorrelationId = evt.RequestId
 	}
+	evt.Ctx = NewContext(ctx, &evt)
 	return &evt
 }
 
+// NewContext returns a copy of ctx that carries attributes of the event,
+// and the event as the cause of follow-up events (see event.Cause).
+func NewContext(ctx context.Context, e *AbstractEvent) context.Context {
+	ctx = context.WithValue(ctx, constant.ContextEventAttributes, MakeAttributes(e))
+	return event.WithCause(ctx, e.AsCause())
+}
+
 func (a *AbstractEvent) String() string {
 	return a.ToString(a)
 }
@@ -61,6 +61,28 @@ func TestNewAbstractEvent_GivenANameAndRequestAttribute_ShouldInitCorrectly(t *t
 	}, e.AdditionalData)
 }
 
+func TestNewAbstractEvent_WhenCreatedFromContextOfAnotherEvent_ShouldBeInSameCorrelationChain(t *testing.T) {
+	attr := context2.RequestAttributes{CorrelationId: "request-1"}
+	ctx := context.WithValue(context.Background(), constant.ContextReqAttribute, &attr)
+	first := NewAbstractEvent(ctx, "OrderCreated")
+	assert.Equal(t, "request-1", first.CorrelationId)
+	assert.Empty(t, first.CausationId)
+
+	second := NewAbstractEvent(first.Context(), "PaymentRequested")
+	assert.Equal(t, "request-1", second.CorrelationId)
+	assert.Equal(t, first.Id, second.CausationId)
+
+	third := NewAbstractEvent(second.Context(), "PaymentCaptured")
+	assert.Equal(t, "request-1", third.CorrelationId)
+	assert.Equal(t, second.Id, third.CausationId)
+	assert.Equal(t, &event.Cause{EventId: third.Id, CorrelationId: "request-1", CausationId: second.Id},
+		event.GetCause(third.Context()))
+
+	// Without a request, the first event correlates to itself
+	standalone := NewAbstractEvent(context.Background(), "JobStarted")
+	assert.Equal(t, standalone.Id, standalone.CorrelationId)
+}
+
 func TestNewAbstractEvent_GivenANameAndOptions_ShouldRunOptionsCorrectly(t *testing.T) {
 	eventName := "TestEvent"
 	payload := map[string]string{"a": "a"}
@@ -18,11 +18,6 @@ type RequestCompletedLogListener struct {
 	httpRequestProps *properties.HttpRequestLogProperties
 }
 
-// RegisterHandler implements pubsub.Subscriber.
-func (r *RequestCompletedLogListener) RegisterHandler(topicName string, handler any) {
-	panic("unimplemented")
-}
-
 func NewRequestCompletedLogListener(
 	appProps *config.AppProperties,
 	httpRequestProps *properties.HttpRequestLogProperties,
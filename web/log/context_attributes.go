@@ -1,6 +1,7 @@
 package log
 
 import (
+	baseEvent "github.com/golibs-starter/golib/event"
 	"github.com/golibs-starter/golib/log/field"
 	webContext "github.com/golibs-starter/golib/web/context"
 	"github.com/golibs-starter/golib/web/event"
@@ -12,6 +13,12 @@ type ContextAttributes struct {
 	DeviceId          string `json:"device_id,omitempty"`
 	DeviceSessionId   string `json:"device_session_id,omitempty"`
 	TechnicalUsername string `json:"technical_username,omitempty"`
+
+	// EventId, EventCorrelationId and EventCausationId describe
+	// the event that is being handled, see event.Cause
+	EventId            string `json:"event_id,omitempty"`
+	EventCorrelationId string `json:"correlation_id,omitempty"`
+	EventCausationId   string `json:"causation_id,omitempty"`
 }
 
 func (c ContextAttributes) MarshalLogObject(encoder field.ObjectEncoder) error {
@@ -30,6 +37,15 @@ func (c ContextAttributes) MarshalLogObject(encoder field.ObjectEncoder) error {
 	if c.TechnicalUsername != "" {
 		encoder.AddString("technical_username", c.TechnicalUsername)
 	}
+	if c.EventId != "" {
+		encoder.AddString("event_id", c.EventId)
+	}
+	if c.EventCorrelationId != "" {
+		encoder.AddString("correlation_id", c.EventCorrelationId)
+	}
+	if c.EventCausationId != "" {
+		encoder.AddString("causation_id", c.EventCausationId)
+	}
 	return nil
 }
 
@@ -43,6 +59,14 @@ func NewContextAttributesFromReqAttr(requestAttributes *webContext.RequestAttrib
 	}
 }
 
+// WithCause sets attributes of the event that is being handled
+func (c *ContextAttributes) WithCause(cause *baseEvent.Cause) *ContextAttributes {
+	c.EventId = cause.EventId
+	c.EventCorrelationId = cause.CorrelationId
+	c.EventCausationId = cause.CausationId
+	return c
+}
+
 func NewContextAttributesFromEventAttrs(attributes *event.Attributes) *ContextAttributes {
 	return &ContextAttributes{
 		DeviceId:          attributes.DeviceId,
@@ -2,22 +2,32 @@ package log
 
 import (
 	"context"
+	baseEvent "github.com/golibs-starter/golib/event"
 	"github.com/golibs-starter/golib/log/field"
 	"github.com/golibs-starter/golib/web/constant"
 	webContext "github.com/golibs-starter/golib/web/context"
 	"github.com/golibs-starter/golib/web/event"
 )
 
+// ContextExtractor extracts attributes of the request or the event from ctx,
+// when an event is being handled, its id, correlation id and causation id are included.
 func ContextExtractor(ctx context.Context) []field.Field {
+	var attributes *ContextAttributes
 	if requestAttributes := webContext.GetRequestAttributes(ctx); requestAttributes != nil {
-		return []field.Field{
-			field.Object(constant.ContextReqMeta, NewContextAttributesFromReqAttr(requestAttributes)),
-		}
+		attributes = NewContextAttributesFromReqAttr(requestAttributes)
+	} else if eventAttributes := event.GetAttributes(ctx); eventAttributes != nil {
+		attributes = NewContextAttributesFromEventAttrs(eventAttributes)
 	}
-	if eventAttributes := event.GetAttributes(ctx); eventAttributes != nil {
-		return []field.Field{
-			field.Object(constant.ContextReqMeta, NewContextAttributesFromEventAttrs(eventAttributes)),
+	if cause := baseEvent.GetCause(ctx); cause != nil {
+		if attributes == nil {
+			attributes = &ContextAttributes{}
 		}
+		attributes.WithCause(cause)
+	}
+	if attributes == nil {
+		return nil
+	}
+	return []field.Field{
+		field.Object(constant.ContextReqMeta, attributes),
 	}
-	return nil
 }
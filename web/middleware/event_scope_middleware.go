@@ -0,0 +1,40 @@
+package middleware
+
+import (
+	"github.com/golibs-starter/golib/log"
+	"github.com/golibs-starter/golib/pubsub"
+	"net/http"
+)
+
+// EventScope middleware responsible to collect events of the request by pubsub.Collect,
+// they are published when the response status is 2xx, otherwise they are discarded.
+// This middleware should be run after AdvancedResponseWriter to detect the response status.
+func EventScope() func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := pubsub.WithEventScope(r.Context())
+			scope := pubsub.GetEventScope(ctx)
+			committed := false
+			defer func() {
+				if !committed {
+					scope.Rollback()
+				}
+			}()
+			next.ServeHTTP(w, r.WithContext(ctx))
+			advancedResponseWriter, err := getAdvancedResponseWriter(w)
+			if err != nil {
+				log.WithCtx(ctx).WithErrors(err).Warn("Cannot detect AdvancedResponseWriter, collected events are discarded")
+				return
+			}
+			if status := advancedResponseWriter.Status(); status < 200 || status >= 300 {
+				log.WithCtx(ctx).Debugf("Response status is [%d], [%d] collected events are discarded",
+					status, len(scope.Events()))
+				return
+			}
+			committed = true
+			if err := scope.Commit(); err != nil {
+				log.WithCtx(ctx).WithErrors(err).Warn("Cannot publish collected events")
+			}
+		})
+	}
+}
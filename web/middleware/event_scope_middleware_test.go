@@ -0,0 +1,40 @@
+package middleware
+
+import (
+	"github.com/golibs-starter/golib/pubsub"
+	"github.com/golibs-starter/golib/pubsub/pubsubtest"
+	"github.com/golibs-starter/golib/web/context"
+	"github.com/golibs-starter/golib/web/event"
+	assert "github.com/stretchr/testify/require"
+	"net/http"
+	"testing"
+)
+
+func serveEventScope(t *testing.T, w http.ResponseWriter, responseStatus int) {
+	handler := EventScope()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.NotNil(t, pubsub.GetEventScope(r.Context()))
+		assert.NoError(t, pubsub.Collect(r.Context(), event.NewAbstractEvent(r.Context(), "OrderCreated")))
+		assert.NoError(t, pubsub.Collect(r.Context(), event.NewAbstractEvent(r.Context(), "OrderPaid")))
+		w.WriteHeader(responseStatus)
+	}))
+	r, _ := http.NewRequest("POST", "/orders", nil)
+	handler.ServeHTTP(w, r)
+}
+
+func TestEventScope_WhenResponseIsSuccessful_ShouldPublishCollectedEvents(t *testing.T) {
+	publisher := pubsubtest.SwapGlobal(t)
+	serveEventScope(t, context.NewAdvancedResponseWriter(&mockResponseWriter{}), http.StatusCreated)
+	assert.Equal(t, []string{"OrderCreated", "OrderPaid"}, publisher.Recorder.EventNames())
+}
+
+func TestEventScope_WhenResponseIsNotSuccessful_ShouldDiscardCollectedEvents(t *testing.T) {
+	publisher := pubsubtest.SwapGlobal(t)
+	serveEventScope(t, context.NewAdvancedResponseWriter(&mockResponseWriter{}), http.StatusBadRequest)
+	assert.Empty(t, publisher.Recorder.EventNames())
+}
+
+func TestEventScope_WhenResponseWriterIsNotAdvanced_ShouldDiscardCollectedEvents(t *testing.T) {
+	publisher := pubsubtest.SwapGlobal(t)
+	serveEventScope(t, &mockResponseWriter{}, http.StatusOK)
+	assert.Empty(t, publisher.Recorder.EventNames())
+}
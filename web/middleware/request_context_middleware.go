@@ -47,7 +47,7 @@ func getAdvancedResponseWriter(w http.ResponseWriter) (*context.AdvancedResponse
 }
 
 func publishRequestCompletedEvent(ctx mainContext.Context, requestAttributes *context.RequestAttributes) {
-	pubsub.Publish(event.NewRequestCompletedEvent(ctx, &event.RequestCompletedMessage{
+	err := pubsub.PublishCtx(ctx, event.NewRequestCompletedEvent(ctx, &event.RequestCompletedMessage{
 		Status:            requestAttributes.StatusCode,
 		ExecutionTime:     requestAttributes.ExecutionTime,
 		Uri:               requestAttributes.Uri,
@@ -64,4 +64,7 @@ func publishRequestCompletedEvent(ctx mainContext.Context, requestAttributes *co
 		UserId:            requestAttributes.SecurityAttributes.UserId,
 		TechnicalUsername: requestAttributes.SecurityAttributes.TechnicalUsername,
 	}))
+	if err != nil {
+		log.WithCtx(ctx).WithErrors(err).Warn("Cannot publish RequestCompletedEvent")
+	}
 }